@@ -0,0 +1,179 @@
+// คำสั่ง library ส่งออกและนำเข้าคลังกิจกรรมจาก command line โดยใช้ฐานข้อมูลและ storage ตามค่า env เดียวกับ server
+//
+//	library export -o library.zip [-ids 1,2,3]
+//	library import -f library.zip [-dry-run] [-skip-conflicts] [-on-duplicate skip|create] [-owner 1]
+//
+// ไฟล์สื่อที่นำเข้าจะมีสถานะ pending และ server จะสร้างภาพย่อให้เองเมื่อ thumbnail worker ตรวจพบ
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"project-backend/config"
+	"project-backend/controllers"
+	"project-backend/db"
+	"project-backend/library"
+	"project-backend/models"
+	"project-backend/storage"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  library export -o <file.zip> [-ids 1,2,3]")
+	fmt.Fprintln(os.Stderr, "  library import -f <file.zip> [-dry-run] [-skip-conflicts] [-on-duplicate skip|create] [-owner <user id>]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	_ = godotenv.Load()
+
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func connect() (*gorm.DB, storage.Storage) {
+	gormDB, err := db.InitDB(config.GetDBConfig())
+	if err != nil {
+		log.Fatalf("Database connection failed: %v", err)
+	}
+	storageCfg := config.GetStorageConfig()
+	store, err := storage.New(storageCfg)
+	if err != nil {
+		log.Fatalf("Storage initialization failed: %v", err)
+	}
+	models.SetMediaBaseURL(storageCfg.PublicBaseURL)
+	return gormDB, store
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("o", "", "output zip file")
+	rawIDs := fs.String("ids", "", "comma-separated activity IDs (default: whole library)")
+	fs.Parse(args)
+	if *out == "" {
+		usage()
+	}
+
+	var ids []uint
+	for _, part := range strings.Split(*rawIDs, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid activity id %q", part)
+		}
+		ids = append(ids, uint(id))
+	}
+
+	gormDB, store := connect()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Could not create %s: %v", *out, err)
+	}
+	manifest, err := library.Export(context.Background(), gormDB, store, f, library.ExportOptions{ActivityIDs: ids})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*out)
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	printJSON(manifest)
+	log.Printf("Exported %d activities to %s", manifest.Counts.Activities, *out)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	in := fs.String("f", "", "package zip file")
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	skipConflicts := fs.Bool("skip-conflicts", false, "import even if some taxonomy entries conflict")
+	onDuplicate := fs.String("on-duplicate", library.OnDuplicateSkip, "what to do with activities whose title already exists: skip or create")
+	owner := fs.Uint("owner", 0, "user ID recorded as the creator of imported activities (default: first admin)")
+	fs.Parse(args)
+	if *in == "" {
+		usage()
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("Could not open %s: %v", *in, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatalf("Could not read %s: %v", *in, err)
+	}
+	pkg, err := library.Open(f, info.Size())
+	if err != nil {
+		log.Fatalf("Invalid package: %v", err)
+	}
+
+	gormDB, store := connect()
+
+	ownerID := *owner
+	if ownerID == 0 {
+		var admin models.User
+		err := gormDB.Joins("JOIN roles ON roles.id = users.role_id").
+			Where("roles.role_name = ?", "admin").
+			Order("users.id ASC").
+			First(&admin).Error
+		if err != nil {
+			log.Fatalf("No admin user found, pass -owner: %v", err)
+		}
+		ownerID = admin.ID
+	}
+
+	report, err := library.Import(context.Background(), gormDB, store, pkg, library.ImportOptions{
+		DryRun:        *dryRun,
+		SkipConflicts: *skipConflicts,
+		OnDuplicate:   *onDuplicate,
+		OwnerID:       ownerID,
+		QR:            config.GetQRConfig(),
+		MaxMediaBytes: config.GetStorageConfig().MaxUploadBytes,
+		Validate:      controllers.ValidateImportedActivity,
+	}, nil)
+	if report != nil {
+		printJSON(report)
+	}
+	if errors.Is(err, library.ErrConflicts) {
+		log.Fatalf("%v; rerun with -skip-conflicts to import anyway", err)
+	}
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	if *dryRun {
+		log.Printf("Dry run: %d activities would be created, %d skipped", report.Created, report.Skipped)
+		return
+	}
+	log.Printf("Imported %d activities, %d skipped", report.Created, report.Skipped)
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
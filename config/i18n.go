@@ -0,0 +1,20 @@
+package config
+
+import "strings"
+
+// LocaleConfig กำหนดภาษาที่ระบบรองรับ ภาษาแรกคือภาษาต้นฉบับของข้อมูล (ภาษาไทย)
+type LocaleConfig struct {
+	Supported []string
+}
+
+func GetLocaleConfig() *LocaleConfig {
+	raw := getEnv("SUPPORTED_LOCALES", "th,en")
+
+	var locales []string
+	for _, l := range strings.Split(raw, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			locales = append(locales, l)
+		}
+	}
+	return &LocaleConfig{Supported: locales}
+}
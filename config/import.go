@@ -0,0 +1,18 @@
+package config
+
+// ImportConfig กำหนดขีดจำกัดของการนำเข้าข้อมูลจากไฟล์
+// ไฟล์ที่มีจำนวนแถวมากกว่า AsyncRows จะถูกนำเข้าแบบ background job
+// MaxLibraryBytes ใช้กับ package คลังกิจกรรม (zip) ซึ่งรวมไฟล์สื่อจึงใหญ่กว่าไฟล์ตารางมาก
+type ImportConfig struct {
+	MaxUploadBytes  int64
+	MaxLibraryBytes int64
+	AsyncRows       int
+}
+
+func GetImportConfig() *ImportConfig {
+	return &ImportConfig{
+		MaxUploadBytes:  int64(getEnvInt("IMPORT_MAX_MB", 20)) << 20,
+		MaxLibraryBytes: int64(getEnvInt("LIBRARY_IMPORT_MAX_MB", 1024)) << 20,
+		AsyncRows:       getEnvInt("IMPORT_ASYNC_ROWS", 200),
+	}
+}
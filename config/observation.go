@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// ObservationConfig กำหนดระยะเวลาหลัง session จบที่ยังบันทึกหรือแก้ไขผลการสังเกตได้
+// และจำนวนผลการสังเกตสูงสุดต่อการส่งครั้งเดียว (จากแท็บเล็ต)
+type ObservationConfig struct {
+	EditWindow time.Duration
+	MaxBulk    int
+}
+
+func GetObservationConfig() *ObservationConfig {
+	return &ObservationConfig{
+		EditWindow: time.Duration(getEnvInt("OBSERVATION_EDIT_WINDOW_HOURS", 72)) * time.Hour,
+		MaxBulk:    getEnvInt("OBSERVATION_MAX_BULK", 200),
+	}
+}
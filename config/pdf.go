@@ -0,0 +1,19 @@
+package config
+
+// PDFConfig กำหนดฟอนต์และขีดจำกัดของการส่งออกเอกสาร PDF
+// ฟอนต์ต้องรองรับภาษาไทยและถูกฝังลงในไฟล์ PDF ทุกไฟล์
+type PDFConfig struct {
+	FontDir     string
+	FontRegular string
+	FontBold    string
+	BookletMax  int
+}
+
+func GetPDFConfig() *PDFConfig {
+	return &PDFConfig{
+		FontDir:     getEnv("PDF_FONT_DIR", "assets/fonts"),
+		FontRegular: getEnv("PDF_FONT_REGULAR", "Sarabun-Regular.ttf"),
+		FontBold:    getEnv("PDF_FONT_BOLD", "Sarabun-Bold.ttf"),
+		BookletMax:  getEnvInt("PDF_BOOKLET_MAX", 50),
+	}
+}
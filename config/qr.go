@@ -0,0 +1,18 @@
+package config
+
+// QRConfig กำหนดค่าเริ่มต้นของ QR code ที่ระบบสร้างให้กิจกรรม
+type QRConfig struct {
+	DefaultSize  int
+	DefaultLevel string
+	MinSize      int
+	MaxSize      int
+}
+
+func GetQRConfig() *QRConfig {
+	return &QRConfig{
+		DefaultSize:  getEnvInt("QR_DEFAULT_SIZE", 256),
+		DefaultLevel: getEnv("QR_DEFAULT_LEVEL", "M"),
+		MinSize:      64,
+		MaxSize:      getEnvInt("QR_MAX_SIZE", 2048),
+	}
+}
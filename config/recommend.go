@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// RecommendConfig กำหนดรอบการคำนวณโมเดลแนะนำกิจกรรมและน้ำหนักของแต่ละแหล่ง
+// ContentWeight เป็นสัดส่วน (0-1) ของคะแนนจากเป้าหมาย/หมวดหมู่ที่ผู้ใช้สนใจ ส่วนที่เหลือมาจาก co-occurrence
+type RecommendConfig struct {
+	Interval       time.Duration
+	PopularityDays int
+	Neighbors      int
+	ContentWeight  float64
+	DefaultLimit   int
+	MaxLimit       int
+}
+
+func GetRecommendConfig() *RecommendConfig {
+	weight := float64(getEnvInt("RECOMMEND_CONTENT_WEIGHT_PERCENT", 50)) / 100
+	if weight < 0 {
+		weight = 0
+	}
+	if weight > 1 {
+		weight = 1
+	}
+	return &RecommendConfig{
+		Interval:       time.Duration(getEnvInt("RECOMMEND_INTERVAL_MINUTES", 60)) * time.Minute,
+		PopularityDays: getEnvInt("RECOMMEND_POPULARITY_DAYS", 90),
+		Neighbors:      getEnvInt("RECOMMEND_NEIGHBORS", 50),
+		ContentWeight:  weight,
+		DefaultLimit:   getEnvInt("RECOMMEND_DEFAULT_LIMIT", 10),
+		MaxLimit:       getEnvInt("RECOMMEND_MAX_LIMIT", 50),
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// RelatedConfig กำหนดจำนวนกิจกรรมที่เกี่ยวข้องที่คืนต่อครั้ง และอายุของผลลัพธ์ที่เก็บไว้ใน cache
+type RelatedConfig struct {
+	DefaultLimit int
+	MaxLimit     int
+	CacheTTL     time.Duration
+}
+
+func GetRelatedConfig() *RelatedConfig {
+	return &RelatedConfig{
+		DefaultLimit: getEnvInt("RELATED_DEFAULT_LIMIT", 6),
+		MaxLimit:     getEnvInt("RELATED_MAX_LIMIT", 20),
+		CacheTTL:     time.Duration(getEnvInt("RELATED_CACHE_TTL_SECONDS", 600)) * time.Second,
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// StorageConfig กำหนดที่เก็บไฟล์อัปโหลด (local disk หรือ S3-compatible เช่น MinIO)
+type StorageConfig struct {
+	Backend       string
+	LocalDir      string
+	PublicBaseURL string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+
+	MaxUploadBytes int64
+	MaxImageWidth  int
+	MaxImageHeight int
+
+	ThumbnailWorkers int
+}
+
+func GetStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Backend:       getEnv("STORAGE_BACKEND", "local"),
+		LocalDir:      getEnv("UPLOAD_DIR", "uploads"),
+		PublicBaseURL: getEnv("MEDIA_BASE_URL", ""),
+
+		S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:    getEnv("S3_BUCKET", "music-therapy"),
+		S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		S3UseSSL:    getEnvBool("S3_USE_SSL", true),
+
+		MaxUploadBytes: int64(getEnvInt("MAX_UPLOAD_MB", 10)) << 20,
+		MaxImageWidth:  getEnvInt("MAX_IMAGE_WIDTH", 6000),
+		MaxImageHeight: getEnvInt("MAX_IMAGE_HEIGHT", 6000),
+
+		ThumbnailWorkers: getEnvInt("THUMBNAIL_WORKERS", 2),
+	}
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -1,52 +1,183 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"project-backend/config"
+	"project-backend/i18n"
 	"project-backend/models"
+	"project-backend/qrcodes"
+	"project-backend/related"
+	"project-backend/richtext"
 
 	"github.com/gin-gonic/gin"
 
 	"gorm.io/gorm"
 )
 
-func CreateActivity(db *gorm.DB) gin.HandlerFunc {
+// activityCreateInput คือข้อมูลสร้างกิจกรรม ใช้ตรวจกิจกรรมที่นำเข้าจากไฟล์ด้วย (ดู ValidateImportedActivity)
+type activityCreateInput struct {
+	Title string `json:"title" binding:"notblank,max=200"`
+
+	CoverImage string `json:"cover_image" binding:"omitempty,max=2048,weburl"`
+
+	GoalDescription string `json:"goal_description" binding:"max=20000"`
+
+	Equipment string `json:"equipment" binding:"max=2000"`
+
+	Process string `json:"process_markdown" binding:"max=20000"`
+
+	ObservableBehavior string `json:"observable_behavior_markdown" binding:"max=20000"`
+
+	Suggestion string `json:"suggestion_markdown" binding:"max=20000"`
+
+	LegacyRichTextInput
+
+	Song string `json:"song" binding:"max=2000"`
+
+	SongImage string `json:"song_image" binding:"omitempty,max=2048,weburl"`
+
+	QR1 string `json:"qr_1" binding:"omitempty,max=2048,weburl"`
+
+	QR2 string `json:"qr_2" binding:"omitempty,max=2048,weburl"`
+
+	// URL ปลายทางของ QR ให้เซิร์ฟเวอร์สร้างภาพ QR เอง
+	QR1Target string `json:"qr_1_target" binding:"max=2048"`
+
+	QR2Target string `json:"qr_2_target" binding:"max=2048"`
+
+	QR1Level string `json:"qr_1_level"`
+
+	QR2Level string `json:"qr_2_level"`
+
+	SubGoalIDs []uint `json:"sub_goal_ids"`
+
+	SubCategoryIDs []uint `json:"sub_category_ids"`
+
+	Steps []ActivityStepInput `json:"steps" binding:"dive"`
+
+	Variants []ActivityVariantInput `json:"variants" binding:"dive"`
+
+	EquipmentItems []ActivityEquipmentInput `json:"equipment_items" binding:"dive"`
+
+	SongIDs []uint `json:"song_ids"`
+
+	TargetPopulationIDs []uint `json:"target_population_ids"`
+
+	ActivityAttributesInput
+
+	// draft หรือ published (ค่าเริ่มต้น)
+	Status string `json:"status" binding:"omitempty,oneof=draft published"`
+
+	CoverMediaID *uint `json:"cover_media_id"`
+
+	SongImageMediaID *uint `json:"song_image_media_id"`
+}
+
+func CreateActivity(db *gorm.DB, qrCfg *config.QRConfig, relatedCache *related.Cache) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
-		var input struct {
-			Title string `json:"title"`
+		var input activityCreateInput
+
+		if !bindValidJSON(c, &input) {
 
-			CoverImage string `json:"cover_image"`
+			return
+
+		}
 
-			GoalDescription string `json:"goal_description"`
+		if input.Status == "" {
 
-			Equipment string `json:"equipment"`
+			input.Status = models.ActivityStatusPublished
 
-			Process string `json:"process"`
+		}
+
+		// ตรวจข้อมูลที่อ้างอิงถึงทั้งหมดก่อน แล้วตอบข้อผิดพลาดรายฟิลด์พร้อมกันครั้งเดียว
+		fields := fieldErrors{}
 
-			ObservableBehavior string `json:"observable_behavior"`
+		input.LegacyRichTextInput.applyTo(&input.Process, &input.ObservableBehavior, &input.Suggestion, fields)
 
-			Suggestion string `json:"suggestion"`
+		if err := validateActivitySteps(db, 0, input.Steps); err != nil {
 
-			Song string `json:"song"`
+			fields.add("steps", "%v", err)
 
-			SongImage string `json:"song_image"`
+		}
 
-			QR1 string `json:"qr_1"`
+		equipment, songs, err := variantReferences(db, 0, activityEquipmentIDs(input.EquipmentItems), append([]uint{}, input.SongIDs...))
 
-			QR2 string `json:"qr_2"`
+		if err == nil {
 
-			SubGoalIDs []uint `json:"sub_goal_ids"`
+			err = validateActivityVariants(db, 0, input.Variants, equipment, songs)
 
-			SubCategoryIDs []uint `json:"sub_category_ids"`
 		}
 
-		if err := c.ShouldBindJSON(&input); err != nil {
+		if err != nil {
 
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			fields.add("variants", "%v", err)
 
-			return
+		}
+
+		if err := validateActivityEquipment(db, input.EquipmentItems); err != nil {
+
+			fields.add("equipment_items", "%v", err)
+
+		}
+
+		qrTargets := []qrTargetInput{
+			{Slot: 1, Target: &input.QR1Target, Level: input.QR1Level},
+			{Slot: 2, Target: &input.QR2Target, Level: input.QR2Level},
+		}
+
+		validateQRTargets(qrTargets, fields)
+
+		selectedSongs, err := findSongsByIDs(db, input.SongIDs)
+
+		if err != nil {
+
+			fields.add("song_ids", "%v", err)
+
+		}
+
+		selectedPopulations, err := findTargetPopulationsByIDs(db, input.TargetPopulationIDs)
+
+		if err != nil {
+
+			fields.add("target_population_ids", "%v", err)
+
+		}
+
+		coverMedia, err := resolveMediaRef(db, c, "cover_media_id", input.CoverMediaID, models.MediaPurposeActivityCover)
+
+		if err != nil {
+
+			fields.add("cover_media_id", "%v", err)
+
+		}
+
+		songImageMedia, err := resolveMediaRef(db, c, "song_image_media_id", input.SongImageMediaID, models.MediaPurposeSongImage)
+
+		if err != nil {
+
+			fields.add("song_image_media_id", "%v", err)
+
+		}
+
+		// กิจกรรมใหม่เลือกได้เฉพาะ taxonomy ที่ยังไม่ถูกยกเลิก
+		selectedSubGoals, err := findSelectableSubGoals(db, input.SubGoalIDs, nil)
+
+		if err != nil {
+
+			fields.add("sub_goal_ids", "%v", err)
+
+		}
+
+		selectedSubCats, err := findSelectableSubCategories(db, input.SubCategoryIDs, nil)
+
+		if err != nil {
+
+			fields.add("sub_category_ids", "%v", err)
 
 		}
 
@@ -62,17 +193,9 @@ func CreateActivity(db *gorm.DB) gin.HandlerFunc {
 
 		userID := val.(uint)
 
-		var selectedSubGoals []models.ActivitySubGoal
-
-		db.Where("id IN ?", input.SubGoalIDs).Find(&selectedSubGoals)
-
-		var selectedSubCats []models.ActivitySubCategory
-
-		db.Where("id IN ?", input.SubCategoryIDs).Find(&selectedSubCats)
-
 		activity := models.Activity{
 
-			Title: input.Title,
+			Title: strings.TrimSpace(input.Title),
 
 			CoverImage: input.CoverImage,
 
@@ -96,24 +219,176 @@ func CreateActivity(db *gorm.DB) gin.HandlerFunc {
 
 			AdminID: userID,
 
+			Status: input.Status,
+
 			SubGoals: selectedSubGoals,
 
 			SubCategories: selectedSubCats,
+
+			Songs: selectedSongs,
+
+			CoverMediaID: input.CoverMediaID,
+
+			SongImageMediaID: input.SongImageMediaID,
+
+			TargetPopulations: selectedPopulations,
+		}
+
+		if err := input.ActivityAttributesInput.apply(&activity); err != nil {
+
+			addAttributeError(fields, err)
+
+		}
+
+		if len(fields) > 0 {
+
+			respondValidation(c, fields)
+
+			return
+
+		}
+
+		// เก็บ URL ของไฟล์ไว้ในฟิลด์ข้อความเดิมด้วย เพื่อให้ client รุ่นเก่ายังแสดงรูปได้
+		if coverMedia != nil {
+			activity.CoverImage = coverMedia.URL
 		}
+		if songImageMedia != nil {
+			activity.SongImage = songImageMedia.URL
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+
+			if err := tx.Omit("Steps", "EquipmentItems", "Variants").Create(&activity).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := replaceActivitySteps(tx, activity.ID, input.Steps); err != nil {
+
+				return err
+
+			}
+
+			if err := replaceActivityVariants(tx, activity.ID, input.Variants); err != nil {
+
+				return err
+
+			}
+
+			if err := replaceActivityEquipment(tx, activity.ID, input.EquipmentItems); err != nil {
+
+				return err
 
-		if err := db.Debug().Create(&activity).Error; err != nil {
+			}
+
+			return applyQRTargets(tx, qrCfg, activity.ID, qrTargets)
+
+		})
+
+		if err != nil {
 
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
 			return
 
 		}
+
+		if len(activity.SubGoals) > 0 || len(activity.SubCategories) > 0 {
+
+			relatedCache.Reset()
+
+		}
+
+		preloadActivityDetails(db).First(&activity, activity.ID)
+
 		c.JSON(http.StatusCreated, activity)
 	}
 
 }
 
-func UpdateActivity(db *gorm.DB) gin.HandlerFunc {
+// LegacyRichTextInput รับชื่อฟิลด์เดิมก่อนเปลี่ยนเป็น Markdown (process, observable_behavior, suggestion)
+// client เดิมที่ยังส่งชื่อเดิมมากับ PUT จึงไม่ทำให้ข้อความถูกล้างโดยไม่รู้ตัว
+// ข้อความที่เป็น HTML ถูกแปลงเป็น Markdown แบบเดียวกับ migrations.MigrateRichText
+type LegacyRichTextInput struct {
+	LegacyProcess            *string `json:"process" binding:"omitempty,max=20000"`
+	LegacyObservableBehavior *string `json:"observable_behavior" binding:"omitempty,max=20000"`
+	LegacySuggestion         *string `json:"suggestion" binding:"omitempty,max=20000"`
+}
+
+// legacyMarkdown แปลงข้อความจากชื่อฟิลด์เดิมเป็น Markdown
+func legacyMarkdown(text string) string {
+	if richtext.LooksLikeHTML(text) {
+		return richtext.FromHTML(text)
+	}
+	return text
+}
+
+// applyTo ใช้ค่าจากชื่อฟิลด์เดิมเมื่อไม่ได้ส่งชื่อใหม่ (*_markdown) มา ส่งทั้งสองชื่อด้วยค่าต่างกันเป็นข้อผิดพลาด
+func (in *LegacyRichTextInput) applyTo(process, observableBehavior, suggestion *string, fields fieldErrors) {
+	apply := func(name string, legacy, target *string) {
+		if legacy == nil {
+			return
+		}
+		text := legacyMarkdown(*legacy)
+		switch {
+		case *target == "":
+			*target = text
+		case *target != text:
+			fields.add(name, "send either %s or %s_markdown, not both", name, name)
+		}
+	}
+	apply("process", in.LegacyProcess, process)
+	apply("observable_behavior", in.LegacyObservableBehavior, observableBehavior)
+	apply("suggestion", in.LegacySuggestion, suggestion)
+}
+
+// activityUpdateInput คือข้อมูลแก้ไขกิจกรรมแบบ PUT ซึ่งแทนที่ข้อความทุกฟิลด์ด้วยค่าที่ส่งมา
+// PatchActivity เติมค่าเดิมลงในฟิลด์ที่ไม่ได้ส่งมาแล้วใช้ struct นี้ตรวจและบันทึกต่อ
+type activityUpdateInput struct {
+	Title              string `json:"title" binding:"notblank,max=200"`
+	GoalDescription    string `json:"goal_description" binding:"max=20000"`
+	SubGoalIDs         []uint `json:"sub_goal_ids"`
+	SubCategoryIDs     []uint `json:"sub_category_ids"`
+	CoverImage         string `json:"cover_image" binding:"omitempty,max=2048,weburl"`
+	Equipment          string `json:"equipment" binding:"max=2000"`
+	Process            string `json:"process_markdown" binding:"max=20000"`
+	ObservableBehavior string `json:"observable_behavior_markdown" binding:"max=20000"`
+	Suggestion         string `json:"suggestion_markdown" binding:"max=20000"`
+	LegacyRichTextInput
+	Song      string `json:"song" binding:"max=2000"`
+	SongImage string `json:"song_image" binding:"omitempty,max=2048,weburl"`
+	QR1       string `json:"qr_1" binding:"omitempty,max=2048,weburl"`
+	QR2       string `json:"qr_2" binding:"omitempty,max=2048,weburl"`
+
+	// nil = คง QR เดิม, "" = ลบ QR ช่องนั้น
+	QR1Target *string `json:"qr_1_target" binding:"omitempty,max=2048"`
+	QR2Target *string `json:"qr_2_target" binding:"omitempty,max=2048"`
+	QR1Level  string  `json:"qr_1_level"`
+	QR2Level  string  `json:"qr_2_level"`
+
+	// Steps เป็น pointer เพื่อแยกกรณี "ไม่ส่งมา" (คงขั้นตอนเดิม) ออกจาก "ส่งรายการว่าง" (ลบทั้งหมด)
+	Steps          *[]ActivityStepInput      `json:"steps" binding:"omitempty,dive"`
+	EquipmentItems *[]ActivityEquipmentInput `json:"equipment_items" binding:"omitempty,dive"`
+	SongIDs        *[]uint                   `json:"song_ids"`
+	Variants       *[]ActivityVariantInput   `json:"variants" binding:"omitempty,dive"`
+
+	// nil = คงกลุ่มเป้าหมายเดิม
+	TargetPopulationIDs *[]uint `json:"target_population_ids"`
+	ActivityAttributesInput
+
+	// nil = คงสถานะเดิม
+	Status *string `json:"status" binding:"omitempty,oneof=draft published"`
+
+	CoverMediaID     *uint `json:"cover_media_id"`
+	SongImageMediaID *uint `json:"song_image_media_id"`
+
+	// PATCH ที่ส่ง cover_media_id/song_image_media_id เป็น null จะล้างการอ้างอิงไฟล์
+	clearCoverMedia     bool
+	clearSongImageMedia bool
+}
+
+func UpdateActivity(db *gorm.DB, qrCfg *config.QRConfig, relatedCache *related.Cache) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		var activity models.Activity
@@ -121,64 +396,222 @@ func UpdateActivity(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
 			return
 		}
-		var input struct {
-			Title              string `json:"title"`
-			GoalDescription    string `json:"goal_description"`
-			SubGoalIDs         []uint `json:"sub_goal_ids"`
-			SubCategoryIDs     []uint `json:"sub_category_ids"`
-			CoverImage         string `json:"cover_image"`
-			Equipment          string `json:"equipment"`
-			Process            string `json:"process"`
-			ObservableBehavior string `json:"observable_behavior"`
-			Suggestion         string `json:"suggestion"`
-			Song               string `json:"song"`
-			SongImage          string `json:"song_image"`
-			QR1                string `json:"qr_1"`
-			QR2                string `json:"qr_2"`
-		}
-		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if !requireIfMatch(c, db, &activity) {
 			return
 		}
-		var newSubGoals []models.ActivitySubGoal
-		db.Where("id IN ?", input.SubGoalIDs).Find(&newSubGoals)
-		var newSubCats []models.ActivitySubCategory
-		db.Where("id IN ?", input.SubCategoryIDs).Find(&newSubCats)
-		err := db.Transaction(func(tx *gorm.DB) error {
-			updates := map[string]interface{}{
-				"title":               input.Title,
-				"goal_description":    input.GoalDescription,
-				"cover_image":         input.CoverImage,
-				"equipment":           input.Equipment,
-				"process":             input.Process,
-				"observable_behavior": input.ObservableBehavior,
-				"suggestion":          input.Suggestion,
-				"song":                input.Song,
-				"song_image":          input.SongImage,
-				"qr1":                 input.QR1,
-				"qr2":                 input.QR2,
-			}
-			if err := tx.Model(&activity).Updates(updates).Error; err != nil {
+		var input activityUpdateInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		saveActivityUpdate(c, db, qrCfg, relatedCache, &activity, &input)
+	}
+}
+
+// saveActivityUpdate ตรวจข้อมูลที่อ้างอิงถึง บันทึกการแก้ไข แล้วตอบกิจกรรมที่แก้แล้ว
+// activity ต้อง preload SubGoals และ SubCategories มาแล้ว และ input ต้องผ่าน binding tag แล้ว
+// การเขียนจะสำเร็จเฉพาะเมื่อ version ในฐานข้อมูลยังเท่ากับ activity.Version (ตรวจ If-Match มาแล้ว)
+func saveActivityUpdate(c *gin.Context, db *gorm.DB, qrCfg *config.QRConfig, relatedCache *related.Cache, activity *models.Activity, input *activityUpdateInput) {
+	fields := fieldErrors{}
+	input.LegacyRichTextInput.applyTo(&input.Process, &input.ObservableBehavior, &input.Suggestion, fields)
+	qrTargets := []qrTargetInput{
+		{Slot: 1, Target: input.QR1Target, Level: input.QR1Level},
+		{Slot: 2, Target: input.QR2Target, Level: input.QR2Level},
+	}
+	validateQRTargets(qrTargets, fields)
+	if input.Steps != nil {
+		if err := validateActivitySteps(db, activity.ID, *input.Steps); err != nil {
+			fields.add("steps", "%v", err)
+		}
+	}
+	if input.EquipmentItems != nil {
+		if err := validateActivityEquipment(db, *input.EquipmentItems); err != nil {
+			fields.add("equipment_items", "%v", err)
+		}
+	}
+	if input.Variants != nil {
+		var equipmentIDs, songIDs []uint
+		if input.EquipmentItems != nil {
+			equipmentIDs = activityEquipmentIDs(*input.EquipmentItems)
+		}
+		if input.SongIDs != nil {
+			songIDs = append([]uint{}, *input.SongIDs...)
+		}
+		equipment, songs, err := variantReferences(db, activity.ID, equipmentIDs, songIDs)
+		if err == nil {
+			err = validateActivityVariants(db, activity.ID, *input.Variants, equipment, songs)
+		}
+		if err != nil {
+			fields.add("variants", "%v", err)
+		}
+	}
+	var newSongs []models.Song
+	if input.SongIDs != nil {
+		songs, err := findSongsByIDs(db, *input.SongIDs)
+		if err != nil {
+			fields.add("song_ids", "%v", err)
+		}
+		newSongs = songs
+	}
+	var newPopulations []models.TargetPopulation
+	if input.TargetPopulationIDs != nil {
+		populations, err := findTargetPopulationsByIDs(db, *input.TargetPopulationIDs)
+		if err != nil {
+			fields.add("target_population_ids", "%v", err)
+		}
+		newPopulations = populations
+	}
+	attributes := *activity
+	if err := input.ActivityAttributesInput.apply(&attributes); err != nil {
+		addAttributeError(fields, err)
+	}
+	coverMedia, err := resolveMediaRef(db, c, "cover_media_id", input.CoverMediaID, models.MediaPurposeActivityCover)
+	if err != nil {
+		fields.add("cover_media_id", "%v", err)
+	}
+	songImageMedia, err := resolveMediaRef(db, c, "song_image_media_id", input.SongImageMediaID, models.MediaPurposeSongImage)
+	if err != nil {
+		fields.add("song_image_media_id", "%v", err)
+	}
+	// taxonomy ที่ถูกยกเลิกแล้วคงไว้ได้ถ้ากิจกรรมเลือกไว้อยู่เดิม แต่เพิ่มใหม่ไม่ได้
+	currentSubGoalIDs := make([]uint, 0, len(activity.SubGoals))
+	for _, sg := range activity.SubGoals {
+		currentSubGoalIDs = append(currentSubGoalIDs, sg.ID)
+	}
+	currentSubCatIDs := make([]uint, 0, len(activity.SubCategories))
+	for _, sc := range activity.SubCategories {
+		currentSubCatIDs = append(currentSubCatIDs, sc.ID)
+	}
+	newSubGoals, err := findSelectableSubGoals(db, input.SubGoalIDs, currentSubGoalIDs)
+	if err != nil {
+		fields.add("sub_goal_ids", "%v", err)
+	}
+	newSubCats, err := findSelectableSubCategories(db, input.SubCategoryIDs, currentSubCatIDs)
+	if err != nil {
+		fields.add("sub_category_ids", "%v", err)
+	}
+	if len(fields) > 0 {
+		respondValidation(c, fields)
+		return
+	}
+
+	statusChanged := input.Status != nil && *input.Status != activity.Status
+	err = db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"title":               strings.TrimSpace(input.Title),
+			"goal_description":    input.GoalDescription,
+			"cover_image":         input.CoverImage,
+			"equipment":           input.Equipment,
+			"process":             input.Process,
+			"observable_behavior": input.ObservableBehavior,
+			"suggestion":          input.Suggestion,
+			"song":                input.Song,
+			"song_image":          input.SongImage,
+		}
+		for column, value := range input.ActivityAttributesInput.updates() {
+			updates[column] = value
+		}
+		if input.Status != nil {
+			updates["status"] = *input.Status
+		}
+		updates["version"] = gorm.Expr("version + 1")
+		// ช่องที่มี QR ที่ระบบสร้างแล้ว คอลัมน์ qr เก็บ URL ของภาพ จึงไม่เขียนทับด้วยข้อความที่ส่งมา
+		generated, err := qrcodes.GeneratedSlots(tx, activity.ID)
+		if err != nil {
+			return err
+		}
+		if !generated[1] {
+			updates["qr1"] = input.QR1
+		}
+		if !generated[2] {
+			updates["qr2"] = input.QR2
+		}
+		if input.clearCoverMedia {
+			updates["cover_media_id"] = nil
+		}
+		if input.clearSongImageMedia {
+			updates["song_image_media_id"] = nil
+		}
+		if coverMedia != nil {
+			updates["cover_media_id"] = coverMedia.ID
+			updates["cover_image"] = coverMedia.URL
+		}
+		if songImageMedia != nil {
+			updates["song_image_media_id"] = songImageMedia.ID
+			updates["song_image"] = songImageMedia.URL
+		}
+		// มีผู้อื่นแก้ไขไปแล้วหลังจากตรวจ If-Match ถ้าไม่มีแถวไหนถูกแก้
+		res := tx.Model(activity).Where("version = ?", activity.Version).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errStaleActivity
+		}
+		if err := tx.Model(activity).Association("SubGoals").Replace(newSubGoals); err != nil {
+			return err
+		}
+		if err := tx.Model(activity).Association("SubCategories").Replace(newSubCats); err != nil {
+			return err
+		}
+		if input.Steps != nil {
+			if err := replaceActivitySteps(tx, activity.ID, *input.Steps); err != nil {
 				return err
 			}
-			if err := tx.Model(&activity).Association("SubGoals").Replace(newSubGoals); err != nil {
+		}
+		if input.EquipmentItems != nil {
+			if err := replaceActivityEquipment(tx, activity.ID, *input.EquipmentItems); err != nil {
 				return err
 			}
-			if err := tx.Model(&activity).Association("SubCategories").Replace(newSubCats); err != nil {
+		}
+		if input.Variants != nil {
+			if err := replaceActivityVariants(tx, activity.ID, *input.Variants); err != nil {
+				return err
+			}
+		}
+		if input.SongIDs != nil {
+			if err := tx.Model(activity).Association("Songs").Replace(newSongs); err != nil {
+				return err
+			}
+		}
+		if input.Variants == nil && (input.EquipmentItems != nil || input.SongIDs != nil) {
+			if err := pruneVariantReferences(tx, activity.ID); err != nil {
+				return err
+			}
+		}
+		if input.TargetPopulationIDs != nil {
+			if err := tx.Model(activity).Association("TargetPopulations").Replace(newPopulations); err != nil {
 				return err
 			}
-			return nil
-		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed: " + err.Error()})
-			return
 		}
-		db.Preload("SubGoals").Preload("SubCategories").First(&activity, id)
-		c.JSON(http.StatusOK, activity)
+		return applyQRTargets(tx, qrCfg, activity.ID, qrTargets)
+	})
+	if errors.Is(err, errStaleActivity) {
+		respondStaleActivity(c, db, activity.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed: " + err.Error()})
+		return
+	}
+	// กิจกรรมที่เกี่ยวข้องคำนวณจากเป้าหมายย่อย/หมวดหมู่ย่อยของกิจกรรมที่เผยแพร่ จึงล้าง cache เมื่อรายการเหล่านี้หรือสถานะเปลี่ยนเท่านั้น
+	newSubGoalIDs := make([]uint, 0, len(newSubGoals))
+	for _, sg := range newSubGoals {
+		newSubGoalIDs = append(newSubGoalIDs, sg.ID)
 	}
+	newSubCatIDs := make([]uint, 0, len(newSubCats))
+	for _, sc := range newSubCats {
+		newSubCatIDs = append(newSubCatIDs, sc.ID)
+	}
+	if statusChanged || !sameIDSet(currentSubGoalIDs, newSubGoalIDs) || !sameIDSet(currentSubCatIDs, newSubCatIDs) {
+		relatedCache.Reset()
+	}
+	var updated models.Activity
+	preloadActivityDetails(db).Scopes(adminActivityRelations).First(&updated, activity.ID)
+	c.Header("ETag", activityETag(updated.Version))
+	c.JSON(http.StatusOK, updated)
 }
 
-func DeleteActivity(db *gorm.DB) gin.HandlerFunc {
+func DeleteActivity(db *gorm.DB, relatedCache *related.Cache) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
@@ -208,6 +641,85 @@ func DeleteActivity(db *gorm.DB) gin.HandlerFunc {
 
 			}
 
+			if err := tx.Model(&activity).Association("Songs").Clear(); err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Model(&activity).Association("TargetPopulations").Clear(); err != nil {
+
+				return err
+
+			}
+
+			if err := deleteActivityTranslations(tx, activity.ID); err != nil {
+
+				return err
+
+			}
+
+			if err := deleteActivityReviews(tx, activity.ID); err != nil {
+
+				return err
+
+			}
+
+			if err := deleteActivityVariants(tx, activity.ID); err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Model(&models.Activity{}).Where("derived_from_id = ?", activity.ID).Update("derived_from_id", nil).Error; err != nil {
+
+				return err
+
+			}
+
+			// แผน session ที่ใช้กิจกรรมนี้ยังคงอยู่ โดยเหลือเพียงชื่อกิจกรรมที่บันทึกไว้
+			if err := tx.Model(&models.SessionItem{}).Where("activity_id = ?", activity.ID).Update("activity_id", nil).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Model(&models.Observation{}).Where("activity_id = ?", activity.ID).Update("activity_id", nil).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Where("activity_id = ?", activity.ID).Delete(&models.ActivityStep{}).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Where("activity_id = ?", activity.ID).Delete(&models.ActivityEquipment{}).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Where("activity_id = ?", activity.ID).Delete(&models.ActivityQRCode{}).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Where("activity_id = ? OR related_activity_id = ?", activity.ID, activity.ID).Delete(&models.ActivityCooccurrence{}).Error; err != nil {
+
+				return err
+
+			}
+
+			if err := tx.Where("activity_id = ?", activity.ID).Delete(&models.ActivityPopularity{}).Error; err != nil {
+
+				return err
+
+			}
+
 			return tx.Delete(&activity).Error
 
 		})
@@ -220,23 +732,37 @@ func DeleteActivity(db *gorm.DB) gin.HandlerFunc {
 
 		}
 
+		relatedCache.Reset()
+
 		c.JSON(http.StatusOK, gin.H{"message": "Activity deleted successfully"})
 
 	}
 
 }
 
+// GetActivityByID คืนกิจกรรมที่เผยแพร่แล้ว (ฉบับร่างตอบ 404)
 func GetActivityByID(db *gorm.DB) gin.HandlerFunc {
 
+	return getActivity(db, publishedActivities)
+
+}
+
+// GetAdminActivity คืนกิจกรรมทุกสถานะรวมฉบับร่าง พร้อม ETag สำหรับแก้ไขด้วย PUT/PATCH
+func GetAdminActivity(db *gorm.DB) gin.HandlerFunc {
+
+	return getActivity(db, adminActivityRelations)
+
+}
+
+func getActivity(db *gorm.DB, scope func(*gorm.DB) *gorm.DB) gin.HandlerFunc {
+
 	return func(c *gin.Context) {
 
 		id := c.Param("id")
 
 		var activity models.Activity
 
-		if err := db.
-			Preload("SubGoals").
-			Preload("SubCategories").
+		if err := preloadActivityDetails(db).Scopes(scope).
 			First(&activity, id).Error; err != nil {
 
 			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
@@ -245,7 +771,19 @@ func GetActivityByID(db *gorm.DB) gin.HandlerFunc {
 
 		}
 
-		c.JSON(http.StatusOK, activity)
+		localized := []models.Activity{activity}
+
+		if err := localizeActivities(c, db, localized); err != nil {
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+			return
+
+		}
+
+		c.Header("ETag", activityETag(activity.Version))
+
+		c.JSON(http.StatusOK, localized[0])
 
 	}
 
@@ -257,9 +795,8 @@ func ListActivities(db *gorm.DB) gin.HandlerFunc {
 
 		var activities []models.Activity
 
-		if err := db.
-			Preload("SubGoals").
-			Preload("SubCategories").
+		if err := preloadActivityDetails(db).
+			Where("status = ?", models.ActivityStatusPublished).
 			Order("id DESC").
 			Find(&activities).Error; err != nil {
 
@@ -269,19 +806,41 @@ func ListActivities(db *gorm.DB) gin.HandlerFunc {
 
 		}
 
+		if err := localizeActivities(c, db, activities); err != nil {
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+			return
+
+		}
+
 		c.JSON(http.StatusOK, activities)
 
 	}
 
 }
 
+// GetActivityMasterGoals คืนเป้าหมายที่ใช้งานอยู่ตามลำดับที่ admin กำหนด
+// ?include_retired=true รวมรายการที่ถูกยกเลิกแล้ว (สำหรับหน้าจัดการ taxonomy)
 func GetActivityMasterGoals(db *gorm.DB) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
 		var goals []models.ActivityGoal
 
-		if err := db.Preload("SubGoals").Order("id ASC").Find(&goals).Error; err != nil {
+		if err := taxonomyScope(c)(db).Preload("SubGoals", taxonomyScope(c)).Find(&goals).Error; err != nil {
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+			return
+
+		}
+
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range goals {
+			batch.Goal(&goals[i])
+		}
+		if err := batch.Apply(db); err != nil {
 
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
@@ -295,13 +854,14 @@ func GetActivityMasterGoals(db *gorm.DB) gin.HandlerFunc {
 
 }
 
+// GetActivityMasterCategories คืนหมวดหมู่ที่ใช้งานอยู่ตามลำดับที่ admin กำหนด รองรับ ?include_retired=true เช่นกัน
 func GetActivityMasterCategories(db *gorm.DB) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
 		var categories []models.ActivityMainCategory
 
-		if err := db.Preload("SubCategories").Order("id ASC").Find(&categories).Error; err != nil {
+		if err := taxonomyScope(c)(db).Preload("SubCategories", taxonomyScope(c)).Find(&categories).Error; err != nil {
 
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch categories: " + err.Error()})
 
@@ -309,22 +869,39 @@ func GetActivityMasterCategories(db *gorm.DB) gin.HandlerFunc {
 
 		}
 
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range categories {
+			batch.Category(&categories[i])
+		}
+		if err := batch.Apply(db); err != nil {
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+			return
+
+		}
+
 		c.JSON(http.StatusOK, categories)
 
 	}
 
 }
 
+func taxonomyScope(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	if c.Query("include_retired") == "true" {
+		return models.TaxonomyOrder
+	}
+	return models.ActiveTaxonomy
+}
+
 func ToggleFavorite(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		val, _ := c.Get("user_id")
 		userID := val.(uint)
 
-		idStr := c.Param("id")
-		activityID, err := strconv.ParseUint(idStr, 10, 32)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid activity ID"})
+		activityID, ok := requirePublishedActivity(c, db)
+		if !ok {
 			return
 		}
 
@@ -367,6 +944,15 @@ func ListFavorites(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range favorites {
+			batch.Add(i18n.EntityActivity, favorites[i].Activity.ID, "title", &favorites[i].Activity.Title)
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusOK, favorites)
 	}
 }
@@ -376,8 +962,10 @@ func RecordReadHistory(db *gorm.DB) gin.HandlerFunc {
 		val, _ := c.Get("user_id")
 		userID := val.(uint)
 
-		idStr := c.Param("id")
-		activityID, _ := strconv.ParseUint(idStr, 10, 32)
+		activityID, ok := requirePublishedActivity(c, db)
+		if !ok {
+			return
+		}
 
 		var history models.UserReadHistory
 		result := db.Where("user_id = ? AND activity_id = ?", userID, uint(activityID)).First(&history)
@@ -416,6 +1004,15 @@ func ListReadHistory(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range history {
+			batch.Add(i18n.EntityActivity, history[i].Activity.ID, "title", &history[i].Activity.Title)
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusOK, history)
 	}
 }
@@ -451,11 +1048,18 @@ func SearchAndFilterActivities(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var activities []models.Activity
 		// เริ่มต้น Query และ Preload ข้อมูลที่เกี่ยวข้องมาแสดงผลด้วย
-		query := db.Model(&models.Activity{}).Preload("SubGoals").Preload("SubCategories")
+		query := preloadActivityDetails(db.Model(&models.Activity{})).
+			Where("activities.status = ?", models.ActivityStatusPublished)
 
 		// 1. ค้นหาจากชื่อ (Title)
+		// ค้นหาในคำแปลของชื่อกิจกรรมด้วย เมื่อ request ขอภาษาอื่นที่ไม่ใช่ภาษาไทย
 		if title := c.Query("title"); title != "" {
-			query = query.Where("activities.title LIKE ?", "%"+title+"%")
+			if chain := i18n.Chain(c.GetString("locale")); len(chain) > 0 {
+				query = query.Where("activities.title LIKE ? OR EXISTS (SELECT 1 FROM translations WHERE translations.entity_type = ? AND translations.entity_id = activities.id AND translations.field = 'title' AND translations.locale IN ? AND translations.value ILIKE ?)",
+					"%"+title+"%", i18n.EntityActivity, chain, "%"+title+"%")
+			} else {
+				query = query.Where("activities.title LIKE ?", "%"+title+"%")
+			}
 		}
 
 		// 2. ค้นหาจาก เป้าหมายย่อย (Sub Goal)
@@ -486,8 +1090,64 @@ func SearchAndFilterActivities(db *gorm.DB) gin.HandlerFunc {
 				Where("activity_sub_categories.category_id = ?", catID)
 		}
 
+		// 6. ค้นหาจากเพลง (song_id หรือชื่อเพลง)
+		if songID := c.Query("song_id"); songID != "" {
+			query = query.Where("EXISTS (SELECT 1 FROM activity_songs WHERE activity_songs.activity_id = activities.id AND activity_songs.song_id = ?)", songID)
+		}
+		if song := c.Query("song"); song != "" {
+			query = query.Where("EXISTS (SELECT 1 FROM activity_songs JOIN songs ON songs.id = activity_songs.song_id WHERE activity_songs.activity_id = activities.id AND songs.title ILIKE ?)", "%"+song+"%")
+		}
+
+		// 7. กรองเฉพาะกิจกรรมที่อุปกรณ์ที่ต้องใช้ทั้งหมดอยู่ในรายการที่ผู้ใช้มี (subset)
+		// เช่น ?available_equipment_ids=1,4 กิจกรรมที่ไม่ต้องใช้อุปกรณ์จาก catalog จะผ่านเสมอ
+		if available, ok := c.GetQuery("available_equipment_ids"); ok {
+			ids, err := parseIDList(available)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "available_equipment_ids: " + err.Error()})
+				return
+			}
+			if len(ids) == 0 {
+				query = query.Where("NOT EXISTS (SELECT 1 FROM activity_equipments WHERE activity_equipments.activity_id = activities.id)")
+			} else {
+				query = query.Where("NOT EXISTS (SELECT 1 FROM activity_equipments WHERE activity_equipments.activity_id = activities.id AND activity_equipments.equipment_id NOT IN ?)", ids)
+			}
+		}
+
+		// 8. กรองตามช่วงอายุ ขนาดกลุ่ม ระยะเวลา ระดับความยาก และกลุ่มเป้าหมาย
+		query, err := applyAttributeFilters(c, query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 9. กรองคะแนนรีวิวขั้นต่ำ เช่น ?min_rating=4
+		if minRating := c.Query("min_rating"); minRating != "" {
+			v, err := strconv.ParseFloat(minRating, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "min_rating must be a number"})
+				return
+			}
+			query = query.Where("activities.rating_count > 0 AND activities.rating_average >= ?", v)
+		}
+
+		// เรียงผลลัพธ์ ?sort=newest (ค่าเริ่มต้น) หรือ rating (คะแนนเฉลี่ยสูงสุดก่อน แล้วตามจำนวนรีวิว)
+		order := "activities.id DESC"
+		switch c.DefaultQuery("sort", "newest") {
+		case "newest":
+		case "rating":
+			order = "activities.rating_average DESC, activities.rating_count DESC, activities.id DESC"
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest or rating"})
+			return
+		}
+
 		// ใช้ .Distinct() เพื่อป้องกันข้อมูลซ้ำกรณีที่ 1 กิจกรรมมีหลาย Sub-goal ใน Master เดียวกัน
-		if err := query.Distinct("activities.*").Order("activities.id DESC").Find(&activities).Error; err != nil {
+		if err := query.Distinct("activities.*").Order(order).Find(&activities).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := localizeActivities(c, db, activities); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -498,7 +1158,10 @@ func SearchAndFilterActivities(db *gorm.DB) gin.HandlerFunc {
 
 func GetActivityStats(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, ok := requirePublishedActivity(c, db)
+		if !ok {
+			return
+		}
 
 		var favCount int64
 		var readTotal int64
@@ -572,6 +1235,18 @@ func GetAdminDashboard(db *gorm.DB) gin.HandlerFunc {
 			Joins("JOIN activity_sub_goals ON activity_sub_goals.id = activity_selected_sub_goals.activity_sub_goal_id").
 			Group("activity_sub_goals.sub_goal_name").Order("total_read DESC").Limit(5).Scan(&topReadGoals)
 
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range topReadActivities {
+			batch.Add(i18n.EntityActivity, topReadActivities[i].ActivityID, "title", &topReadActivities[i].Title)
+		}
+		for i := range topFavActivities {
+			batch.Add(i18n.EntityActivity, topFavActivities[i].ActivityID, "title", &topFavActivities[i].Title)
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"top_read_activities": topReadActivities,
 			"top_fav_activities":  topFavActivities,
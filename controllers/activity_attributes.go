@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ActivityAttributesInput คือข้อมูลสำหรับเลือกกิจกรรมให้เหมาะกับผู้เข้าร่วม ใช้ร่วมกันทั้งตอนสร้างและแก้ไข
+// ค่า null หมายถึงไม่จำกัด (อายุ ขนาดกลุ่ม) หรือไม่ได้ระบุ (ระยะเวลา ระดับความยาก)
+type ActivityAttributesInput struct {
+	AgeMin            *int   `json:"age_min" binding:"omitempty,gte=0"`
+	AgeMax            *int   `json:"age_max" binding:"omitempty,gte=0"`
+	GroupSizeMin      *int   `json:"group_size_min" binding:"omitempty,gte=1"`
+	GroupSizeMax      *int   `json:"group_size_max" binding:"omitempty,gte=1"`
+	DurationMinutes   *int   `json:"duration_minutes" binding:"omitempty,gt=0"`
+	Difficulty        *int   `json:"difficulty" binding:"omitempty,gte=1,lte=5"`
+	Contraindications string `json:"contraindications" binding:"max=5000"`
+	SafetyNotes       string `json:"safety_notes" binding:"max=5000"`
+}
+
+// apply คัดลอกค่าลงกิจกรรมแล้วตรวจสอบช่วงค่า
+func (in ActivityAttributesInput) apply(activity *models.Activity) error {
+	activity.AgeMin = in.AgeMin
+	activity.AgeMax = in.AgeMax
+	activity.GroupSizeMin = in.GroupSizeMin
+	activity.GroupSizeMax = in.GroupSizeMax
+	activity.DurationMinutes = in.DurationMinutes
+	activity.Difficulty = in.Difficulty
+	activity.Contraindications = strings.TrimSpace(in.Contraindications)
+	activity.SafetyNotes = strings.TrimSpace(in.SafetyNotes)
+	return activity.ValidateAttributes()
+}
+
+// addAttributeError ใส่ error จาก ValidateAttributes ลงในฟิลด์ที่ขึ้นต้นข้อความ (เช่น "age_min must ...")
+func addAttributeError(fields fieldErrors, err error) {
+	field, message, _ := strings.Cut(err.Error(), " ")
+	fields.add(field, "%s", message)
+}
+
+// updates คืนค่าคอลัมน์สำหรับ Updates แบบ map เพื่อให้ค่า null ล้างค่าเดิมได้
+func (in ActivityAttributesInput) updates() map[string]interface{} {
+	return map[string]interface{}{
+		"age_min":           in.AgeMin,
+		"age_max":           in.AgeMax,
+		"group_size_min":    in.GroupSizeMin,
+		"group_size_max":    in.GroupSizeMax,
+		"duration_minutes":  in.DurationMinutes,
+		"difficulty":        in.Difficulty,
+		"contraindications": strings.TrimSpace(in.Contraindications),
+		"safety_notes":      strings.TrimSpace(in.SafetyNotes),
+	}
+}
+
+func findTargetPopulationsByIDs(db *gorm.DB, ids []uint) ([]models.TargetPopulation, error) {
+	var populations []models.TargetPopulation
+	if len(ids) == 0 {
+		return populations, nil
+	}
+	if err := db.Where("id IN ?", ids).Find(&populations).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[uint]bool, len(populations))
+	for _, p := range populations {
+		found[p.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("target_population_id %d not found", id)
+		}
+	}
+	return populations, nil
+}
+
+// applyAttributeFilters เพิ่มเงื่อนไขค้นหาตามช่วงอายุ ขนาดกลุ่ม ระยะเวลา ระดับความยาก และกลุ่มเป้าหมาย
+//
+//	?age=7                        กิจกรรมที่ช่วงอายุครอบคลุม 7 ปี
+//	?age_min=5&age_max=10         กิจกรรมที่ช่วงอายุซ้อนทับกับ 5-10 ปี (ระบุข้างเดียวได้)
+//	?group_size=4                 กิจกรรมที่ทำได้กับกลุ่ม 4 คน
+//	?group_size_min=&group_size_max=  กิจกรรมที่ช่วงขนาดกลุ่มซ้อนทับกับช่วงที่ระบุ
+//	?duration_min=10&duration_max=30  กิจกรรมที่ใช้เวลา 10-30 นาที
+//	?difficulty=2 หรือ ?difficulty_min=1&difficulty_max=3
+//	?target_population_ids=1,2[&target_population_match=all]
+//
+// อายุและขนาดกลุ่มที่เป็น null ถือว่าไม่จำกัด ส่วนระยะเวลาและระดับความยากที่เป็น null จะไม่ผ่านตัวกรอง
+func applyAttributeFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	ints := make(map[string]*int)
+	for _, name := range []string{
+		"age", "age_min", "age_max",
+		"group_size", "group_size_min", "group_size_max",
+		"duration_min", "duration_max",
+		"difficulty", "difficulty_min", "difficulty_max",
+	} {
+		raw := strings.TrimSpace(c.Query(name))
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", name)
+		}
+		ints[name] = &v
+	}
+
+	query = containsFilter(query, "activities.age_min", "activities.age_max", ints["age"])
+	query = overlapFilter(query, "activities.age_min", "activities.age_max", ints["age_min"], ints["age_max"])
+	query = containsFilter(query, "activities.group_size_min", "activities.group_size_max", ints["group_size"])
+	query = overlapFilter(query, "activities.group_size_min", "activities.group_size_max", ints["group_size_min"], ints["group_size_max"])
+
+	if v := ints["duration_min"]; v != nil {
+		query = query.Where("activities.duration_minutes >= ?", *v)
+	}
+	if v := ints["duration_max"]; v != nil {
+		query = query.Where("activities.duration_minutes <= ?", *v)
+	}
+	if v := ints["difficulty"]; v != nil {
+		query = query.Where("activities.difficulty = ?", *v)
+	}
+	if v := ints["difficulty_min"]; v != nil {
+		query = query.Where("activities.difficulty >= ?", *v)
+	}
+	if v := ints["difficulty_max"]; v != nil {
+		query = query.Where("activities.difficulty <= ?", *v)
+	}
+
+	if raw := c.Query("target_population_ids"); raw != "" {
+		ids, err := parseIDList(raw)
+		if err != nil {
+			return nil, fmt.Errorf("target_population_ids: %w", err)
+		}
+		if len(ids) > 0 {
+			switch c.DefaultQuery("target_population_match", "any") {
+			case "any":
+				query = query.Where("EXISTS (SELECT 1 FROM activity_target_populations WHERE activity_target_populations.activity_id = activities.id AND activity_target_populations.target_population_id IN ?)", ids)
+			case "all":
+				query = query.Where("(SELECT COUNT(DISTINCT activity_target_populations.target_population_id) FROM activity_target_populations WHERE activity_target_populations.activity_id = activities.id AND activity_target_populations.target_population_id IN ?) = ?", ids, len(uniqueIDs(ids)))
+			default:
+				return nil, fmt.Errorf("target_population_match must be any or all")
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// containsFilter กรองแถวที่ช่วง [minCol, maxCol] ครอบคลุมค่า v (null = ไม่จำกัด)
+func containsFilter(query *gorm.DB, minCol, maxCol string, v *int) *gorm.DB {
+	if v == nil {
+		return query
+	}
+	return query.
+		Where(fmt.Sprintf("(%s IS NULL OR %s <= ?)", minCol, minCol), *v).
+		Where(fmt.Sprintf("(%s IS NULL OR %s >= ?)", maxCol, maxCol), *v)
+}
+
+// overlapFilter กรองแถวที่ช่วง [minCol, maxCol] ซ้อนทับกับช่วง [from, to] ที่ค้นหา
+func overlapFilter(query *gorm.DB, minCol, maxCol string, from, to *int) *gorm.DB {
+	if from != nil {
+		query = query.Where(fmt.Sprintf("(%s IS NULL OR %s >= ?)", maxCol, maxCol), *from)
+	}
+	if to != nil {
+		query = query.Where(fmt.Sprintf("(%s IS NULL OR %s <= ?)", minCol, minCol), *to)
+	}
+	return query
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"project-backend/config"
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/qrcodes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CloneActivity คัดลอกกิจกรรมทั้งหมด (ข้อความ ช่วงอายุ taxonomy ขั้นตอน รูปแบบ อุปกรณ์ เพลง กลุ่มเป้าหมาย QR คำแปล
+// และการอ้างอิงไฟล์สื่อ) เป็นฉบับร่างใหม่ของผู้เรียก และบันทึกกิจกรรมต้นแบบไว้ใน derived_from_id
+// body เป็น optional: {"title": "..."} ถ้าไม่ระบุจะใช้ชื่อเดิมต่อท้ายด้วย "(สำเนา)"
+func CloneActivity(db *gorm.DB, qrCfg *config.QRConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var source models.Activity
+		if err := db.
+			Preload("SubGoals").
+			Preload("SubCategories").
+			Preload("Steps", orderedSteps).
+			Preload("Variants", orderedVariants).
+			Preload("Variants.SubGoals").
+			Preload("Variants.SelectedEquipment").
+			Preload("Variants.Songs").
+			Preload("EquipmentItems").
+			Preload("Songs").
+			Preload("TargetPopulations").
+			Preload("QRCodes").
+			First(&source, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
+
+		var input struct {
+			Title string `json:"title"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		title := strings.TrimSpace(input.Title)
+		if title == "" {
+			title = source.Title + " (สำเนา)"
+		}
+
+		clone := source
+		clone.ID = 0
+		clone.Title = title
+		clone.CreatedAt, clone.UpdatedAt = time.Time{}, time.Time{}
+		clone.AdminID = userID
+		clone.Status = models.ActivityStatusDraft
+		clone.DerivedFromID = &source.ID
+		clone.RatingAverage, clone.RatingCount = 0, 0
+		clone.Version = 0
+		clone.Steps, clone.EquipmentItems, clone.QRCodes, clone.Variants = nil, nil, nil, nil
+		clone.DerivedFrom, clone.Derivatives = nil, nil
+		clone.CoverMedia, clone.SongImageMedia = nil, nil
+
+		// ช่องที่ระบบสร้าง QR ให้ คอลัมน์ qr เก็บ URL ภาพของกิจกรรมต้นแบบ จึงให้ qrcodes.Sync สร้างใหม่
+		for _, q := range source.QRCodes {
+			switch q.Slot {
+			case 1:
+				clone.QR1 = ""
+			case 2:
+				clone.QR2 = ""
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("SubGoals.*", "SubCategories.*", "Songs.*", "TargetPopulations.*", "Steps", "EquipmentItems", "QRCodes", "Variants").Create(&clone).Error; err != nil {
+				return err
+			}
+
+			stepIDs := make(map[uint]uint, len(source.Steps))
+			for _, s := range source.Steps {
+				step := s
+				step.ID = 0
+				step.ActivityID = clone.ID
+				step.SubGoal, step.Media = nil, nil
+				if err := tx.Create(&step).Error; err != nil {
+					return err
+				}
+				stepIDs[s.ID] = step.ID
+			}
+
+			variantIDs := make(map[uint]uint, len(source.Variants))
+			for _, v := range source.Variants {
+				variant := v
+				variant.ID = 0
+				variant.ActivityID = clone.ID
+				variant.CreatedAt, variant.UpdatedAt = time.Time{}, time.Time{}
+				if err := tx.Omit("SubGoals.*", "SelectedEquipment.*", "Songs.*").Create(&variant).Error; err != nil {
+					return err
+				}
+				variantIDs[v.ID] = variant.ID
+			}
+
+			for _, item := range source.EquipmentItems {
+				item.ActivityID = clone.ID
+				if err := tx.Omit("Equipment").Create(&item).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, q := range source.QRCodes {
+				if err := qrcodes.Sync(tx, qrCfg, clone.ID, q.Slot, q.TargetURL, q.Level); err != nil {
+					return err
+				}
+			}
+
+			return copyActivityTranslations(tx, source.ID, clone.ID, stepIDs, variantIDs)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Clone failed: " + err.Error()})
+			return
+		}
+
+		preloadActivityDetails(db).Scopes(adminActivityRelations).First(&clone, clone.ID)
+		c.JSON(http.StatusCreated, clone)
+	}
+}
+
+// copyActivityTranslations คัดลอกคำแปลของกิจกรรม ขั้นตอน และรูปแบบไปยังกิจกรรมที่ clone แล้ว
+// stepIDs/variantIDs จับคู่ id เดิมกับ id ใหม่
+func copyActivityTranslations(tx *gorm.DB, sourceID, cloneID uint, stepIDs, variantIDs map[uint]uint) error {
+	query := tx.Where("entity_type = ? AND entity_id = ?", i18n.EntityActivity, sourceID)
+	for entityType, ids := range map[string]map[uint]uint{i18n.EntityStep: stepIDs, i18n.EntityVariant: variantIDs} {
+		old := make([]uint, 0, len(ids))
+		for id := range ids {
+			old = append(old, id)
+		}
+		if len(old) > 0 {
+			query = query.Or("entity_type = ? AND entity_id IN ?", entityType, old)
+		}
+	}
+	var rows []models.Translation
+	if err := query.Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	for i := range rows {
+		rows[i].ID = 0
+		switch rows[i].EntityType {
+		case i18n.EntityStep:
+			rows[i].EntityID = stepIDs[rows[i].EntityID]
+		case i18n.EntityVariant:
+			rows[i].EntityID = variantIDs[rows[i].EntityID]
+		default:
+			rows[i].EntityID = cloneID
+		}
+	}
+	return tx.Create(&rows).Error
+}
+
+func allDerivatives(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
+
+// adminActivityRelations ให้ admin เห็นกิจกรรมต้นแบบและกิจกรรมที่ clone ไปทุกสถานะ รวมฉบับร่าง
+// ใช้ต่อท้าย preloadActivityDetails เพื่อแทน preload ที่จำกัดเฉพาะกิจกรรมที่เผยแพร่แล้ว
+func adminActivityRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("DerivedFrom").Preload("Derivatives", allDerivatives)
+}
+
+// ListAdminActivities คือรายการกิจกรรมสำหรับ admin รวมฉบับร่าง
+// ?status=draft|published และ ?derived_from=<id> เพื่อดูกิจกรรมที่ clone จากกิจกรรมนั้น
+func ListAdminActivities(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := preloadActivityDetails(db).Scopes(adminActivityRelations).Order("id DESC")
+		if status := c.Query("status"); status != "" {
+			if !models.ValidActivityStatus(status) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft or published"})
+				return
+			}
+			query = query.Where("status = ?", status)
+		}
+		if derivedFrom := c.Query("derived_from"); derivedFrom != "" {
+			query = query.Where("derived_from_id = ?", derivedFrom)
+		}
+
+		var activities []models.Activity
+		if err := query.Find(&activities).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, activities)
+	}
+}
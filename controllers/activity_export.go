@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"project-backend/config"
+	"project-backend/handout"
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExportActivityPDF ส่งเอกสาร PDF ของกิจกรรมเดียวสำหรับพิมพ์
+func ExportActivityPDF(db *gorm.DB, store storage.Storage, fonts *handout.Fonts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if fonts == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF export is not available (fonts not installed)"})
+			return
+		}
+
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var activity models.Activity
+		if err := preloadActivityDetails(db).Scopes(publishedActivities).First(&activity, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
+
+		pages, err := buildHandoutPages(c.Request.Context(), db, store, c.GetString("locale"), []models.Activity{activity})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		writeHandout(c, fonts, pages, fmt.Sprintf("activity-%d.pdf", activity.ID))
+	}
+}
+
+// ExportActivityBooklet ส่ง PDF ที่รวมหลายกิจกรรมตามลำดับใน ?ids=1,2,3
+func ExportActivityBooklet(db *gorm.DB, store storage.Storage, fonts *handout.Fonts, cfg *config.PDFConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if fonts == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF export is not available (fonts not installed)"})
+			return
+		}
+
+		ids, err := parseIDList(c.Query("ids"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids: " + err.Error()})
+			return
+		}
+		if len(ids) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids is required"})
+			return
+		}
+		if len(ids) > cfg.BookletMax {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("a booklet can contain at most %d activities", cfg.BookletMax)})
+			return
+		}
+
+		var found []models.Activity
+		if err := preloadActivityDetails(db).Scopes(publishedActivities).Where("activities.id IN ?", ids).Find(&found).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		byID := make(map[uint]models.Activity, len(found))
+		for _, a := range found {
+			byID[a.ID] = a
+		}
+		activities := make([]models.Activity, 0, len(ids))
+		seen := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			a, ok := byID[id]
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("activity %d not found", id)})
+				return
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			activities = append(activities, a)
+		}
+
+		pages, err := buildHandoutPages(c.Request.Context(), db, store, c.GetString("locale"), activities)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		writeHandout(c, fonts, pages, "activities-booklet.pdf")
+	}
+}
+
+func writeHandout(c *gin.Context, fonts *handout.Fonts, pages []handout.Page, filename string) {
+	var buf bytes.Buffer
+	if err := handout.Render(&buf, fonts, pages, handout.LabelsFor(c.GetString("locale"))); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render PDF: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// buildHandoutPages รวบรวมข้อมูลที่ PDF ต้องใช้: เป้าหมายหลัก ภาพปก และภาพ QR ที่สร้างไว้
+// ข้อความของกิจกรรมและ taxonomy ถูกแทนด้วยคำแปลตาม locale
+func buildHandoutPages(ctx context.Context, db *gorm.DB, store storage.Storage, locale string, activities []models.Activity) ([]handout.Page, error) {
+	var goals []models.ActivityGoal
+	if err := models.TaxonomyOrder(db).Find(&goals).Error; err != nil {
+		return nil, err
+	}
+
+	batch := i18n.NewBatch(locale)
+	for i := range activities {
+		batch.Activity(&activities[i])
+	}
+	for i := range goals {
+		batch.Goal(&goals[i])
+	}
+	if err := batch.Apply(db); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(activities))
+	for _, a := range activities {
+		ids = append(ids, a.ID)
+	}
+	var codes []models.ActivityQRCode
+	if err := db.Where("activity_id IN ?", ids).Order("slot ASC").Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	codesByActivity := make(map[uint][]handout.QRCode)
+	for _, code := range codes {
+		codesByActivity[code.ActivityID] = append(codesByActivity[code.ActivityID], handout.QRCode{
+			Label:     fmt.Sprintf("QR %d", code.Slot),
+			TargetURL: code.TargetURL,
+			PNG:       code.PNG,
+		})
+	}
+
+	pages := make([]handout.Page, 0, len(activities))
+	for _, a := range activities {
+		pages = append(pages, handout.Page{
+			Activity: a,
+			Goals:    goals,
+			Cover:    readPrintImage(ctx, store, a.CoverMedia),
+			QRCodes:  codesByActivity[a.ID],
+		})
+	}
+	return pages, nil
+}
+
+// readPrintImage อ่านภาพขนาด "full" ถ้าสร้างเสร็จแล้ว ไม่เช่นนั้นใช้ไฟล์ต้นฉบับ
+// ถ้าอ่านไม่ได้จะคืน nil เพื่อให้ PDF ยังสร้างได้โดยไม่มีภาพ
+func readPrintImage(ctx context.Context, store storage.Storage, media *models.Media) []byte {
+	if media == nil {
+		return nil
+	}
+
+	key := media.StorageKey
+	for _, v := range media.Variants {
+		if v.Name == "full" {
+			key = v.StorageKey
+			break
+		}
+	}
+
+	rc, err := store.Open(ctx, key)
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"net/http"
+
+	"project-backend/config"
+	"project-backend/helpers"
+	"project-backend/models"
+	"project-backend/related"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// activityPatchInput คือข้อมูลแก้ไขกิจกรรมแบบ PATCH แก้เฉพาะฟิลด์ที่ส่งมา
+//
+//	ไม่ส่งฟิลด์      คงค่าเดิม
+//	ส่ง null         ข้อความ = "", ตัวเลข/ไฟล์ที่อ้างอิง = ล้างค่า, รายการ = ลบทั้งหมด, QR = ลบ
+//	                 (ยกเว้น title และ status ที่เป็น null ไม่ได้)
+//	ส่งค่ามา         แทนที่ด้วยค่านั้น
+type activityPatchInput struct {
+	Title              helpers.Optional[string] `json:"title"`
+	GoalDescription    helpers.Optional[string] `json:"goal_description"`
+	CoverImage         helpers.Optional[string] `json:"cover_image"`
+	Equipment          helpers.Optional[string] `json:"equipment"`
+	Process            helpers.Optional[string] `json:"process_markdown"`
+	ObservableBehavior helpers.Optional[string] `json:"observable_behavior_markdown"`
+	Suggestion         helpers.Optional[string] `json:"suggestion_markdown"`
+	// ชื่อฟิลด์เดิมก่อนเปลี่ยนเป็น Markdown ใช้เมื่อไม่ได้ส่งชื่อใหม่มา (ดู LegacyRichTextInput)
+	LegacyProcess            helpers.Optional[string] `json:"process"`
+	LegacyObservableBehavior helpers.Optional[string] `json:"observable_behavior"`
+	LegacySuggestion         helpers.Optional[string] `json:"suggestion"`
+	Song                     helpers.Optional[string] `json:"song"`
+	SongImage                helpers.Optional[string] `json:"song_image"`
+	QR1                      helpers.Optional[string] `json:"qr_1"`
+	QR2                      helpers.Optional[string] `json:"qr_2"`
+	QR1Target                helpers.Optional[string] `json:"qr_1_target"`
+	QR2Target                helpers.Optional[string] `json:"qr_2_target"`
+	QR1Level                 helpers.Optional[string] `json:"qr_1_level"`
+	QR2Level                 helpers.Optional[string] `json:"qr_2_level"`
+
+	SubGoalIDs          helpers.Optional[[]uint]                   `json:"sub_goal_ids"`
+	SubCategoryIDs      helpers.Optional[[]uint]                   `json:"sub_category_ids"`
+	Steps               helpers.Optional[[]ActivityStepInput]      `json:"steps"`
+	EquipmentItems      helpers.Optional[[]ActivityEquipmentInput] `json:"equipment_items"`
+	SongIDs             helpers.Optional[[]uint]                   `json:"song_ids"`
+	Variants            helpers.Optional[[]ActivityVariantInput]   `json:"variants"`
+	TargetPopulationIDs helpers.Optional[[]uint]                   `json:"target_population_ids"`
+
+	AgeMin            helpers.Optional[int]    `json:"age_min"`
+	AgeMax            helpers.Optional[int]    `json:"age_max"`
+	GroupSizeMin      helpers.Optional[int]    `json:"group_size_min"`
+	GroupSizeMax      helpers.Optional[int]    `json:"group_size_max"`
+	DurationMinutes   helpers.Optional[int]    `json:"duration_minutes"`
+	Difficulty        helpers.Optional[int]    `json:"difficulty"`
+	Contraindications helpers.Optional[string] `json:"contraindications"`
+	SafetyNotes       helpers.Optional[string] `json:"safety_notes"`
+
+	Status helpers.Optional[string] `json:"status"`
+
+	CoverMediaID     helpers.Optional[uint] `json:"cover_media_id"`
+	SongImageMediaID helpers.Optional[uint] `json:"song_image_media_id"`
+}
+
+func patchText(o helpers.Optional[string], current string) string {
+	if !o.Set {
+		return current
+	}
+	return o.Value
+}
+
+// patchLegacyText ใช้ค่าจากชื่อฟิลด์เดิมเมื่อไม่ได้ส่งชื่อใหม่ (*_markdown) มา ข้อความ HTML ถูกแปลงเป็น Markdown
+func patchLegacyText(o, legacy helpers.Optional[string]) helpers.Optional[string] {
+	if o.Set || !legacy.Set {
+		return o
+	}
+	legacy.Value = legacyMarkdown(legacy.Value)
+	return legacy
+}
+
+func patchInt(o helpers.Optional[int], current *int) *int {
+	if !o.Set {
+		return current
+	}
+	if o.Null {
+		return nil
+	}
+	v := o.Value
+	return &v
+}
+
+// patchList คืน nil เมื่อไม่ได้ส่งมา (คงรายการเดิม) และรายการว่างเมื่อส่ง null
+func patchList[T any](o helpers.Optional[[]T]) *[]T {
+	if !o.Set {
+		return nil
+	}
+	list := o.Value
+	if list == nil {
+		list = []T{}
+	}
+	return &list
+}
+
+func patchQRTarget(o helpers.Optional[string]) *string {
+	if !o.Set {
+		return nil
+	}
+	target := o.Value
+	return &target
+}
+
+// toUpdate รวมค่าที่ส่งมากับค่าเดิมของกิจกรรมเป็น activityUpdateInput
+// activity ต้อง preload SubGoals และ SubCategories มาแล้ว
+func (in *activityPatchInput) toUpdate(activity *models.Activity) (*activityUpdateInput, fieldErrors) {
+	fields := fieldErrors{}
+	if in.Title.Null {
+		fields.add("title", "must not be null")
+	}
+	if in.Status.Null {
+		fields.add("status", "must not be null")
+	}
+
+	out := &activityUpdateInput{
+		Title:              patchText(in.Title, activity.Title),
+		GoalDescription:    patchText(in.GoalDescription, activity.GoalDescription),
+		CoverImage:         patchText(in.CoverImage, activity.CoverImage),
+		Equipment:          patchText(in.Equipment, activity.Equipment),
+		Process:            patchText(patchLegacyText(in.Process, in.LegacyProcess), activity.Process),
+		ObservableBehavior: patchText(patchLegacyText(in.ObservableBehavior, in.LegacyObservableBehavior), activity.ObservableBehavior),
+		Suggestion:         patchText(patchLegacyText(in.Suggestion, in.LegacySuggestion), activity.Suggestion),
+		Song:               patchText(in.Song, activity.Song),
+		SongImage:          patchText(in.SongImage, activity.SongImage),
+		QR1:                patchText(in.QR1, activity.QR1),
+		QR2:                patchText(in.QR2, activity.QR2),
+		QR1Target:          patchQRTarget(in.QR1Target),
+		QR2Target:          patchQRTarget(in.QR2Target),
+		QR1Level:           in.QR1Level.Value,
+		QR2Level:           in.QR2Level.Value,
+
+		Steps:               patchList(in.Steps),
+		EquipmentItems:      patchList(in.EquipmentItems),
+		SongIDs:             patchList(in.SongIDs),
+		Variants:            patchList(in.Variants),
+		TargetPopulationIDs: patchList(in.TargetPopulationIDs),
+
+		ActivityAttributesInput: ActivityAttributesInput{
+			AgeMin:            patchInt(in.AgeMin, activity.AgeMin),
+			AgeMax:            patchInt(in.AgeMax, activity.AgeMax),
+			GroupSizeMin:      patchInt(in.GroupSizeMin, activity.GroupSizeMin),
+			GroupSizeMax:      patchInt(in.GroupSizeMax, activity.GroupSizeMax),
+			DurationMinutes:   patchInt(in.DurationMinutes, activity.DurationMinutes),
+			Difficulty:        patchInt(in.Difficulty, activity.Difficulty),
+			Contraindications: patchText(in.Contraindications, activity.Contraindications),
+			SafetyNotes:       patchText(in.SafetyNotes, activity.SafetyNotes),
+		},
+	}
+
+	if in.SubGoalIDs.Set {
+		out.SubGoalIDs = in.SubGoalIDs.Value
+	} else {
+		for _, sg := range activity.SubGoals {
+			out.SubGoalIDs = append(out.SubGoalIDs, sg.ID)
+		}
+	}
+	if in.SubCategoryIDs.Set {
+		out.SubCategoryIDs = in.SubCategoryIDs.Value
+	} else {
+		for _, sc := range activity.SubCategories {
+			out.SubCategoryIDs = append(out.SubCategoryIDs, sc.ID)
+		}
+	}
+
+	if in.Status.Present() {
+		status := in.Status.Value
+		out.Status = &status
+	}
+
+	// ล้างไฟล์ที่อ้างอิงด้วย null ถ้าไม่ได้ส่ง cover_image/song_image มาด้วยให้ล้างข้อความ URL เดิมไปพร้อมกัน
+	if in.CoverMediaID.Present() {
+		id := in.CoverMediaID.Value
+		out.CoverMediaID = &id
+	} else if in.CoverMediaID.Null {
+		out.clearCoverMedia = true
+		if !in.CoverImage.Set {
+			out.CoverImage = ""
+		}
+	}
+	if in.SongImageMediaID.Present() {
+		id := in.SongImageMediaID.Value
+		out.SongImageMediaID = &id
+	} else if in.SongImageMediaID.Null {
+		out.clearSongImageMedia = true
+		if !in.SongImage.Set {
+			out.SongImage = ""
+		}
+	}
+
+	return out, fields
+}
+
+// PatchActivity แก้ไขเฉพาะฟิลด์ที่ส่งมา (ดู activityPatchInput) แล้วตรวจสอบด้วยกฎเดียวกับ UpdateActivity
+// ต้องส่ง If-Match เป็น ETag ที่ได้จาก GetAdminActivity เช่นเดียวกับ PUT
+func PatchActivity(db *gorm.DB, qrCfg *config.QRConfig, relatedCache *related.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var activity models.Activity
+		if err := db.Preload("SubGoals").Preload("SubCategories").First(&activity, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
+		if !requireIfMatch(c, db, &activity) {
+			return
+		}
+		var patch activityPatchInput
+		if err := c.ShouldBindJSON(&patch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		input, fields := patch.toUpdate(&activity)
+		tagFields, err := validateStruct(input)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for field, message := range tagFields {
+			fields.add(field, "%s", message)
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+		saveActivityUpdate(c, db, qrCfg, relatedCache, &activity, input)
+	}
+}
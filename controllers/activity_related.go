@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"project-backend/config"
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/related"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type relatedActivity struct {
+	Activity models.Activity  `json:"activity"`
+	Score    int              `json:"score"`
+	Reasons  []related.Reason `json:"reasons"`
+}
+
+// GetRelatedActivities คืนกิจกรรมที่มีเป้าหมายย่อย/หมวดหมู่ย่อยร่วมกับกิจกรรมนี้ พร้อมคะแนนและเหตุผล
+// ?limit=N (ค่าเริ่มต้นและค่าสูงสุดตาม RelatedConfig)
+func GetRelatedActivities(db *gorm.DB, cache *related.Cache, cfg *config.RelatedConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		activityID, ok := requirePublishedActivity(c, db)
+		if !ok {
+			return
+		}
+
+		var err error
+		limit := cfg.DefaultLimit
+		if raw := c.Query("limit"); raw != "" {
+			limit, err = strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > cfg.MaxLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(cfg.MaxLimit)})
+				return
+			}
+		}
+
+		// cache เก็บผลลัพธ์ขนาด MaxLimit เสมอ แล้วตัดตาม limit ของแต่ละ request
+		matches, ok := cache.Get(activityID)
+		if !ok {
+			matches, err = related.Find(db, activityID, cfg.MaxLimit)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			cache.Set(activityID, matches)
+		}
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+
+		ids := make([]uint, 0, len(matches))
+		for _, m := range matches {
+			ids = append(ids, m.ActivityID)
+		}
+		var activities []models.Activity
+		if len(ids) > 0 {
+			if err := db.Preload("CoverMedia.Variants").Where("id IN ?", ids).Find(&activities).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		byID := make(map[uint]models.Activity, len(activities))
+		for _, a := range activities {
+			byID[a.ID] = a
+		}
+
+		// คัดลอก reasons ก่อนแปล เพราะ slice ใน cache ใช้ร่วมกันทุก request
+		results := make([]relatedActivity, 0, len(matches))
+		for _, m := range matches {
+			a, ok := byID[m.ActivityID]
+			if !ok {
+				continue
+			}
+			results = append(results, relatedActivity{
+				Activity: a,
+				Score:    m.Score,
+				Reasons:  append([]related.Reason(nil), m.Reasons...),
+			})
+		}
+
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range results {
+			batch.Activity(&results[i].Activity)
+			for j := range results[i].Reasons {
+				r := &results[i].Reasons[j]
+				if entity, ok := i18n.Lookup(r.Kind); ok {
+					batch.Add(r.Kind, r.ID, entity.Fields[0], &r.Name)
+				}
+			}
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"activity_id": activityID, "related": results})
+	}
+}
+
+// sameIDSet ตรวจว่า ID สองชุดเหมือนกันโดยไม่สนลำดับ
+func sameIDSet(a, b []uint) bool {
+	a, b = uniqueIDs(a), uniqueIDs(b)
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[uint]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ActivityStepInput คือข้อมูลขั้นตอนที่รับมาจาก Client
+// ถ้าส่ง step_id มาด้วยจะเป็นการแก้ไข/เรียงลำดับขั้นตอนเดิม ถ้าไม่ส่งจะสร้างขั้นตอนใหม่
+// instruction เป็น Markdown ข้อความ HTML จาก client เดิมถูกแปลงเป็น Markdown ก่อนบันทึก (ดู legacyMarkdown)
+type ActivityStepInput struct {
+	StepID          *uint  `json:"step_id"`
+	Instruction     string `json:"instruction" binding:"notblank,max=5000"`
+	DurationSeconds *int   `json:"duration_seconds" binding:"omitempty,gte=0"`
+	SubGoalID       *uint  `json:"sub_goal_id"`
+	FacilitatorCue  string `json:"facilitator_cue" binding:"max=2000"`
+	MediaURL        string `json:"media_url" binding:"omitempty,max=2048,weburl"`
+	MediaID         *uint  `json:"media_id"`
+}
+
+// qrCodeMetadata โหลดเฉพาะข้อมูลของ QR ไม่รวมภาพ PNG/SVG ที่เก็บไว้
+func qrCodeMetadata(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "activity_id", "slot", "target_url", "level", "size", "updated_at").Order("slot ASC")
+}
+
+// orderedSteps ใช้กับ Preload("Steps") เพื่อให้ได้ขั้นตอนตามลำดับเสมอ
+func orderedSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("activity_steps.position ASC")
+}
+
+// preloadActivityDetails โหลดความสัมพันธ์ทั้งหมดที่ใช้แสดงรายละเอียดกิจกรรม
+// กิจกรรมต้นแบบและกิจกรรมที่ clone ไปโหลดเฉพาะที่เผยแพร่แล้ว route ของ admin ใช้ adminActivityRelations แทน
+func preloadActivityDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("SubGoals").
+		Preload("SubCategories").
+		Preload("Steps", orderedSteps).
+		Preload("Steps.Media").
+		Preload("Variants", orderedVariants).
+		Preload("Variants.SubGoals").
+		Preload("Variants.SelectedEquipment").
+		Preload("Variants.Songs").
+		Preload("EquipmentItems.Equipment").
+		Preload("Songs").
+		Preload("TargetPopulations", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Preload("QRCodes", qrCodeMetadata).
+		Preload("CoverMedia.Variants").
+		Preload("SongImageMedia.Variants").
+		Preload("DerivedFrom", publishedActivities).
+		Preload("Derivatives", publishedDerivatives)
+}
+
+// publishedActivities จำกัด query ของ activities ให้เหลือเฉพาะกิจกรรมที่เผยแพร่แล้ว
+// ใช้กับทุก route ที่ไม่ใช่ของ admin ฉบับร่างจึงตอบ 404 เหมือนไม่มีกิจกรรม
+func publishedActivities(db *gorm.DB) *gorm.DB {
+	return db.Where("activities.status = ?", models.ActivityStatusPublished)
+}
+
+// requirePublishedActivity อ่าน :id แล้วตรวจว่าเป็นกิจกรรมที่เผยแพร่แล้ว ถ้าไม่ใช่ตอบ 400/404 แล้วคืน false
+func requirePublishedActivity(c *gin.Context, db *gorm.DB) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid activity ID"})
+		return 0, false
+	}
+	var count int64
+	if err := db.Model(&models.Activity{}).Scopes(publishedActivities).Where("activities.id = ?", id).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// publishedDerivatives แสดงเฉพาะกิจกรรมที่ clone ไปแล้วเผยแพร่ ฉบับร่างดูได้จากรายการของ admin
+func publishedDerivatives(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", models.ActivityStatusPublished).Order("id ASC")
+}
+
+// validateActivitySteps ตรวจสอบขั้นตอนก่อนบันทึก
+// activityID เป็น 0 เมื่อสร้างกิจกรรมใหม่ (ยังไม่มีขั้นตอนเดิมให้อ้างอิงด้วย step_id)
+func validateActivitySteps(db *gorm.DB, activityID uint, inputs []ActivityStepInput) error {
+	seen := make(map[uint]bool, len(inputs))
+	for i, in := range inputs {
+		if strings.TrimSpace(in.Instruction) == "" {
+			return fmt.Errorf("step %d: instruction is required", i+1)
+		}
+		if in.DurationSeconds != nil && *in.DurationSeconds < 0 {
+			return fmt.Errorf("step %d: duration_seconds must not be negative", i+1)
+		}
+		if in.SubGoalID != nil {
+			var count int64
+			if err := db.Model(&models.ActivitySubGoal{}).Where("id = ?", *in.SubGoalID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("step %d: sub_goal_id %d not found", i+1, *in.SubGoalID)
+			}
+		}
+		if in.MediaID != nil {
+			var count int64
+			if err := db.Model(&models.Media{}).
+				Where("id = ? AND purpose IN ?", *in.MediaID, []string{models.MediaPurposeStep, models.MediaPurposeActivityCover}).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("step %d: media_id %d not found", i+1, *in.MediaID)
+			}
+		}
+		if in.StepID != nil {
+			if seen[*in.StepID] {
+				return fmt.Errorf("step %d: step_id %d is listed more than once", i+1, *in.StepID)
+			}
+			seen[*in.StepID] = true
+
+			var count int64
+			if err := db.Model(&models.ActivityStep{}).
+				Where("id = ? AND activity_id = ?", *in.StepID, activityID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("step %d: step_id %d does not belong to this activity", i+1, *in.StepID)
+			}
+		}
+	}
+	return nil
+}
+
+// replaceActivitySteps แทนที่ขั้นตอนทั้งหมดของกิจกรรมตามลำดับใน inputs
+// (ขั้นตอนที่ไม่อยู่ในรายการจะถูกลบ) ต้องเรียกภายใน Transaction
+// และผ่าน validateActivitySteps มาก่อนแล้ว
+func replaceActivitySteps(tx *gorm.DB, activityID uint, inputs []ActivityStepInput) error {
+	keep := make([]uint, 0, len(inputs))
+	for i, in := range inputs {
+		step := models.ActivityStep{
+			ActivityID:      activityID,
+			Position:        i + 1,
+			Instruction:     legacyMarkdown(in.Instruction),
+			DurationSeconds: in.DurationSeconds,
+			SubGoalID:       in.SubGoalID,
+			FacilitatorCue:  in.FacilitatorCue,
+			MediaURL:        in.MediaURL,
+			MediaID:         in.MediaID,
+		}
+
+		if in.StepID != nil {
+			step.ID = *in.StepID
+			if err := tx.Omit("SubGoal", "Media").Save(&step).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Omit("SubGoal", "Media").Create(&step).Error; err != nil {
+			return err
+		}
+		keep = append(keep, step.ID)
+	}
+
+	cleanup := tx.Where("activity_id = ?", activityID)
+	if len(keep) > 0 {
+		cleanup = cleanup.Where("id NOT IN ?", keep)
+	}
+	return cleanup.Delete(&models.ActivityStep{}).Error
+}
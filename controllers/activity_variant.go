@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"project-backend/i18n"
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ActivityVariantInput คือข้อมูลรูปแบบกิจกรรม (ง่ายขึ้น/ยากขึ้น) ที่รับมาจาก Client
+// ถ้าส่ง variant_id มาด้วยจะเป็นการแก้ไขรูปแบบเดิม ถ้าไม่ส่งจะสร้างรูปแบบใหม่
+// process เป็น Markdown ข้อความ HTML จาก client เดิมถูกแปลงเป็น Markdown ก่อนบันทึก (ดู legacyMarkdown)
+// equipment_ids และ song_ids ต้องเป็นอุปกรณ์/เพลงที่อยู่ในรายการของกิจกรรมหลัก
+type ActivityVariantInput struct {
+	VariantID    *uint  `json:"variant_id"`
+	Level        string `json:"level" binding:"oneof=easier harder"`
+	Label        string `json:"label" binding:"notblank,max=200"`
+	Process      string `json:"process" binding:"max=20000"`
+	Equipment    string `json:"equipment" binding:"max=2000"`
+	Song         string `json:"song" binding:"max=2000"`
+	SubGoalIDs   []uint `json:"sub_goal_ids"`
+	EquipmentIDs []uint `json:"equipment_ids"`
+	SongIDs      []uint `json:"song_ids"`
+}
+
+// variantReferences คืนอุปกรณ์และเพลงของกิจกรรมที่รูปแบบอ้างอิงได้
+// ใช้รายการจาก request ถ้าส่งมา (equipmentIDs/songIDs ไม่เป็น nil) ไม่เช่นนั้นใช้รายการที่บันทึกไว้
+func variantReferences(db *gorm.DB, activityID uint, equipmentIDs, songIDs []uint) (map[uint]bool, map[uint]bool, error) {
+	if equipmentIDs == nil && activityID != 0 {
+		if err := db.Model(&models.ActivityEquipment{}).Where("activity_id = ?", activityID).Pluck("equipment_id", &equipmentIDs).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	if songIDs == nil && activityID != 0 {
+		if err := db.Table("activity_songs").Where("activity_id = ?", activityID).Pluck("song_id", &songIDs).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	equipment := make(map[uint]bool, len(equipmentIDs))
+	for _, id := range equipmentIDs {
+		equipment[id] = true
+	}
+	songs := make(map[uint]bool, len(songIDs))
+	for _, id := range songIDs {
+		songs[id] = true
+	}
+	return equipment, songs, nil
+}
+
+// orderedVariants ใช้กับ Preload("Variants") เพื่อให้ได้รูปแบบตามลำดับเสมอ
+func orderedVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("activity_variants.position ASC")
+}
+
+// validateActivityVariants ตรวจสอบรูปแบบกิจกรรมก่อนบันทึก
+// activityID เป็น 0 เมื่อสร้างกิจกรรมใหม่ เป้าหมายย่อยที่ถูกยกเลิกแล้วคงไว้ได้เฉพาะรูปแบบที่เลือกไว้อยู่เดิม
+// equipment และ songs คืออุปกรณ์/เพลงของกิจกรรมหลักหลังบันทึก (ดู variantReferences)
+func validateActivityVariants(db *gorm.DB, activityID uint, inputs []ActivityVariantInput, equipment, songs map[uint]bool) error {
+	seen := make(map[uint]bool, len(inputs))
+	for i, in := range inputs {
+		if !models.ValidVariantLevel(in.Level) {
+			return fmt.Errorf("variant %d: level must be %s or %s", i+1, models.VariantLevelEasier, models.VariantLevelHarder)
+		}
+		if strings.TrimSpace(in.Label) == "" {
+			return fmt.Errorf("variant %d: label is required", i+1)
+		}
+		for _, id := range in.EquipmentIDs {
+			if !equipment[id] {
+				return fmt.Errorf("variant %d: equipment_id %d is not in the activity's equipment_items", i+1, id)
+			}
+		}
+		for _, id := range in.SongIDs {
+			if !songs[id] {
+				return fmt.Errorf("variant %d: song_id %d is not in the activity's song_ids", i+1, id)
+			}
+		}
+
+		var current []uint
+		if in.VariantID != nil {
+			if seen[*in.VariantID] {
+				return fmt.Errorf("variant %d: variant_id %d is listed more than once", i+1, *in.VariantID)
+			}
+			seen[*in.VariantID] = true
+
+			var count int64
+			if err := db.Model(&models.ActivityVariant{}).
+				Where("id = ? AND activity_id = ?", *in.VariantID, activityID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("variant %d: variant_id %d does not belong to this activity", i+1, *in.VariantID)
+			}
+			if err := db.Table("activity_variant_sub_goals").
+				Where("activity_variant_id = ?", *in.VariantID).
+				Pluck("activity_sub_goal_id", &current).Error; err != nil {
+				return err
+			}
+		}
+
+		ids := uniqueIDs(in.SubGoalIDs)
+		if len(ids) == 0 {
+			continue
+		}
+		var count int64
+		if err := db.Model(&models.ActivitySubGoal{}).
+			Where("id IN ?", ids).
+			Where("retired_at IS NULL OR id IN ?", current).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(ids) {
+			return fmt.Errorf("variant %d: sub_goal_ids contains unknown or retired sub-goals", i+1)
+		}
+	}
+	return nil
+}
+
+// replaceActivityVariants แทนที่รูปแบบทั้งหมดของกิจกรรมตามลำดับใน inputs
+// (รูปแบบที่ไม่อยู่ในรายการจะถูกลบพร้อมคำแปล) ต้องเรียกภายใน Transaction
+// และผ่าน validateActivityVariants มาก่อนแล้ว
+func replaceActivityVariants(tx *gorm.DB, activityID uint, inputs []ActivityVariantInput) error {
+	keep := make([]uint, 0, len(inputs))
+	for i, in := range inputs {
+		variant := models.ActivityVariant{
+			ActivityID: activityID,
+			Position:   i + 1,
+			Level:      in.Level,
+			Label:      strings.TrimSpace(in.Label),
+			Process:    legacyMarkdown(in.Process),
+			Equipment:  in.Equipment,
+			Song:       in.Song,
+		}
+
+		if in.VariantID != nil {
+			variant.ID = *in.VariantID
+			if err := tx.Omit("SubGoals", "SelectedEquipment", "Songs", "CreatedAt").Save(&variant).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Omit("SubGoals", "SelectedEquipment", "Songs").Create(&variant).Error; err != nil {
+			return err
+		}
+
+		var subGoals []models.ActivitySubGoal
+		if ids := uniqueIDs(in.SubGoalIDs); len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&subGoals).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&variant).Association("SubGoals").Replace(subGoals); err != nil {
+			return err
+		}
+
+		var equipment []models.Equipment
+		if ids := uniqueIDs(in.EquipmentIDs); len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&equipment).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&variant).Association("SelectedEquipment").Replace(equipment); err != nil {
+			return err
+		}
+
+		var songs []models.Song
+		if ids := uniqueIDs(in.SongIDs); len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&songs).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&variant).Association("Songs").Replace(songs); err != nil {
+			return err
+		}
+		keep = append(keep, variant.ID)
+	}
+
+	removed := tx.Model(&models.ActivityVariant{}).Select("id").Where("activity_id = ?", activityID)
+	if len(keep) > 0 {
+		removed = removed.Where("id NOT IN ?", keep)
+	}
+	return deleteVariants(tx, removed)
+}
+
+// deleteVariants ลบรูปแบบกิจกรรมตาม subquery ของ id พร้อมเป้าหมายย่อย อุปกรณ์ และเพลงที่เลือกไว้ และคำแปล
+func deleteVariants(tx *gorm.DB, ids *gorm.DB) error {
+	for _, table := range []string{"activity_variant_sub_goals", "activity_variant_equipment", "activity_variant_songs"} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE activity_variant_id IN (?)", ids).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("entity_type = ? AND entity_id IN (?)", i18n.EntityVariant, ids).Delete(&models.Translation{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN (?)", ids).Delete(&models.ActivityVariant{}).Error
+}
+
+// pruneVariantReferences ถอดอุปกรณ์และเพลงที่ไม่อยู่ในรายการของกิจกรรมหลักแล้วออกจากรูปแบบ
+// เรียกภายใน Transaction หลังแทนที่ equipment_items หรือ song_ids โดยไม่ได้ส่ง variants มาด้วย
+func pruneVariantReferences(tx *gorm.DB, activityID uint) error {
+	variants := tx.Model(&models.ActivityVariant{}).Select("id").Where("activity_id = ?", activityID)
+	if err := tx.Exec("DELETE FROM activity_variant_equipment WHERE activity_variant_id IN (?) AND equipment_id NOT IN (SELECT equipment_id FROM activity_equipments WHERE activity_id = ?)", variants, activityID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM activity_variant_songs WHERE activity_variant_id IN (?) AND song_id NOT IN (SELECT song_id FROM activity_songs WHERE activity_id = ?)", variants, activityID).Error
+}
+
+// deleteActivityVariants ลบรูปแบบทั้งหมดของกิจกรรม
+func deleteActivityVariants(tx *gorm.DB, activityID uint) error {
+	return deleteVariants(tx, tx.Model(&models.ActivityVariant{}).Select("id").Where("activity_id = ?", activityID))
+}
+
+// variantSearchResult คือรูปแบบกิจกรรมที่ค้นเจอ พร้อมข้อมูลย่อของกิจกรรมหลัก
+type variantSearchResult struct {
+	models.ActivityVariant
+	Activity models.ActivitySummary `json:"activity"`
+}
+
+// SearchActivityVariants ค้นหารูปแบบกิจกรรมโดยตรง (เฉพาะของกิจกรรมที่เผยแพร่แล้ว)
+// รองรับ ?q= (ชื่อรูปแบบ/ขั้นตอน/อุปกรณ์/เพลง) ?level= ?activity_id= ?sub_goal_id= ?goal_id=
+func SearchActivityVariants(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&models.ActivityVariant{}).
+			Preload("SubGoals").
+			Preload("SelectedEquipment").
+			Preload("Songs").
+			Joins("JOIN activities ON activities.id = activity_variants.activity_id").
+			Where("activities.status = ?", models.ActivityStatusPublished)
+
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			pattern := "%" + q + "%"
+			match := "activity_variants.label ILIKE @q OR activity_variants.process ILIKE @q OR activity_variants.equipment ILIKE @q OR activity_variants.song ILIKE @q" +
+				" OR EXISTS (SELECT 1 FROM activity_variant_equipment JOIN equipment ON equipment.id = activity_variant_equipment.equipment_id WHERE activity_variant_equipment.activity_variant_id = activity_variants.id AND equipment.name ILIKE @q)" +
+				" OR EXISTS (SELECT 1 FROM activity_variant_songs JOIN songs ON songs.id = activity_variant_songs.song_id WHERE activity_variant_songs.activity_variant_id = activity_variants.id AND songs.title ILIKE @q)"
+			args := map[string]interface{}{"q": pattern}
+			// ค้นหาในคำแปลด้วย เมื่อ request ขอภาษาอื่นที่ไม่ใช่ภาษาไทย
+			if chain := i18n.Chain(c.GetString("locale")); len(chain) > 0 {
+				match += " OR EXISTS (SELECT 1 FROM translations WHERE translations.entity_type = @entity AND translations.entity_id = activity_variants.id AND translations.locale IN @chain AND translations.value ILIKE @q)"
+				args["entity"] = i18n.EntityVariant
+				args["chain"] = chain
+			}
+			query = query.Where(match, args)
+		}
+
+		if level := c.Query("level"); level != "" {
+			if !models.ValidVariantLevel(level) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "level must be easier or harder"})
+				return
+			}
+			query = query.Where("activity_variants.level = ?", level)
+		}
+
+		if activityID := c.Query("activity_id"); activityID != "" {
+			query = query.Where("activity_variants.activity_id = ?", activityID)
+		}
+
+		if subGoalID := c.Query("sub_goal_id"); subGoalID != "" {
+			query = query.Where("EXISTS (SELECT 1 FROM activity_variant_sub_goals WHERE activity_variant_sub_goals.activity_variant_id = activity_variants.id AND activity_variant_sub_goals.activity_sub_goal_id = ?)", subGoalID)
+		}
+
+		if goalID := c.Query("goal_id"); goalID != "" {
+			query = query.Where("EXISTS (SELECT 1 FROM activity_variant_sub_goals JOIN activity_sub_goals ON activity_sub_goals.id = activity_variant_sub_goals.activity_sub_goal_id WHERE activity_variant_sub_goals.activity_variant_id = activity_variants.id AND activity_sub_goals.goal_id = ?)", goalID)
+		}
+
+		var variants []models.ActivityVariant
+		if err := query.Select("activity_variants.*").
+			Order("activity_variants.activity_id DESC, activity_variants.position ASC").
+			Find(&variants).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		activityIDs := make([]uint, 0, len(variants))
+		for _, v := range variants {
+			activityIDs = append(activityIDs, v.ActivityID)
+		}
+		var summaries []models.ActivitySummary
+		if err := db.Where("id IN ?", uniqueIDs(activityIDs)).Find(&summaries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		byID := make(map[uint]models.ActivitySummary, len(summaries))
+		for _, s := range summaries {
+			byID[s.ID] = s
+		}
+
+		results := make([]variantSearchResult, 0, len(variants))
+		for _, v := range variants {
+			results = append(results, variantSearchResult{ActivityVariant: v, Activity: byID[v.ActivityID]})
+		}
+
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range results {
+			batch.Variant(&results[i].ActivityVariant)
+			batch.Add(i18n.EntityActivity, results[i].Activity.ID, "title", &results[i].Activity.Title)
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, results)
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errStaleActivity คือกรณีที่กิจกรรมถูกแก้ไขไปแล้วระหว่างที่ตรวจ If-Match กับตอนเขียนจริง
+var errStaleActivity = errors.New("activity was modified by someone else")
+
+// activityETag คือ ETag ของกิจกรรมตาม version
+func activityETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseIfMatch อ่าน version จาก header If-Match รับทั้ง "3" และ W/"3"
+// ถ้าส่งมาหลายค่า (คั่นด้วย ,) ใช้ค่าที่ตรงกับ current ก่อน
+func parseIfMatch(header string, current int) (int, bool) {
+	version, found := 0, false
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		v, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err != nil || v < 1 {
+			continue
+		}
+		if v == current {
+			return v, true
+		}
+		version, found = v, true
+	}
+	return version, found
+}
+
+// requireIfMatch ตรวจว่า request ส่ง If-Match ที่ตรงกับ version ปัจจุบันของกิจกรรม
+// ไม่ส่งมาตอบ 428 ส่วน version ไม่ตรงตอบ 412 พร้อม version และข้อมูลปัจจุบัน แล้วคืน false
+func requireIfMatch(c *gin.Context, db *gorm.DB, activity *models.Activity) bool {
+	header := c.GetHeader("If-Match")
+	if strings.TrimSpace(header) == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error":           "If-Match header with the activity version is required",
+			"current_version": activity.Version,
+		})
+		return false
+	}
+	version, ok := parseIfMatch(header, activity.Version)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be an activity ETag such as " + activityETag(activity.Version)})
+		return false
+	}
+	if version != activity.Version {
+		respondStaleActivity(c, db, activity.ID)
+		return false
+	}
+	return true
+}
+
+// respondStaleActivity ตอบ 412 พร้อม version ล่าสุดและกิจกรรมฉบับปัจจุบัน ให้หน้า admin แสดงการรวมการแก้ไขได้
+func respondStaleActivity(c *gin.Context, db *gorm.DB, id uint) {
+	var current models.Activity
+	if err := preloadActivityDetails(db).First(&current, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+		return
+	}
+	c.Header("ETag", activityETag(current.Version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":           "activity has been modified since it was loaded",
+		"current_version": current.Version,
+		"current":         current,
+	})
+}
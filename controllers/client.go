@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ClientGuardianInput คือผู้ปกครอง/ผู้ติดต่อของผู้รับบริการ
+type ClientGuardianInput struct {
+	Name         string `json:"name" binding:"notblank,max=200"`
+	Relationship string `json:"relationship" binding:"max=100"`
+	Phone        string `json:"phone" binding:"max=50"`
+	Email        string `json:"email" binding:"omitempty,max=254,email"`
+}
+
+// ClientInput คือข้อมูลผู้รับบริการที่แก้ไขได้ (PUT แทนที่ทั้งหมดรวมรายชื่อผู้ปกครอง)
+type ClientInput struct {
+	Name          string                `json:"name" binding:"notblank,max=200"`
+	IsPseudonym   bool                  `json:"is_pseudonym"`
+	DateOfBirth   string                `json:"date_of_birth" binding:"omitempty,datetime=2006-01-02"`
+	DiagnosisTags []string              `json:"diagnosis_tags" binding:"max=50,dive,notblank,max=100"`
+	Notes         string                `json:"notes" binding:"max=20000"`
+	Guardians     []ClientGuardianInput `json:"guardians" binding:"max=20,dive"`
+}
+
+// clientCreateInput เลือกเจ้าของได้ตอนสร้างเท่านั้น ถ้าไม่ส่ง organization_id ผู้สร้างเป็นเจ้าของ
+type clientCreateInput struct {
+	ClientInput
+	OrganizationID *uint `json:"organization_id"`
+}
+
+// normalizeDiagnosisTags ตัดช่องว่างและรวมแท็กที่ซ้ำกัน (ไม่สนตัวพิมพ์) โดยคงลำดับเดิม
+func normalizeDiagnosisTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// apply ตรวจค่าที่ binding tag ตรวจไม่ได้แล้วเขียนลงใน client
+func (in *ClientInput) apply(client *models.Client, fields fieldErrors) {
+	client.Name = strings.TrimSpace(in.Name)
+	client.IsPseudonym = in.IsPseudonym
+	client.DiagnosisTags = normalizeDiagnosisTags(in.DiagnosisTags)
+	client.Notes = in.Notes
+
+	client.DateOfBirth = nil
+	if in.DateOfBirth != "" {
+		dob, err := time.Parse("2006-01-02", in.DateOfBirth)
+		if err != nil {
+			fields.add("date_of_birth", "must be a date in the format 2006-01-02")
+		} else if dob.After(time.Now()) {
+			fields.add("date_of_birth", "must not be in the future")
+		} else {
+			client.DateOfBirth = &dob
+		}
+	}
+}
+
+// replaceClientGuardians แทนที่รายชื่อผู้ปกครองทั้งหมดตามลำดับใน inputs ต้องเรียกภายใน Transaction
+func replaceClientGuardians(tx *gorm.DB, clientID uint, inputs []ClientGuardianInput) error {
+	if err := tx.Where("client_id = ?", clientID).Delete(&models.ClientGuardian{}).Error; err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return nil
+	}
+	guardians := make([]models.ClientGuardian, 0, len(inputs))
+	for i, in := range inputs {
+		guardians = append(guardians, models.ClientGuardian{
+			ClientID:     clientID,
+			Position:     i + 1,
+			Name:         strings.TrimSpace(in.Name),
+			Relationship: strings.TrimSpace(in.Relationship),
+			Phone:        strings.TrimSpace(in.Phone),
+			Email:        strings.TrimSpace(in.Email),
+		})
+	}
+	return tx.Create(&guardians).Error
+}
+
+func orderedGuardians(db *gorm.DB) *gorm.DB {
+	return db.Order("client_guardians.position ASC")
+}
+
+// isOrganizationMember ตรวจว่าผู้ใช้เป็นสมาชิกของหน่วยงาน
+func isOrganizationMember(db *gorm.DB, organizationID, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListClients คืนผู้รับบริการที่ผู้ใช้เข้าถึงได้ (ของตนเองและของหน่วยงานที่เป็นสมาชิก) เรียงตามชื่อ
+// รองรับ ?q= (ชื่อ) ?tag= (แท็กการวินิจฉัย ไม่สนตัวพิมพ์) ?organization_id= และ ?owner=me
+func ListClients(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		query := db.Model(&models.Client{}).
+			Scopes(models.ClientsAccessibleBy(userID)).
+			Preload("Guardians", orderedGuardians)
+
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			query = query.Where("clients.name ILIKE ?", "%"+q+"%")
+		}
+		if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+			query = query.Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(clients.diagnosis_tags) AS t(tag) WHERE LOWER(t.tag) = LOWER(?))", tag)
+		}
+		if organizationID := c.Query("organization_id"); organizationID != "" {
+			query = query.Where("clients.organization_id = ?", organizationID)
+		}
+		if c.Query("owner") == "me" {
+			query = query.Where("clients.owner_user_id = ?", userID)
+		}
+
+		var clients []models.Client
+		if err := query.Order("clients.name ASC, clients.id ASC").Find(&clients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, clients)
+	}
+}
+
+// CreateClient สร้างผู้รับบริการ ถ้าส่ง organization_id มาผู้สร้างต้องเป็นสมาชิกของหน่วยงานนั้น
+func CreateClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		var input clientCreateInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+
+		client := models.Client{CreatedByID: userID}
+		fields := fieldErrors{}
+		input.apply(&client, fields)
+
+		if input.OrganizationID != nil {
+			member, err := isOrganizationMember(db, *input.OrganizationID, userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !member {
+				fields.add("organization_id", "you are not a member of this organization")
+			}
+			client.OrganizationID = input.OrganizationID
+		} else {
+			client.OwnerUserID = &userID
+		}
+
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("Guardians", "Organization").Create(&client).Error; err != nil {
+				return err
+			}
+			return replaceClientGuardians(tx, client.ID, input.Guardians)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		respondClient(c, db, http.StatusCreated, client.ID)
+	}
+}
+
+// GetClient คืนผู้รับบริการที่ middleware.ClientAccess โหลดและตรวจสิทธิ์แล้ว
+func GetClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet("client").(*models.Client)
+		respondClient(c, db, http.StatusOK, client.ID)
+	}
+}
+
+// UpdateClient แก้ไขข้อมูลผู้รับบริการทั้งหมด (เจ้าของเปลี่ยนไม่ได้)
+func UpdateClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet("client").(*models.Client)
+
+		var input ClientInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		fields := fieldErrors{}
+		input.apply(client, fields)
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(client).Select("name", "is_pseudonym", "date_of_birth", "diagnosis_tags", "notes").
+				Updates(client).Error; err != nil {
+				return err
+			}
+			return replaceClientGuardians(tx, client.ID, input.Guardians)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		respondClient(c, db, http.StatusOK, client.ID)
+	}
+}
+
+// DeleteClient ลบผู้รับบริการแบบ soft delete (ข้อมูลยังอยู่ในฐานข้อมูลแต่ไม่แสดงในระบบ)
+func DeleteClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet("client").(*models.Client)
+		if err := db.Delete(client).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Client deleted successfully"})
+	}
+}
+
+func respondClient(c *gin.Context, db *gorm.DB, status int, id uint) {
+	var client models.Client
+	if err := db.Preload("Guardians", orderedGuardians).Preload("Organization").First(&client, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Client not found"})
+		return
+	}
+	c.JSON(status, client)
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ClientGroupInput คือข้อมูลกลุ่มผู้รับบริการ client_ids แทนที่สมาชิกทั้งหมดของกลุ่ม
+type ClientGroupInput struct {
+	Name      string `json:"name" binding:"notblank,max=200"`
+	Notes     string `json:"notes" binding:"max=20000"`
+	ClientIDs []uint `json:"client_ids" binding:"max=100"`
+}
+
+// clientGroupCreateInput เลือกเจ้าของได้ตอนสร้างเท่านั้น เหมือน clientCreateInput
+type clientGroupCreateInput struct {
+	ClientGroupInput
+	OrganizationID *uint `json:"organization_id"`
+}
+
+// findGroupMembers โหลดผู้รับบริการตาม ids ที่ผู้ใช้เข้าถึงได้และมีเจ้าของเดียวกับกลุ่ม
+// กลุ่มของหน่วยงานรับเฉพาะผู้รับบริการของหน่วยงานนั้น กลุ่มส่วนตัวรับเฉพาะผู้รับบริการส่วนตัวของเจ้าของกลุ่ม
+// เพื่อไม่ให้สมาชิกคนอื่นของหน่วยงานเห็นข้อมูลผู้รับบริการส่วนตัวผ่านกลุ่ม, session และผลการสังเกต
+// ถ้ามีรายการที่ไม่ผ่านคืน error
+func findGroupMembers(db *gorm.DB, userID uint, group *models.ClientGroup, ids []uint) ([]models.Client, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var clients []models.Client
+	if err := db.Scopes(models.ClientsAccessibleBy(userID)).Where("id IN ?", ids).Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	if len(clients) != len(ids) {
+		return nil, fmt.Errorf("contains clients that do not exist or that you cannot access")
+	}
+	for _, client := range clients {
+		if !sameID(client.OrganizationID, group.OrganizationID) || !sameID(client.OwnerUserID, group.OwnerUserID) {
+			if group.OrganizationID != nil {
+				return nil, fmt.Errorf("client %d does not belong to the group's organization", client.ID)
+			}
+			return nil, fmt.Errorf("client %d is not one of your personal clients", client.ID)
+		}
+	}
+	return clients, nil
+}
+
+// sameID เทียบ id ที่เป็น null ได้
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func clientGroupMembers(db *gorm.DB) *gorm.DB {
+	return db.Order("clients.name ASC")
+}
+
+// ListClientGroups คืนกลุ่มผู้รับบริการที่ผู้ใช้เข้าถึงได้พร้อมสมาชิก ?organization_id= กรองตามหน่วยงาน
+func ListClientGroups(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		query := db.Model(&models.ClientGroup{}).
+			Scopes(models.ClientGroupsAccessibleBy(userID)).
+			Preload("Members", clientGroupMembers)
+		if organizationID := c.Query("organization_id"); organizationID != "" {
+			query = query.Where("client_groups.organization_id = ?", organizationID)
+		}
+
+		var groups []models.ClientGroup
+		if err := query.Order("client_groups.name ASC, client_groups.id ASC").Find(&groups).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, groups)
+	}
+}
+
+// CreateClientGroup สร้างกลุ่มผู้รับบริการ สมาชิกต้องเป็นผู้รับบริการที่ผู้สร้างเข้าถึงได้และมีเจ้าของเดียวกับกลุ่ม
+func CreateClientGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		var input clientGroupCreateInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+
+		fields := fieldErrors{}
+		group := models.ClientGroup{
+			CreatedByID: userID,
+			Name:        strings.TrimSpace(input.Name),
+			Notes:       input.Notes,
+		}
+		if input.OrganizationID != nil {
+			member, err := isOrganizationMember(db, *input.OrganizationID, userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !member {
+				fields.add("organization_id", "you are not a member of this organization")
+			}
+			group.OrganizationID = input.OrganizationID
+		} else {
+			group.OwnerUserID = &userID
+		}
+		members, err := findGroupMembers(db, userID, &group, input.ClientIDs)
+		if err != nil {
+			fields.add("client_ids", "%v", err)
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		group.Members = members
+		if err := db.Omit("Members.*").Create(&group).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondClientGroup(c, db, http.StatusCreated, group.ID)
+	}
+}
+
+// GetClientGroup คืนกลุ่มที่ middleware.ClientGroupAccess ตรวจสิทธิ์แล้ว
+func GetClientGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group := c.MustGet("client_group").(*models.ClientGroup)
+		respondClientGroup(c, db, http.StatusOK, group.ID)
+	}
+}
+
+// UpdateClientGroup แก้ไขชื่อ หมายเหตุ และแทนที่สมาชิกทั้งหมดของกลุ่ม
+// สมาชิกเดิมที่ผู้ใช้เข้าถึงไม่ได้แล้ว (เช่น ถูกลบ) ต้องเอาออกจากรายการ
+func UpdateClientGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		group := c.MustGet("client_group").(*models.ClientGroup)
+
+		var input ClientGroupInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		members, err := findGroupMembers(db, userID, group, input.ClientIDs)
+		if err != nil {
+			respondValidation(c, fieldErrors{"client_ids": err.Error()})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(group).Updates(map[string]interface{}{
+				"name":  strings.TrimSpace(input.Name),
+				"notes": input.Notes,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Model(group).Association("Members").Replace(members)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondClientGroup(c, db, http.StatusOK, group.ID)
+	}
+}
+
+// DeleteClientGroup ลบกลุ่มแบบ soft delete (ผู้รับบริการในกลุ่มไม่ถูกลบ)
+func DeleteClientGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group := c.MustGet("client_group").(*models.ClientGroup)
+		if err := db.Delete(group).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Client group deleted successfully"})
+	}
+}
+
+func respondClientGroup(c *gin.Context, db *gorm.DB, status int, id uint) {
+	var group models.ClientGroup
+	if err := db.Preload("Members", clientGroupMembers).First(&group, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Client group not found"})
+		return
+	}
+	c.JSON(status, group)
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type EquipmentInput struct {
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+}
+
+// ActivityEquipmentInput คืออุปกรณ์ที่กิจกรรมต้องใช้ (อ้างอิงจาก catalog)
+type ActivityEquipmentInput struct {
+	EquipmentID  uint `json:"equipment_id" binding:"required"`
+	Quantity     int  `json:"quantity" binding:"gte=1"`
+	PerGroupSize int  `json:"per_group_size" binding:"gte=0"`
+}
+
+func ListEquipment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var items []models.Equipment
+
+		query := db.Order("name ASC")
+		if equipmentType := c.Query("type"); equipmentType != "" {
+			query = query.Where("type = ?", equipmentType)
+		}
+		if name := c.Query("name"); name != "" {
+			query = query.Where("name ILIKE ?", "%"+name+"%")
+		}
+
+		if err := query.Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+func CreateEquipment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input EquipmentInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		item := models.Equipment{
+			Name:     strings.TrimSpace(input.Name),
+			Type:     input.Type,
+			ImageURL: input.ImageURL,
+		}
+		if err := db.Create(&item).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Could not create equipment (name may already exist)"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, item)
+	}
+}
+
+func UpdateEquipment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var item models.Equipment
+		if err := db.First(&item, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Equipment not found"})
+			return
+		}
+
+		var input EquipmentInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{
+			"name":      strings.TrimSpace(input.Name),
+			"type":      input.Type,
+			"image_url": input.ImageURL,
+		}
+		if err := db.Model(&item).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Could not update equipment (name may already exist)"})
+			return
+		}
+
+		db.First(&item, item.ID)
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+func DeleteEquipment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var item models.Equipment
+		if err := db.First(&item, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Equipment not found"})
+			return
+		}
+
+		var usage int64
+		db.Model(&models.ActivityEquipment{}).Where("equipment_id = ?", item.ID).Count(&usage)
+		if usage > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "Equipment is still used by activities",
+				"activity_count": usage,
+			})
+			return
+		}
+
+		if err := db.Delete(&item).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Equipment deleted successfully"})
+	}
+}
+
+// validateActivityEquipment ตรวจสอบว่าอุปกรณ์ที่อ้างถึงมีอยู่จริงและจำนวนถูกต้อง
+func validateActivityEquipment(db *gorm.DB, inputs []ActivityEquipmentInput) error {
+	seen := make(map[uint]bool, len(inputs))
+	for i, in := range inputs {
+		if seen[in.EquipmentID] {
+			return fmt.Errorf("equipment %d: equipment_id %d is listed more than once", i+1, in.EquipmentID)
+		}
+		seen[in.EquipmentID] = true
+
+		if in.Quantity < 1 {
+			return fmt.Errorf("equipment %d: quantity must be at least 1", i+1)
+		}
+		if in.PerGroupSize < 0 {
+			return fmt.Errorf("equipment %d: per_group_size must not be negative", i+1)
+		}
+
+		var count int64
+		if err := db.Model(&models.Equipment{}).Where("id = ?", in.EquipmentID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("equipment %d: equipment_id %d not found", i+1, in.EquipmentID)
+		}
+	}
+	return nil
+}
+
+// activityEquipmentIDs คืน equipment_id ของรายการอุปกรณ์ (ไม่เป็น nil แม้รายการว่าง)
+func activityEquipmentIDs(inputs []ActivityEquipmentInput) []uint {
+	ids := make([]uint, 0, len(inputs))
+	for _, in := range inputs {
+		ids = append(ids, in.EquipmentID)
+	}
+	return ids
+}
+
+// replaceActivityEquipment แทนที่รายการอุปกรณ์ทั้งหมดของกิจกรรม (เรียกภายใน Transaction)
+func replaceActivityEquipment(tx *gorm.DB, activityID uint, inputs []ActivityEquipmentInput) error {
+	if err := tx.Where("activity_id = ?", activityID).Delete(&models.ActivityEquipment{}).Error; err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	links := make([]models.ActivityEquipment, 0, len(inputs))
+	for _, in := range inputs {
+		links = append(links, models.ActivityEquipment{
+			ActivityID:   activityID,
+			EquipmentID:  in.EquipmentID,
+			Quantity:     in.Quantity,
+			PerGroupSize: in.PerGroupSize,
+		})
+	}
+	return tx.Omit("Equipment").Create(&links).Error
+}
+
+// parseIDList แปลง query แบบ "1,2,3" เป็น []uint
+func parseIDList(raw string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
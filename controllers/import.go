@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"project-backend/config"
+	"project-backend/importer"
+	"project-backend/jobs"
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const jobTypeActivityImport = "activity_import"
+
+// ImportActivities นำเข้ากิจกรรมจากไฟล์ CSV, XLSX หรือ JSON (multipart field "file")
+// ?dry_run=true ตรวจสอบอย่างเดียวและคืนรายงานรายแถว
+// ?async=true หรือไฟล์ที่มีแถวมากกว่า IMPORT_ASYNC_ROWS จะทำเป็น background job (ตอบ 202 พร้อม job)
+func ImportActivities(db *gorm.DB, runner *jobs.Runner, qrCfg *config.QRConfig, cfg *config.ImportConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxUploadBytes+1<<20)
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+			return
+		}
+		if header.Size > cfg.MaxUploadBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+			return
+		}
+
+		format, err := importer.DetectFormat(c.Query("format"), header.Filename)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+		records, err := importer.Parse(format, file)
+		file.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(records) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File has no rows"})
+			return
+		}
+
+		opts := importer.Options{
+			DryRun:   c.Query("dry_run") == "true",
+			AdminID:  userID,
+			QR:       qrCfg,
+			Validate: ValidateImportedActivity,
+		}
+
+		if c.Query("async") == "true" || len(records) > cfg.AsyncRows {
+			job, err := runner.Start(jobTypeActivityImport, userID, len(records), func(ctx context.Context, progress jobs.Progress) (any, error) {
+				return importer.ImportActivities(ctx, db, records, opts, progress)
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusAccepted, job)
+			return
+		}
+
+		report, err := importer.ImportActivities(c.Request.Context(), db, records, opts, nil)
+		switch {
+		case errors.Is(err, importer.ErrInvalidRows):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+		default:
+			status := http.StatusCreated
+			if opts.DryRun {
+				status = http.StatusOK
+			}
+			c.JSON(status, report)
+		}
+	}
+}
+
+// ValidateImportedActivity ตรวจกิจกรรมที่สร้างจากไฟล์นำเข้าด้วย activityCreateInput ชุดเดียวกับ CreateActivity
+// คืนข้อผิดพลาดรายฟิลด์เรียงตามชื่อฟิลด์ (nil ถ้าผ่าน) ใช้เป็น Validate ของ importer และ library
+func ValidateImportedActivity(activity *models.Activity) []string {
+	input := activityCreateInput{
+		Title:              activity.Title,
+		CoverImage:         activity.CoverImage,
+		GoalDescription:    activity.GoalDescription,
+		Equipment:          activity.Equipment,
+		Process:            activity.Process,
+		ObservableBehavior: activity.ObservableBehavior,
+		Suggestion:         activity.Suggestion,
+		Song:               activity.Song,
+		SongImage:          activity.SongImage,
+		QR1:                activity.QR1,
+		QR2:                activity.QR2,
+		Status:             activity.Status,
+		ActivityAttributesInput: ActivityAttributesInput{
+			AgeMin:            activity.AgeMin,
+			AgeMax:            activity.AgeMax,
+			GroupSizeMin:      activity.GroupSizeMin,
+			GroupSizeMax:      activity.GroupSizeMax,
+			DurationMinutes:   activity.DurationMinutes,
+			Difficulty:        activity.Difficulty,
+			Contraindications: activity.Contraindications,
+			SafetyNotes:       activity.SafetyNotes,
+		},
+	}
+	for _, s := range activity.Steps {
+		input.Steps = append(input.Steps, ActivityStepInput{
+			Instruction:     s.Instruction,
+			DurationSeconds: s.DurationSeconds,
+			FacilitatorCue:  s.FacilitatorCue,
+			MediaURL:        s.MediaURL,
+		})
+	}
+	for _, v := range activity.Variants {
+		variant := ActivityVariantInput{
+			Level:     v.Level,
+			Label:     v.Label,
+			Process:   v.Process,
+			Equipment: v.Equipment,
+			Song:      v.Song,
+		}
+		for _, e := range v.SelectedEquipment {
+			variant.EquipmentIDs = append(variant.EquipmentIDs, e.ID)
+		}
+		for _, song := range v.Songs {
+			variant.SongIDs = append(variant.SongIDs, song.ID)
+		}
+		input.Variants = append(input.Variants, variant)
+	}
+	for _, item := range activity.EquipmentItems {
+		input.EquipmentItems = append(input.EquipmentItems, ActivityEquipmentInput{
+			EquipmentID:  item.EquipmentID,
+			Quantity:     item.Quantity,
+			PerGroupSize: item.PerGroupSize,
+		})
+	}
+
+	fields, err := validateStruct(&input)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if fields == nil {
+		fields = fieldErrors{}
+	}
+	if err := activity.ValidateAttributes(); err != nil {
+		addAttributeError(fields, err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	errs := make([]string, 0, len(fields))
+	for field, message := range fields {
+		errs = append(errs, field+" "+message)
+	}
+	sort.Strings(errs)
+	return errs
+}
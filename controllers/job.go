@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetJob คืนสถานะ ความคืบหน้า และผลลัพธ์ของ background job (ใช้ poll หลังเริ่มงาน)
+func GetJob(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var job models.BackgroundJob
+		if err := db.First(&job, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// ListJobs คืนรายการงานล่าสุด (ไม่รวมผลลัพธ์) กรองด้วย ?type= และ ?status= ได้
+func ListJobs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var jobs []models.BackgroundJob
+
+		query := db.Omit("result").Order("id DESC").Limit(100)
+		if jobType := c.Query("type"); jobType != "" {
+			query = query.Where("type = ?", jobType)
+		}
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		if err := query.Find(&jobs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, jobs)
+	}
+}
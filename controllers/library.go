@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"project-backend/config"
+	"project-backend/jobs"
+	"project-backend/library"
+	"project-backend/storage"
+	"project-backend/thumbnail"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const jobTypeLibraryImport = "library_import"
+
+// ExportLibrary ส่งออกคลังกิจกรรมเป็นไฟล์ zip ตาม library.FormatVersion
+// ระบุ ?ids=1,2,3 เพื่อส่งออกเฉพาะกิจกรรมที่เลือก (พร้อมเพลง อุปกรณ์ และไฟล์สื่อที่อ้างถึง)
+func ExportLibrary(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, err := parseIDList(c.Query("ids"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids: " + err.Error()})
+			return
+		}
+
+		// เขียนลงไฟล์ชั่วคราวก่อน เพื่อไม่ให้ client ได้ zip ที่ขาดกลางทางเมื่อเกิดข้อผิดพลาด
+		tmp, err := os.CreateTemp("", "library-export-*.zip")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := library.Export(c.Request.Context(), db, store, tmp, library.ExportOptions{ActivityIDs: ids}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Export failed: " + err.Error()})
+			return
+		}
+
+		filename := fmt.Sprintf("activity-library-%s.zip", time.Now().Format("20060102-150405"))
+		c.FileAttachment(tmp.Name(), filename)
+	}
+}
+
+// ImportLibrary นำเข้า package ที่ได้จาก ExportLibrary (multipart field "file")
+// ?dry_run=true ตรวจสอบและคืนรายงานโดยไม่บันทึก, ?skip_conflicts=true นำเข้าต่อแม้ taxonomy บางรายการจับคู่ไม่ได้,
+// ?on_duplicate=skip|create กำหนดการจัดการกิจกรรมชื่อซ้ำ, ?async=true ทำเป็น background job
+func ImportLibrary(db *gorm.DB, store storage.Storage, runner *jobs.Runner, thumbs *thumbnail.Worker, qrCfg *config.QRConfig, storageCfg *config.StorageConfig, cfg *config.ImportConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxLibraryBytes+1<<20)
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+			return
+		}
+
+		// zip ต้องอ่านแบบสุ่มตำแหน่ง และงาน background ต้องใช้ไฟล์หลัง request จบ จึงคัดลอกเป็นไฟล์ชั่วคราว
+		tmp, err := saveTempUpload(header.Open, "library-import-*.zip")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+		cleanup := func() {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+
+		info, err := tmp.Stat()
+		if err != nil {
+			cleanup()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		pkg, err := library.Open(tmp, info.Size())
+		if err != nil {
+			cleanup()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		opts := library.ImportOptions{
+			DryRun:        c.Query("dry_run") == "true",
+			SkipConflicts: c.Query("skip_conflicts") == "true",
+			OnDuplicate:   c.DefaultQuery("on_duplicate", library.OnDuplicateSkip),
+			OwnerID:       userID,
+			QR:            qrCfg,
+			MaxMediaBytes: storageCfg.MaxUploadBytes,
+			Validate:      ValidateImportedActivity,
+		}
+		if opts.OnDuplicate != library.OnDuplicateSkip && opts.OnDuplicate != library.OnDuplicateCreate {
+			cleanup()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "on_duplicate must be skip or create"})
+			return
+		}
+
+		run := func(ctx context.Context, progress jobs.Progress) (*library.ImportReport, error) {
+			report, err := library.Import(ctx, db, store, pkg, opts, progress)
+			if report != nil {
+				for _, id := range report.NewMediaIDs {
+					thumbs.Enqueue(id)
+				}
+			}
+			return report, err
+		}
+
+		if c.Query("async") == "true" || pkg.ActivityCount() > cfg.AsyncRows {
+			job, err := runner.Start(jobTypeLibraryImport, userID, pkg.ActivityCount(), func(ctx context.Context, progress jobs.Progress) (any, error) {
+				defer cleanup()
+				return run(ctx, progress)
+			})
+			if err != nil {
+				cleanup()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusAccepted, job)
+			return
+		}
+
+		defer cleanup()
+		report, err := run(c.Request.Context(), nil)
+		switch {
+		case errors.Is(err, library.ErrConflicts):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "report": report})
+		case errors.Is(err, library.ErrInvalidActivities):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+		default:
+			status := http.StatusCreated
+			if opts.DryRun {
+				status = http.StatusOK
+			}
+			c.JSON(status, report)
+		}
+	}
+}
+
+// saveTempUpload คัดลอกไฟล์ที่อัปโหลดลงไฟล์ชั่วคราว ผู้เรียกต้องปิดและลบไฟล์เอง
+func saveTempUpload[T io.ReadCloser](open func() (T, error), pattern string) (*os.File, error) {
+	src, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
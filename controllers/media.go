@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"project-backend/config"
+	"project-backend/models"
+	"project-backend/storage"
+	"project-backend/thumbnail"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// mediaPurposeRule กำหนดชนิดไฟล์ที่รับได้และสิทธิ์ของแต่ละ purpose
+type mediaPurposeRule struct {
+	contentTypes map[string]string
+	adminOnly    bool
+}
+
+var mediaPurposeRules = map[string]mediaPurposeRule{
+	models.MediaPurposeProfile:       {contentTypes: models.MediaContentTypes[models.MediaPurposeProfile]},
+	models.MediaPurposeActivityCover: {contentTypes: models.MediaContentTypes[models.MediaPurposeActivityCover], adminOnly: true},
+	models.MediaPurposeSongImage:     {contentTypes: models.MediaContentTypes[models.MediaPurposeSongImage], adminOnly: true},
+	models.MediaPurposeStep:          {contentTypes: models.MediaContentTypes[models.MediaPurposeStep], adminOnly: true},
+	models.MediaPurposeSongAudio:     {contentTypes: models.MediaContentTypes[models.MediaPurposeSongAudio], adminOnly: true},
+}
+
+// UploadMedia รับไฟล์แบบ multipart (field "file" และ "purpose")
+// ตรวจชนิดไฟล์จากเนื้อหาจริง ขนาด และขนาดภาพ ก่อนบันทึกด้วยชื่อไฟล์ที่ระบบสร้างขึ้นเอง
+// ภาพที่ต้องมีภาพย่อจะถูกส่งเข้าคิวของ thumbnail worker หลังบันทึกเสร็จ
+func UploadMedia(db *gorm.DB, store storage.Storage, cfg *config.StorageConfig, thumbs *thumbnail.Worker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		roleName := c.GetString("role_name")
+
+		// จำกัดขนาด body ทั้งหมดก่อนเริ่ม parse multipart (เผื่อส่วนหัวของ multipart ไว้ 1MB)
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxUploadBytes+1<<20)
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+			return
+		}
+
+		purpose := c.PostForm("purpose")
+		rule, ok := mediaPurposeRules[purpose]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown media purpose"})
+			return
+		}
+		if rule.adminOnly && roleName != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied for this media purpose"})
+			return
+		}
+		if header.Size > cfg.MaxUploadBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File is too large (max %d MB)", cfg.MaxUploadBytes>>20)})
+			return
+		}
+		if header.Size == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+		defer file.Close()
+
+		// ตรวจชนิดไฟล์จากเนื้อหา ไม่เชื่อ Content-Type หรือนามสกุลที่ client ส่งมา
+		sniff := make([]byte, 512)
+		n, err := io.ReadFull(file, sniff)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+		contentType := http.DetectContentType(sniff[:n])
+		ext, ok := rule.contentTypes[contentType]
+		if !ok {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported file type: " + contentType})
+			return
+		}
+
+		media := models.Media{
+			OwnerID:      userID,
+			Purpose:      purpose,
+			OriginalName: path.Base(header.Filename),
+			ContentType:  contentType,
+			Size:         header.Size,
+			Backend:      store.Name(),
+		}
+
+		if _, isImage := models.ImageContentTypes[contentType]; isImage {
+			imgCfg, _, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(sniff[:n]), file))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "File is not a valid image"})
+				return
+			}
+			if imgCfg.Width < 1 || imgCfg.Height < 1 ||
+				imgCfg.Width > cfg.MaxImageWidth || imgCfg.Height > cfg.MaxImageHeight {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Image dimensions must be at most %dx%d", cfg.MaxImageWidth, cfg.MaxImageHeight)})
+				return
+			}
+			media.Width = imgCfg.Width
+			media.Height = imgCfg.Height
+			if thumbnail.NeedsVariants(purpose) {
+				media.VariantStatus = models.VariantStatusPending
+			}
+		}
+
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+
+		media.Key = uuid.NewString() + ext
+		now := time.Now()
+		media.StorageKey = fmt.Sprintf("%s/%04d/%02d/%s", purpose, now.Year(), now.Month(), media.Key)
+
+		if err := store.Put(c.Request.Context(), media.StorageKey, file, header.Size, contentType); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not store file"})
+			return
+		}
+		if err := db.Create(&media).Error; err != nil {
+			store.Delete(c.Request.Context(), media.StorageKey)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if media.VariantStatus == models.VariantStatusPending {
+			thumbs.Enqueue(media.ID)
+		}
+
+		c.JSON(http.StatusCreated, media)
+	}
+}
+
+// ServeMedia ส่งไฟล์ตาม key สาธารณะที่ระบบสร้างขึ้น (ทั้งไฟล์ต้นฉบับและภาพย่อ)
+func ServeMedia(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Param("key")
+
+		var storageKey, contentType string
+		var size int64
+
+		var media models.Media
+		if err := db.Where("media_key = ?", key).First(&media).Error; err == nil {
+			storageKey, contentType, size = media.StorageKey, media.ContentType, media.Size
+		} else {
+			var variant models.MediaVariant
+			if err := db.Joins("JOIN media ON media.id = media_variants.media_id AND media.deleted_at IS NULL").
+				Where("media_variants.media_key = ?", key).
+				First(&variant).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+				return
+			}
+			storageKey, contentType, size = variant.StorageKey, variant.ContentType, variant.Size
+		}
+
+		reader, err := store.Open(c.Request.Context(), storageKey)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not open media"})
+			return
+		}
+		defer reader.Close()
+
+		c.DataFromReader(http.StatusOK, size, contentType, reader, map[string]string{
+			"Cache-Control":          "public, max-age=31536000, immutable",
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+func GetMedia(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		media, status, err := findOwnedMedia(db, c, c.Param("id"))
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		db.Model(media).Association("Variants").Find(&media.Variants)
+
+		c.JSON(http.StatusOK, media)
+	}
+}
+
+func DeleteMedia(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		media, status, err := findOwnedMedia(db, c, c.Param("id"))
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := deleteMedia(db, store, c, media); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+	}
+}
+
+// findOwnedMedia โหลด media ที่ผู้ใช้ปัจจุบันเป็นเจ้าของ (admin เข้าถึงได้ทุกไฟล์)
+func findOwnedMedia(db *gorm.DB, c *gin.Context, id string) (*models.Media, int, error) {
+	mediaID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("Invalid media ID")
+	}
+
+	var media models.Media
+	if err := db.First(&media, uint(mediaID)).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("Media not found")
+	}
+	if media.OwnerID != c.MustGet("user_id").(uint) && c.GetString("role_name") != "admin" {
+		return nil, http.StatusForbidden, errors.New("Access denied for this media")
+	}
+	return &media, http.StatusOK, nil
+}
+
+// resolveMediaRef ตรวจสอบ media_id ที่ client ส่งมาอ้างอิง ว่ามีอยู่จริง purpose ถูกต้อง
+// และผู้ใช้มีสิทธิ์ใช้ (เจ้าของไฟล์ หรือ admin) คืน nil ถ้า id เป็น nil
+func resolveMediaRef(db *gorm.DB, c *gin.Context, field string, id *uint, purposes ...string) (*models.Media, error) {
+	if id == nil {
+		return nil, nil
+	}
+
+	var media models.Media
+	if err := db.First(&media, *id).Error; err != nil {
+		return nil, fmt.Errorf("%s: media %d not found", field, *id)
+	}
+	if media.OwnerID != c.MustGet("user_id").(uint) && c.GetString("role_name") != "admin" {
+		return nil, fmt.Errorf("%s: media %d is not owned by you", field, *id)
+	}
+	for _, purpose := range purposes {
+		if media.Purpose == purpose {
+			return &media, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: media %d has purpose %q", field, *id, media.Purpose)
+}
+
+// deleteMedia ลบการอ้างอิงทั้งหมด ลบ record และลบไฟล์ (รวมภาพย่อ) ออกจาก storage
+func deleteMedia(db *gorm.DB, store storage.Storage, c *gin.Context, media *models.Media) error {
+	var variants []models.MediaVariant
+	if err := db.Where("media_id = ?", media.ID).Find(&variants).Error; err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		refs := []struct {
+			model  interface{}
+			column string
+		}{
+			{&models.Activity{}, "cover_media_id"},
+			{&models.Activity{}, "song_image_media_id"},
+			{&models.ActivityStep{}, "media_id"},
+			{&models.Song{}, "cover_media_id"},
+			{&models.Song{}, "audio_media_id"},
+			{&models.User{}, "profile_media_id"},
+		}
+		for _, ref := range refs {
+			if err := tx.Model(ref.model).
+				Where(ref.column+" = ?", media.ID).
+				Update(ref.column, nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("media_id = ?", media.ID).Delete(&models.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(media).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range variants {
+		store.Delete(c.Request.Context(), variant.StorageKey)
+	}
+	return store.Delete(c.Request.Context(), media.StorageKey)
+}
@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-backend/config"
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ObservationInput คือผลการสังเกตหนึ่งรายการ
+// client_id ไม่ต้องส่งเมื่อ session เป็นของผู้รับบริการคนเดียว ส่วน activity_id ได้จาก session_item_id ถ้าส่งมา
+// ไม่ส่ง rating_scale_id ใช้มาตรวัดเริ่มต้น และไม่ส่ง observed_at ใช้เวลาที่บันทึก
+type ObservationInput struct {
+	SessionItemID *uint      `json:"session_item_id"`
+	ClientID      *uint      `json:"client_id"`
+	ActivityID    *uint      `json:"activity_id"`
+	SubGoalID     *uint      `json:"sub_goal_id"`
+	RatingScaleID *uint      `json:"rating_scale_id"`
+	Rating        *int       `json:"rating" binding:"required"`
+	PromptLevel   string     `json:"prompt_level" binding:"omitempty,oneof=independent gestural verbal visual model partial_physical full_physical"`
+	Notes         string     `json:"notes" binding:"max=5000"`
+	ObservedAt    *time.Time `json:"observed_at"`
+}
+
+// ObservationBulkInput คือผลการสังเกตหลายรายการที่ส่งพร้อมกัน (เช่น จากแท็บเล็ตหลังจบ session)
+type ObservationBulkInput struct {
+	Observations []ObservationInput `json:"observations" binding:"required,min=1,dive"`
+}
+
+type RatingScaleLevelInput struct {
+	Value int    `json:"value"`
+	Label string `json:"label" binding:"notblank,max=200"`
+}
+
+type RatingScaleInput struct {
+	Name        string                  `json:"name" binding:"notblank,max=200"`
+	Description string                  `json:"description" binding:"max=2000"`
+	IsDefault   bool                    `json:"is_default"`
+	Levels      []RatingScaleLevelInput `json:"levels" binding:"min=2,max=20,dive"`
+}
+
+// errObservationWindowClosed คือกรณีที่พ้นช่วงเวลาที่บันทึก/แก้ไขผลการสังเกตของ session ได้แล้ว
+var errObservationWindowClosed = errors.New("observations of this session can no longer be changed")
+
+// observationEditableUntil คือเวลาสุดท้ายที่ยังบันทึกหรือแก้ไขผลการสังเกตของ session ได้
+func observationEditableUntil(session *models.Session, cfg *config.ObservationConfig) time.Time {
+	return session.EndsAt.Add(cfg.EditWindow)
+}
+
+// checkObservationWindow ตอบ 409 แล้วคืน false เมื่อ session ถูกยกเลิกหรือพ้นช่วงเวลาที่แก้ไขได้แล้ว
+func checkObservationWindow(c *gin.Context, session *models.Session, cfg *config.ObservationConfig) bool {
+	if session.Status == models.SessionStatusCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "observations cannot be recorded for a cancelled session"})
+		return false
+	}
+	until := observationEditableUntil(session, cfg)
+	if time.Now().After(until) {
+		c.JSON(http.StatusConflict, gin.H{"error": errObservationWindowClosed.Error(), "editable_until": until})
+		return false
+	}
+	return true
+}
+
+// observationContext เก็บข้อมูลของ session ที่ใช้ตรวจผลการสังเกตหลายรายการโดยไม่ต้อง query ซ้ำ
+type observationContext struct {
+	session      *models.Session
+	participants map[uint]bool
+	items        map[uint]models.SessionItem
+	scales       map[uint]*models.RatingScale
+	defaultScale *models.RatingScale
+}
+
+func loadObservationContext(db *gorm.DB, session *models.Session) (*observationContext, error) {
+	oc := &observationContext{
+		session:      session,
+		participants: map[uint]bool{},
+		items:        map[uint]models.SessionItem{},
+		scales:       map[uint]*models.RatingScale{},
+	}
+
+	if session.ClientID != nil {
+		oc.participants[*session.ClientID] = true
+	} else if session.ClientGroupID != nil {
+		var ids []uint
+		if err := db.Table("client_group_members").
+			Where("client_group_id = ?", *session.ClientGroupID).
+			Pluck("client_id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			oc.participants[id] = true
+		}
+	}
+
+	var items []models.SessionItem
+	if err := db.Where("session_id = ?", session.ID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		oc.items[item.ID] = item
+	}
+
+	var scales []models.RatingScale
+	if err := db.Preload("Levels").Find(&scales).Error; err != nil {
+		return nil, err
+	}
+	for i := range scales {
+		oc.scales[scales[i].ID] = &scales[i]
+		if scales[i].IsDefault {
+			oc.defaultScale = &scales[i]
+		}
+	}
+	return oc, nil
+}
+
+// resolve ตรวจผลการสังเกตหนึ่งรายการแล้วเขียนลงใน obs ข้อผิดพลาดใช้ชื่อฟิลด์ขึ้นต้นด้วย prefix
+func (oc *observationContext) resolve(db *gorm.DB, in *ObservationInput, obs *models.Observation, fields fieldErrors, prefix string) error {
+	obs.SessionID = oc.session.ID
+
+	switch {
+	case in.ClientID != nil:
+		if !oc.participants[*in.ClientID] {
+			fields.add(prefix+"client_id", "client is not part of this session")
+		}
+		obs.ClientID = *in.ClientID
+	case oc.session.ClientID != nil:
+		obs.ClientID = *oc.session.ClientID
+	default:
+		fields.add(prefix+"client_id", "is required for group sessions")
+	}
+
+	obs.SessionItemID = in.SessionItemID
+	obs.ActivityID = in.ActivityID
+	if in.SessionItemID != nil {
+		item, ok := oc.items[*in.SessionItemID]
+		switch {
+		case !ok:
+			fields.add(prefix+"session_item_id", "item does not belong to this session")
+		case in.ActivityID != nil && (item.ActivityID == nil || *item.ActivityID != *in.ActivityID):
+			fields.add(prefix+"activity_id", "does not match the activity of session_item_id")
+		default:
+			obs.ActivityID = item.ActivityID
+		}
+	} else if in.ActivityID != nil {
+		var count int64
+		if err := db.Model(&models.Activity{}).Where("id = ?", *in.ActivityID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			fields.add(prefix+"activity_id", "activity not found")
+		}
+	}
+
+	obs.SubGoalID = in.SubGoalID
+	if in.SubGoalID != nil {
+		var count int64
+		if err := db.Model(&models.ActivitySubGoal{}).Where("id = ?", *in.SubGoalID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			fields.add(prefix+"sub_goal_id", "sub-goal not found")
+		}
+	}
+
+	scale := oc.defaultScale
+	if in.RatingScaleID != nil {
+		scale = oc.scales[*in.RatingScaleID]
+	}
+	if scale == nil {
+		fields.add(prefix+"rating_scale_id", "rating scale not found")
+	} else {
+		obs.RatingScaleID = scale.ID
+		if in.Rating != nil && !scale.HasLevel(*in.Rating) {
+			min, max := scale.Range()
+			fields.add(prefix+"rating", "must be a level of the rating scale (%d-%d)", min, max)
+		}
+	}
+	obs.Rating = in.Rating
+	obs.PromptLevel = in.PromptLevel
+	obs.Notes = strings.TrimSpace(in.Notes)
+
+	obs.ObservedAt = time.Now()
+	if in.ObservedAt != nil {
+		obs.ObservedAt = *in.ObservedAt
+	}
+	return nil
+}
+
+func preloadObservationDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Activity").Preload("SubGoal")
+}
+
+// ListSessionObservations คืนผลการสังเกตของ session เรียงตามเวลา ?client_id= กรองตามผู้รับบริการ
+func ListSessionObservations(db *gorm.DB, cfg *config.ObservationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := c.MustGet("session").(*models.Session)
+
+		query := preloadObservationDetails(db).Where("session_id = ?", session.ID)
+		if clientID := c.Query("client_id"); clientID != "" {
+			query = query.Where("client_id = ?", clientID)
+		}
+		var observations []models.Observation
+		if err := query.Order("observed_at ASC, id ASC").Find(&observations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"editable_until": observationEditableUntil(session, cfg),
+			"observations":   observations,
+		})
+	}
+}
+
+// CreateSessionObservations บันทึกผลการสังเกตหลายรายการของ session ในครั้งเดียว
+// ถ้ารายการใดไม่ผ่านการตรวจจะไม่บันทึกเลยสักรายการ (ข้อผิดพลาดระบุตำแหน่ง เช่น observations[2].rating)
+func CreateSessionObservations(db *gorm.DB, cfg *config.ObservationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		session := c.MustGet("session").(*models.Session)
+		if !checkObservationWindow(c, session, cfg) {
+			return
+		}
+
+		var input ObservationBulkInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if len(input.Observations) > cfg.MaxBulk {
+			respondValidation(c, fieldErrors{"observations": fmt.Sprintf("must contain at most %d items", cfg.MaxBulk)})
+			return
+		}
+
+		oc, err := loadObservationContext(db, session)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		fields := fieldErrors{}
+		observations := make([]models.Observation, len(input.Observations))
+		for i := range input.Observations {
+			observations[i].RecordedByID = userID
+			if err := oc.resolve(db, &input.Observations[i], &observations[i], fields, fmt.Sprintf("observations[%d].", i)); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		if err := db.Omit("SessionItem", "Activity", "SubGoal", "RatingScale").Create(&observations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ids := make([]uint, 0, len(observations))
+		for _, obs := range observations {
+			ids = append(ids, obs.ID)
+		}
+		var created []models.Observation
+		if err := preloadObservationDetails(db).Where("id IN ?", ids).Order("observed_at ASC, id ASC").Find(&created).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"editable_until": observationEditableUntil(session, cfg),
+			"observations":   created,
+		})
+	}
+}
+
+// loadObservationSession โหลด session ของผลการสังเกต (ผ่าน middleware.ObservationAccess แล้วจึงเข้าถึงได้)
+func loadObservationSession(c *gin.Context, db *gorm.DB, obs *models.Observation) (*models.Session, bool) {
+	var session models.Session
+	if err := db.First(&session, obs.SessionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+	return &session, true
+}
+
+// UpdateObservation แก้ไขผลการสังเกตทั้งรายการ ทำได้ภายในช่วงเวลาที่กำหนดหลัง session จบเท่านั้น
+func UpdateObservation(db *gorm.DB, cfg *config.ObservationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		obs := c.MustGet("observation").(*models.Observation)
+		session, ok := loadObservationSession(c, db, obs)
+		if !ok || !checkObservationWindow(c, session, cfg) {
+			return
+		}
+
+		var input ObservationInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if input.ObservedAt == nil {
+			// แก้ไขโดยไม่ส่ง observed_at ให้คงเวลาที่สังเกตเดิมไว้
+			observedAt := obs.ObservedAt
+			input.ObservedAt = &observedAt
+		}
+
+		oc, err := loadObservationContext(db, session)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		fields := fieldErrors{}
+		if err := oc.resolve(db, &input, obs, fields, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		if err := db.Model(obs).
+			Select("session_item_id", "client_id", "activity_id", "sub_goal_id", "rating_scale_id", "rating", "prompt_level", "notes", "observed_at").
+			Updates(obs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var updated models.Observation
+		if err := preloadObservationDetails(db).First(&updated, obs.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteObservation ลบผลการสังเกต ทำได้ภายในช่วงเวลาเดียวกับการแก้ไข
+func DeleteObservation(db *gorm.DB, cfg *config.ObservationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		obs := c.MustGet("observation").(*models.Observation)
+		session, ok := loadObservationSession(c, db, obs)
+		if !ok || !checkObservationWindow(c, session, cfg) {
+			return
+		}
+		if err := db.Delete(obs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Observation deleted successfully"})
+	}
+}
+
+func orderedScaleLevels(db *gorm.DB) *gorm.DB {
+	return db.Order("rating_scale_levels.value ASC")
+}
+
+// ListRatingScales คืนมาตรวัดทั้งหมดพร้อมระดับ มาตรวัดเริ่มต้นอยู่ก่อน
+func ListRatingScales(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var scales []models.RatingScale
+		if err := db.Preload("Levels", orderedScaleLevels).
+			Order("is_default DESC, name ASC").
+			Find(&scales).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, scales)
+	}
+}
+
+// CreateRatingScale สร้างมาตรวัดใหม่ (admin) ถ้า is_default มาตรวัดเริ่มต้นเดิมจะถูกยกเลิกการเป็นค่าเริ่มต้น
+func CreateRatingScale(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input RatingScaleInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		scale := models.RatingScale{
+			Name:        strings.TrimSpace(input.Name),
+			Description: input.Description,
+			IsDefault:   input.IsDefault,
+		}
+		seen := map[int]bool{}
+		for i, level := range input.Levels {
+			if seen[level.Value] {
+				respondValidation(c, fieldErrors{fmt.Sprintf("levels[%d].value", i): "is listed more than once"})
+				return
+			}
+			seen[level.Value] = true
+			scale.Levels = append(scale.Levels, models.RatingScaleLevel{Value: level.Value, Label: strings.TrimSpace(level.Label)})
+		}
+
+		var count int64
+		if err := db.Model(&models.RatingScale{}).Where("name = ?", scale.Name).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A rating scale with this name already exists"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if scale.IsDefault {
+				if err := tx.Model(&models.RatingScale{}).Where("is_default = ?", true).Update("is_default", false).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Create(&scale).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, scale)
+	}
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationInput struct {
+	Name string `json:"name" binding:"notblank,max=200"`
+}
+
+type OrganizationMemberInput struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+func organizationMembers(db *gorm.DB) *gorm.DB {
+	return db.Order("organization_members.created_at ASC")
+}
+
+// ListMyOrganizations คืนหน่วยงานที่ผู้ใช้เป็นสมาชิก
+func ListMyOrganizations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		var organizations []models.Organization
+		if err := db.Where("id IN (?)", db.Model(&models.OrganizationMember{}).Select("organization_id").Where("user_id = ?", userID)).
+			Order("name ASC").
+			Find(&organizations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, organizations)
+	}
+}
+
+// ListOrganizations คืนหน่วยงานทั้งหมดพร้อมสมาชิก (admin)
+func ListOrganizations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var organizations []models.Organization
+		if err := db.Preload("Members", organizationMembers).Preload("Members.User", reviewAuthor).
+			Order("name ASC").
+			Find(&organizations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, organizations)
+	}
+}
+
+// CreateOrganization สร้างหน่วยงานใหม่ (admin)
+func CreateOrganization(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input OrganizationInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		organization := models.Organization{Name: strings.TrimSpace(input.Name)}
+		if err := db.Create(&organization).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, organization)
+	}
+}
+
+// AddOrganizationMember เพิ่มผู้ใช้เป็นสมาชิกของหน่วยงาน (admin) เพิ่มซ้ำได้โดยไม่เกิดข้อผิดพลาด
+func AddOrganizationMember(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var organization models.Organization
+		if err := db.First(&organization, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return
+		}
+		var input OrganizationMemberInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		var user models.User
+		if err := db.Select("id").First(&user, input.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		member := models.OrganizationMember{OrganizationID: organization.ID, UserID: user.ID}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"organization_id": organization.ID, "user_id": user.ID})
+	}
+}
+
+// RemoveOrganizationMember นำผู้ใช้ออกจากหน่วยงาน (admin) ผู้ใช้จะเข้าถึงผู้รับบริการของหน่วยงานไม่ได้อีก
+func RemoveOrganizationMember(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := db.Where("organization_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).
+			Delete(&models.OrganizationMember{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Membership not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+	}
+}
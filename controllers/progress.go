@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/progress"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultProgressRange คือช่วงเวลาย้อนหลังเมื่อไม่ได้ส่ง ?from= ตามขนาดช่วงเวลา
+func defaultProgressRange(to time.Time, bucket string) time.Time {
+	switch bucket {
+	case progress.BucketDay:
+		return to.AddDate(0, 0, -30)
+	case progress.BucketWeek:
+		return to.AddDate(0, 0, -7*26)
+	default:
+		return to.AddDate(-1, 0, 0)
+	}
+}
+
+// optionalUintQuery อ่าน query parameter ที่เป็น id (ไม่ส่งได้ nil)
+func optionalUintQuery(c *gin.Context, key string) (*uint, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be an ID"})
+		return nil, false
+	}
+	id := uint(v)
+	return &id, true
+}
+
+// GetClientProgress สรุปคะแนนผลการสังเกตของผู้รับบริการเป็นกราฟเส้นตามเวลา
+//
+//	?level=sub_goal|goal          หนึ่งเส้นต่อเป้าหมายย่อย หรือต่อเป้าหมายหลัก (ค่าเริ่มต้น sub_goal)
+//	?bucket=day|week|month         ขนาดช่วงเวลา (ค่าเริ่มต้น week)
+//	?from= ?to= ?tz=               ช่วงวันที่แบบเดียวกับปฏิทิน session (ค่าเริ่มต้นย้อนหลังตามขนาดช่วงเวลาถึงวันนี้)
+//	?baseline_buckets=N            จำนวนช่วงเวลาแรกที่มีข้อมูลที่ใช้เป็น baseline (ค่าเริ่มต้น 1)
+//	?sub_goal_id= ?goal_id=        เลือกเฉพาะเป้าหมายที่สนใจ
+//
+// labels คือแกน x และ data ของแต่ละเส้นเรียงตาม labels (null คือช่วงที่ไม่มีผลการสังเกต)
+func GetClientProgress(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet("client").(*models.Client)
+
+		level := c.DefaultQuery("level", progress.LevelSubGoal)
+		if !progress.ValidLevel(level) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level must be sub_goal or goal"})
+			return
+		}
+		bucket := c.DefaultQuery("bucket", progress.BucketWeek)
+		if !progress.ValidBucket(bucket) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be day, week or month"})
+			return
+		}
+		baselineBuckets, err := strconv.Atoi(c.DefaultQuery("baseline_buckets", "1"))
+		if err != nil || baselineBuckets < 1 || baselineBuckets > 52 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "baseline_buckets must be between 1 and 52"})
+			return
+		}
+
+		loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tz must be an IANA time zone such as Asia/Bangkok"})
+			return
+		}
+		to := time.Now().In(loc)
+		if raw := c.Query("to"); raw != "" {
+			if to, err = parseCalendarBound(raw, loc, true); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD) or RFC3339 time"})
+				return
+			}
+		}
+		from := progress.BucketStart(defaultProgressRange(to, bucket), bucket)
+		if raw := c.Query("from"); raw != "" {
+			if from, err = parseCalendarBound(raw, loc, false); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD) or RFC3339 time"})
+				return
+			}
+		}
+		if !to.After(from) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+			return
+		}
+		from, to = from.In(loc), to.In(loc)
+		labels, err := progress.Labels(from, to, bucket)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		subGoalID, ok := optionalUintQuery(c, "sub_goal_id")
+		if !ok {
+			return
+		}
+		goalID, ok := optionalUintQuery(c, "goal_id")
+		if !ok {
+			return
+		}
+
+		rows, err := progress.Aggregate(db, progress.Query{
+			ClientID:  client.ID,
+			Level:     level,
+			Bucket:    bucket,
+			From:      from,
+			To:        to,
+			Location:  loc,
+			SubGoalID: subGoalID,
+			GoalID:    goalID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		series := progress.Build(rows, labels, baselineBuckets)
+
+		if err := nameProgressSeries(db, c.GetString("locale"), level, series); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if labels == nil {
+			labels = []string{}
+		}
+		if series == nil {
+			series = []*progress.Series{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"client_id": client.ID,
+			"level":     level,
+			"bucket":    bucket,
+			"from":      from,
+			"to":        to,
+			"timezone":  loc.String(),
+			"labels":    labels,
+			"series":    series,
+		})
+	}
+}
+
+// nameProgressSeries ใส่ชื่อเป้าหมาย (ตามภาษาของ request) ให้แต่ละเส้น
+func nameProgressSeries(db *gorm.DB, locale, level string, series []*progress.Series) error {
+	if len(series) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(series))
+	byID := make(map[uint]*progress.Series, len(series))
+	for _, s := range series {
+		ids = append(ids, s.ID)
+		byID[s.ID] = s
+	}
+
+	batch := i18n.NewBatch(locale)
+	if level == progress.LevelGoal {
+		var goals []models.ActivityGoal
+		if err := db.Where("id IN ?", ids).Find(&goals).Error; err != nil {
+			return err
+		}
+		for _, g := range goals {
+			s := byID[g.ID]
+			s.Name = g.GoalName
+			batch.Add(i18n.EntityGoal, g.ID, "goal_name", &s.Name)
+		}
+		return batch.Apply(db)
+	}
+
+	var subGoals []models.ActivitySubGoal
+	if err := db.Where("id IN ?", ids).Find(&subGoals).Error; err != nil {
+		return err
+	}
+	for _, sg := range subGoals {
+		s := byID[sg.ID]
+		s.Name, s.GoalID = sg.SubGoalName, sg.GoalID
+		batch.Add(i18n.EntitySubGoal, sg.ID, "sub_goal_name", &s.Name)
+	}
+	return batch.Apply(db)
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-backend/config"
+	"project-backend/helpers"
+	"project-backend/models"
+	"project-backend/qrcodes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// qrTargetInput คือค่า QR ที่ส่งมากับฟอร์มกิจกรรม Target เป็น nil เมื่อไม่ต้องการเปลี่ยน
+type qrTargetInput struct {
+	Slot   int
+	Target *string
+	Level  string
+}
+
+// validateQRTargets ตรวจ URL และระดับของ QR ก่อนเริ่ม Transaction และแปลง level ให้อยู่ในรูป L/M/Q/H
+// ข้อผิดพลาดใส่ไว้ใน fields ตามชื่อฟิลด์ qr_<slot>_target / qr_<slot>_level
+func validateQRTargets(inputs []qrTargetInput, fields fieldErrors) {
+	for i := range inputs {
+		in := &inputs[i]
+		if in.Target == nil || strings.TrimSpace(*in.Target) == "" {
+			continue
+		}
+		if err := helpers.ValidateQRTarget(strings.TrimSpace(*in.Target)); err != nil {
+			fields.add(fmt.Sprintf("qr_%d_target", in.Slot), "%v", err)
+		}
+		if in.Level != "" {
+			level, err := helpers.ParseQRLevel(in.Level)
+			if err != nil {
+				fields.add(fmt.Sprintf("qr_%d_level", in.Slot), "%v", err)
+				continue
+			}
+			in.Level = level
+		}
+	}
+}
+
+// applyQRTargets สร้าง/ลบ QR ตามค่าที่ส่งมา (เรียกภายใน Transaction)
+func applyQRTargets(tx *gorm.DB, cfg *config.QRConfig, activityID uint, inputs []qrTargetInput) error {
+	for _, in := range inputs {
+		if in.Target == nil {
+			continue
+		}
+		// ฟอร์มสร้างกิจกรรมส่งค่าว่างเสมอ ถ้าไม่มี QR อยู่แล้วก็ไม่ต้องไปล้างคอลัมน์ qr เดิม
+		if strings.TrimSpace(*in.Target) == "" {
+			var count int64
+			if err := tx.Model(&models.ActivityQRCode{}).Where("activity_id = ? AND slot = ?", activityID, in.Slot).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				continue
+			}
+		}
+		if err := qrcodes.Sync(tx, cfg, activityID, in.Slot, *in.Target, in.Level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetActivityQRCode ส่งภาพ QR ของกิจกรรม รองรับ ?format=png|svg, ?size= และ ?level=
+// ถ้าใช้ค่าเริ่มต้นจะส่งภาพที่สร้างเก็บไว้ ถ้าไม่ใช่จะสร้างภาพตามที่ขอทันที
+func GetActivityQRCode(db *gorm.DB, cfg *config.QRConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slot, err := qrcodes.ParseSlot(c.Param("slot"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		activityID, ok := requirePublishedActivity(c, db)
+		if !ok {
+			return
+		}
+
+		var code models.ActivityQRCode
+		if err := db.Where("activity_id = ? AND slot = ?", activityID, slot).First(&code).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "QR code not found"})
+			return
+		}
+
+		format := c.DefaultQuery("format", "png")
+		if format != "png" && format != "svg" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or svg"})
+			return
+		}
+
+		size := code.Size
+		if raw := c.Query("size"); raw != "" {
+			size, err = strconv.Atoi(raw)
+			if err != nil || size < cfg.MinSize || size > cfg.MaxSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("size must be between %d and %d", cfg.MinSize, cfg.MaxSize)})
+				return
+			}
+		}
+
+		level := code.Level
+		if raw := c.Query("level"); raw != "" {
+			level, err = helpers.ParseQRLevel(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%s", code.TargetURL, level, size, format)))
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "public, max-age=300")
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		cached := size == code.Size && level == code.Level
+		if format == "svg" {
+			svg := code.SVG
+			if !cached {
+				if svg, err = helpers.RenderQRSVG(code.TargetURL, level, size); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+			}
+			c.Data(http.StatusOK, "image/svg+xml", []byte(svg))
+			return
+		}
+
+		png := code.PNG
+		if !cached {
+			if png, err = helpers.RenderQRPNG(code.TargetURL, level, size); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.Data(http.StatusOK, "image/png", png)
+	}
+}
+
+// SetActivityQRCode กำหนด URL เป้าหมายและระดับการแก้ไขข้อผิดพลาดของ QR ช่องที่ระบุ
+// ส่ง target_url ว่างเพื่อลบ QR ช่องนั้น QR เป็นส่วนหนึ่งของกิจกรรม จึงต้องส่ง If-Match เช่นเดียวกับ PUT/PATCH
+// และ version ของกิจกรรมเพิ่มขึ้นทุกครั้งที่แก้
+func SetActivityQRCode(db *gorm.DB, cfg *config.QRConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slot, err := qrcodes.ParseSlot(c.Param("slot"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var activity models.Activity
+		if err := db.First(&activity, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
+		if !requireIfMatch(c, db, &activity) {
+			return
+		}
+
+		var input struct {
+			TargetURL string `json:"target_url"`
+			Level     string `json:"level"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.TargetURL != "" {
+			if err := helpers.ValidateQRTarget(input.TargetURL); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "target_url: " + err.Error()})
+				return
+			}
+		}
+		if input.Level != "" {
+			if input.Level, err = helpers.ParseQRLevel(input.Level); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// มีผู้อื่นแก้ไขไปแล้วหลังจากตรวจ If-Match ถ้าไม่มีแถวไหนถูกแก้
+			res := tx.Model(&activity).Where("version = ?", activity.Version).Update("version", gorm.Expr("version + 1"))
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errStaleActivity
+			}
+			return qrcodes.Sync(tx, cfg, activity.ID, slot, input.TargetURL, input.Level)
+		})
+		if errors.Is(err, errStaleActivity) {
+			respondStaleActivity(c, db, activity.ID)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("ETag", activityETag(activity.Version+1))
+
+		var codes []models.ActivityQRCode
+		db.Where("activity_id = ?", activity.ID).Order("slot ASC").Find(&codes)
+		c.JSON(http.StatusOK, codes)
+	}
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"project-backend/config"
+	"project-backend/jobs"
+	"project-backend/models"
+	"project-backend/recommend"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const jobTypeRecommendations = "recommendations_recompute"
+
+type recommendedActivity struct {
+	recommend.Recommendation
+	Activity models.Activity `json:"activity"`
+}
+
+// GetRecommendations แนะนำกิจกรรมให้ผู้ใช้ที่ login จากประวัติการอ่านและ favorite
+// ผู้ใช้ใหม่ที่ยังไม่มีประวัติจะได้กิจกรรมยอดนิยม ?limit=N
+func GetRecommendations(db *gorm.DB, cfg *config.RecommendConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		limit := cfg.DefaultLimit
+		if raw := c.Query("limit"); raw != "" {
+			var err error
+			limit, err = strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > cfg.MaxLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(cfg.MaxLimit)})
+				return
+			}
+		}
+
+		recs, err := recommend.ForUser(db, cfg, userID, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ids := make([]uint, 0, len(recs))
+		for _, r := range recs {
+			ids = append(ids, r.ActivityID)
+		}
+		var activities []models.Activity
+		if len(ids) > 0 {
+			if err := db.Preload("CoverMedia.Variants").Where("id IN ? AND status = ?", ids, models.ActivityStatusPublished).Find(&activities).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if err := localizeActivities(c, db, activities); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		byID := make(map[uint]models.Activity, len(activities))
+		for _, a := range activities {
+			byID[a.ID] = a
+		}
+
+		// ตารางโมเดลอาจยังอ้างถึงกิจกรรมที่ถูกลบหรือกลับเป็นฉบับร่างหลังการคำนวณรอบล่าสุด จึงข้ามรายการที่หาไม่พบ
+		results := make([]recommendedActivity, 0, len(recs))
+		for _, r := range recs {
+			if a, ok := byID[r.ActivityID]; ok {
+				results = append(results, recommendedActivity{Recommendation: r, Activity: a})
+			}
+		}
+
+		computedAt, err := recommend.LastComputed(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		response := gin.H{"recommendations": results}
+		if !computedAt.IsZero() {
+			response["model_computed_at"] = computedAt
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// RecomputeRecommendations สั่งคำนวณโมเดลแนะนำใหม่ทันทีเป็น background job (ปกติคำนวณตามรอบเวลาอยู่แล้ว)
+func RecomputeRecommendations(runner *jobs.Runner, scheduler *recommend.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		job, err := runner.Start(jobTypeRecommendations, userID, 0, func(ctx context.Context, progress jobs.Progress) (any, error) {
+			return scheduler.Run(ctx)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, job)
+	}
+}
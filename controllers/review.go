@@ -0,0 +1,406 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewInput struct {
+	Rating  int    `json:"rating" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+type ReviewReportInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ReviewModerationInput struct {
+	Reason string `json:"reason"`
+}
+
+type ratingSummary struct {
+	Average      float64     `json:"average"`
+	Count        int         `json:"count"`
+	Distribution map[int]int `json:"distribution"`
+}
+
+// refreshActivityRating คำนวณคะแนนเฉลี่ยและจำนวนรีวิวของกิจกรรมใหม่จากรีวิวที่แสดงอยู่
+func refreshActivityRating(tx *gorm.DB, activityID uint) error {
+	return tx.Exec(`UPDATE activities SET
+		rating_average = COALESCE((SELECT ROUND(AVG(rating)::numeric, 2) FROM activity_reviews WHERE activity_id = ? AND status = ?), 0),
+		rating_count = (SELECT COUNT(*) FROM activity_reviews WHERE activity_id = ? AND status = ?)
+		WHERE id = ?`,
+		activityID, models.ReviewStatusVisible, activityID, models.ReviewStatusVisible, activityID).Error
+}
+
+// deleteActivityReviews ลบรีวิวและการแจ้งรีวิวทั้งหมดของกิจกรรม
+func deleteActivityReviews(tx *gorm.DB, activityID uint) error {
+	reviews := tx.Model(&models.ActivityReview{}).Select("id").Where("activity_id = ?", activityID)
+	if err := tx.Where("review_id IN (?)", reviews).Delete(&models.ReviewReport{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("activity_id = ?", activityID).Delete(&models.ActivityReview{}).Error
+}
+
+func validateReviewInput(input *ReviewInput) error {
+	if input.Rating < models.RatingMin || input.Rating > models.RatingMax {
+		return fmt.Errorf("rating must be between %d and %d", models.RatingMin, models.RatingMax)
+	}
+	input.Comment = strings.TrimSpace(input.Comment)
+	return nil
+}
+
+func reviewAuthor(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "firstname", "lastname", "profile")
+}
+
+// ListActivityReviews คืนสรุปคะแนนและรีวิวที่แสดงอยู่ของกิจกรรม เรียงจากล่าสุด
+// ?limit=N (สูงสุด 100) และ ?before_id= สำหรับหน้าถัดไป
+func ListActivityReviews(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var activity models.Activity
+		if err := db.Scopes(publishedActivities).Select("id", "rating_average", "rating_count").First(&activity, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+
+		var rows []struct {
+			Rating int
+			Count  int
+		}
+		if err := db.Model(&models.ActivityReview{}).
+			Select("rating, COUNT(*) AS count").
+			Where("activity_id = ? AND status = ?", activity.ID, models.ReviewStatusVisible).
+			Group("rating").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		summary := ratingSummary{Average: activity.RatingAverage, Count: activity.RatingCount, Distribution: map[int]int{}}
+		for r := models.RatingMin; r <= models.RatingMax; r++ {
+			summary.Distribution[r] = 0
+		}
+		for _, row := range rows {
+			summary.Distribution[row.Rating] = row.Count
+		}
+
+		query := db.Preload("Author", reviewAuthor).
+			Where("activity_id = ? AND status = ?", activity.ID, models.ReviewStatusVisible).
+			Order("id DESC").
+			Limit(limit)
+		if beforeID := c.Query("before_id"); beforeID != "" {
+			query = query.Where("id < ?", beforeID)
+		}
+		var reviews []models.ActivityReview
+		if err := query.Find(&reviews).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"summary": summary, "reviews": reviews})
+	}
+}
+
+// GetMyReview คืนรีวิวของผู้ใช้ที่ login ต่อกิจกรรมนี้ (รวมรีวิวที่ถูกซ่อน)
+func GetMyReview(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		var review models.ActivityReview
+		if err := db.Where("activity_id = ? AND user_id = ?", c.Param("id"), userID).First(&review).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// UpsertMyReview สร้างหรือแก้ไขรีวิวของผู้ใช้ต่อกิจกรรม (หนึ่งคนหนึ่งรีวิว)
+// การแก้ไขรีวิวที่ถูกซ่อนไม่ทำให้รีวิวกลับมาแสดง
+func UpsertMyReview(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var activity models.Activity
+		if err := db.Scopes(publishedActivities).Select("id").First(&activity, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
+
+		var input ReviewInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validateReviewInput(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var review models.ActivityReview
+		created := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Where("activity_id = ? AND user_id = ?", activity.ID, userID).First(&review).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				created = true
+				review = models.ActivityReview{
+					ActivityID: activity.ID,
+					UserID:     userID,
+					Rating:     input.Rating,
+					Comment:    input.Comment,
+					Status:     models.ReviewStatusVisible,
+				}
+				if err := tx.Create(&review).Error; err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			default:
+				if err := tx.Model(&review).Updates(map[string]interface{}{
+					"rating":  input.Rating,
+					"comment": input.Comment,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return refreshActivityRating(tx, activity.ID)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		db.First(&review, review.ID)
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, review)
+	}
+}
+
+// DeleteMyReview ลบรีวิวของผู้ใช้ต่อกิจกรรม พร้อมการแจ้งรีวิวนั้น
+func DeleteMyReview(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		var review models.ActivityReview
+		if err := db.Where("activity_id = ? AND user_id = ?", c.Param("id"), userID).First(&review).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("review_id = ?", review.ID).Delete(&models.ReviewReport{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&review).Error; err != nil {
+				return err
+			}
+			return refreshActivityRating(tx, review.ActivityID)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+	}
+}
+
+// ReportReview ให้สมาชิกแจ้งรีวิวที่ไม่เหมาะสม รีวิวจะเข้าคิวตรวจสอบของ admin
+func ReportReview(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var review models.ActivityReview
+		if err := db.Where("status = ?", models.ReviewStatusVisible).First(&review, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		if review.UserID == userID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own review"})
+			return
+		}
+
+		var input ReviewReportInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reason := strings.TrimSpace(input.Reason)
+		if reason == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+
+		var count int64
+		db.Model(&models.ReviewReport{}).Where("review_id = ? AND user_id = ?", review.ID, userID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this review"})
+			return
+		}
+
+		report := models.ReviewReport{
+			ReviewID: review.ID,
+			UserID:   userID,
+			Reason:   reason,
+			Status:   models.ReportStatusOpen,
+		}
+		if err := db.Create(&report).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Could not report review (it may already be reported)"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, report)
+	}
+}
+
+// ListReviewModeration คือคิวตรวจสอบรีวิวของ admin
+// ?status=reported (ค่าเริ่มต้น: รีวิวที่มีการแจ้งที่ยังไม่ได้จัดการ เรียงตามจำนวนการแจ้ง), hidden หรือ visible
+func ListReviewModeration(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		openReports := func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", models.ReportStatusOpen).Order("id ASC")
+		}
+		query := db.Preload("Author", reviewAuthor).Limit(100)
+
+		switch status := c.DefaultQuery("status", "reported"); status {
+		case "reported":
+			query = query.Preload("Reports", openReports).
+				Where("EXISTS (SELECT 1 FROM review_reports WHERE review_reports.review_id = activity_reviews.id AND review_reports.status = ?)", models.ReportStatusOpen).
+				Order(clause.OrderBy{Expression: clause.Expr{
+					SQL:                "(SELECT COUNT(*) FROM review_reports WHERE review_reports.review_id = activity_reviews.id AND review_reports.status = ?) DESC, id DESC",
+					Vars:               []interface{}{models.ReportStatusOpen},
+					WithoutParentheses: true,
+				}})
+		case models.ReviewStatusHidden, models.ReviewStatusVisible:
+			query = query.Preload("Reports").Where("status = ?", status).Order("id DESC")
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be reported, hidden or visible"})
+			return
+		}
+		if activityID := c.Query("activity_id"); activityID != "" {
+			query = query.Where("activity_id = ?", activityID)
+		}
+
+		var reviews []models.ActivityReview
+		if err := query.Find(&reviews).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, reviews)
+	}
+}
+
+// HideReview ซ่อนรีวิว ปิดการแจ้งที่ค้างอยู่ว่าจัดการแล้ว และคำนวณคะแนนของกิจกรรมใหม่
+func HideReview(db *gorm.DB) gin.HandlerFunc {
+	return moderateReview(db, func(tx *gorm.DB, review *models.ActivityReview, adminID uint, reason string, now time.Time) error {
+		if err := tx.Model(review).Updates(map[string]interface{}{
+			"status":        models.ReviewStatusHidden,
+			"hidden_reason": reason,
+			"hidden_by_id":  adminID,
+			"hidden_at":     now,
+		}).Error; err != nil {
+			return err
+		}
+		return closeReports(tx, review.ID, models.ReportStatusResolved, adminID, now)
+	})
+}
+
+// RestoreReview แสดงรีวิวที่ถูกซ่อนอีกครั้ง
+func RestoreReview(db *gorm.DB) gin.HandlerFunc {
+	return moderateReview(db, func(tx *gorm.DB, review *models.ActivityReview, adminID uint, reason string, now time.Time) error {
+		return tx.Model(review).Updates(map[string]interface{}{
+			"status":        models.ReviewStatusVisible,
+			"hidden_reason": "",
+			"hidden_by_id":  nil,
+			"hidden_at":     nil,
+		}).Error
+	})
+}
+
+// DismissReviewReports ปิดการแจ้งที่ค้างอยู่โดยไม่ซ่อนรีวิว (รีวิวไม่ผิดกฎ)
+func DismissReviewReports(db *gorm.DB) gin.HandlerFunc {
+	return moderateReview(db, func(tx *gorm.DB, review *models.ActivityReview, adminID uint, reason string, now time.Time) error {
+		return closeReports(tx, review.ID, models.ReportStatusDismissed, adminID, now)
+	})
+}
+
+func moderateReview(db *gorm.DB, apply func(tx *gorm.DB, review *models.ActivityReview, adminID uint, reason string, now time.Time) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID := c.MustGet("user_id").(uint)
+
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var review models.ActivityReview
+		if err := db.First(&review, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+
+		// body เป็น optional ({"reason": "..."})
+		var input ReviewModerationInput
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := apply(tx, &review, adminID, strings.TrimSpace(input.Reason), time.Now()); err != nil {
+				return err
+			}
+			return refreshActivityRating(tx, review.ActivityID)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		db.Preload("Author", reviewAuthor).Preload("Reports").First(&review, review.ID)
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+func closeReports(tx *gorm.DB, reviewID uint, status string, adminID uint, now time.Time) error {
+	return tx.Model(&models.ReviewReport{}).
+		Where("review_id = ? AND status = ?", reviewID, models.ReportStatusOpen).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"resolved_by_id": adminID,
+			"resolved_at":    now,
+		}).Error
+}
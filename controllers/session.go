@@ -0,0 +1,574 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxCalendarRange คือช่วงวันที่ยาวที่สุดที่ขอดูปฏิทิน session ได้ในครั้งเดียว
+const maxCalendarRange = 366 * 24 * time.Hour
+
+// SessionItemInput คือกิจกรรมหนึ่งรายการใน session ถ้าส่ง item_id มาจะเป็นการแก้ไขรายการเดิม
+// ไม่ส่ง planned_minutes จะใช้ระยะเวลาของกิจกรรม (ถ้ามี)
+type SessionItemInput struct {
+	ItemID         *uint  `json:"item_id"`
+	ActivityID     uint   `json:"activity_id" binding:"required"`
+	PlannedMinutes *int   `json:"planned_minutes" binding:"omitempty,gte=1,lte=600"`
+	Notes          string `json:"notes" binding:"max=2000"`
+}
+
+// SessionInput คือข้อมูล session ทั้งหมด ต้องระบุ client_id หรือ client_group_id อย่างใดอย่างหนึ่ง
+// items แทนที่รายการกิจกรรมทั้งหมดตามลำดับที่ส่งมา
+type SessionInput struct {
+	ClientID      *uint              `json:"client_id"`
+	ClientGroupID *uint              `json:"client_group_id"`
+	StartsAt      time.Time          `json:"starts_at" binding:"required"`
+	EndsAt        time.Time          `json:"ends_at" binding:"required"`
+	Location      string             `json:"location" binding:"max=500"`
+	Notes         string             `json:"notes" binding:"max=20000"`
+	Status        string             `json:"status" binding:"omitempty,oneof=planned completed cancelled"`
+	Items         []SessionItemInput `json:"items" binding:"max=50,dive"`
+}
+
+type SessionRescheduleInput struct {
+	StartsAt time.Time  `json:"starts_at" binding:"required"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+type SessionDuplicateInput struct {
+	StartsAt *time.Time `json:"starts_at"`
+}
+
+type SessionReorderInput struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+func orderedSessionItems(db *gorm.DB) *gorm.DB {
+	return db.Order("session_items.position ASC")
+}
+
+// preloadSessionDetails โหลดรายการกิจกรรม ผู้รับบริการ และกลุ่มของ session
+func preloadSessionDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", orderedSessionItems).
+		Preload("Items.Activity").
+		Preload("Client").
+		Preload("ClientGroup")
+}
+
+// validateSessionInput ตรวจเจ้าของ เวลา และรายการกิจกรรม แล้วคืนกิจกรรมที่อ้างถึงตาม id
+// sessionID เป็น 0 เมื่อสร้าง session ใหม่
+func validateSessionInput(db *gorm.DB, userID, sessionID uint, input *SessionInput, fields fieldErrors) (map[uint]models.Activity, error) {
+	switch {
+	case input.ClientID != nil && input.ClientGroupID != nil:
+		fields.add("client_id", "set either client_id or client_group_id, not both")
+	case input.ClientID != nil:
+		var count int64
+		if err := db.Model(&models.Client{}).Scopes(models.ClientsAccessibleBy(userID)).
+			Where("id = ?", *input.ClientID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			fields.add("client_id", "client not found")
+		}
+	case input.ClientGroupID != nil:
+		var count int64
+		if err := db.Model(&models.ClientGroup{}).Scopes(models.ClientGroupsAccessibleBy(userID)).
+			Where("id = ?", *input.ClientGroupID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			fields.add("client_group_id", "client group not found")
+		}
+	default:
+		fields.add("client_id", "client_id or client_group_id is required")
+	}
+
+	if !input.EndsAt.After(input.StartsAt) {
+		fields.add("ends_at", "must be after starts_at")
+	}
+
+	activityIDs := make([]uint, 0, len(input.Items))
+	for _, item := range input.Items {
+		activityIDs = append(activityIDs, item.ActivityID)
+	}
+	activities := map[uint]models.Activity{}
+	if ids := uniqueIDs(activityIDs); len(ids) > 0 {
+		var found []models.Activity
+		if err := db.Select("id", "title", "duration_minutes").
+			Where("id IN ? AND status = ?", ids, models.ActivityStatusPublished).
+			Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, a := range found {
+			activities[a.ID] = a
+		}
+	}
+
+	seen := map[uint]bool{}
+	for i, item := range input.Items {
+		if _, ok := activities[item.ActivityID]; !ok {
+			fields.add(fmt.Sprintf("items[%d].activity_id", i), "activity not found")
+		}
+		if item.ItemID == nil {
+			continue
+		}
+		if seen[*item.ItemID] {
+			fields.add(fmt.Sprintf("items[%d].item_id", i), "item %d is listed more than once", *item.ItemID)
+			continue
+		}
+		seen[*item.ItemID] = true
+		var count int64
+		if err := db.Model(&models.SessionItem{}).
+			Where("id = ? AND session_id = ?", *item.ItemID, sessionID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			fields.add(fmt.Sprintf("items[%d].item_id", i), "item %d does not belong to this session", *item.ItemID)
+		}
+	}
+	return activities, nil
+}
+
+// replaceSessionItems แทนที่รายการกิจกรรมทั้งหมดของ session ตามลำดับใน inputs
+// รายการที่ไม่อยู่ใน inputs จะถูกลบ ต้องเรียกภายใน Transaction และผ่าน validateSessionInput มาก่อนแล้ว
+func replaceSessionItems(tx *gorm.DB, sessionID uint, inputs []SessionItemInput, activities map[uint]models.Activity) error {
+	keep := make([]uint, 0, len(inputs))
+	for i, in := range inputs {
+		activity := activities[in.ActivityID]
+		activityID := activity.ID
+		item := models.SessionItem{
+			SessionID:      sessionID,
+			Position:       i + 1,
+			ActivityID:     &activityID,
+			ActivityTitle:  activity.Title,
+			PlannedMinutes: in.PlannedMinutes,
+			Notes:          strings.TrimSpace(in.Notes),
+		}
+		if item.PlannedMinutes == nil {
+			item.PlannedMinutes = activity.DurationMinutes
+		}
+
+		if in.ItemID != nil {
+			item.ID = *in.ItemID
+			if err := tx.Omit("Activity", "CreatedAt").Save(&item).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Omit("Activity").Create(&item).Error; err != nil {
+			return err
+		}
+		keep = append(keep, item.ID)
+	}
+
+	removed := tx.Where("session_id = ?", sessionID)
+	if len(keep) > 0 {
+		removed = removed.Where("id NOT IN ?", keep)
+	}
+	return removed.Delete(&models.SessionItem{}).Error
+}
+
+// errSessionLocked คือกรณีที่แก้เวลาหรือผู้เข้าร่วมของ session ที่บันทึกผลการสังเกตแล้ว
+// ช่วงเวลาที่แก้ไขผลการสังเกตได้นับจาก ends_at จึงห้ามเลื่อนออกไป และผลการสังเกตต้องยังเป็นของผู้เข้าร่วมเดิม
+var errSessionLocked = errors.New("session has recorded observations; its time and participants can no longer be changed")
+
+// sessionHasObservations ตรวจว่า session มีผลการสังเกตที่บันทึกไว้แล้ว
+func sessionHasObservations(db *gorm.DB, sessionID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Observation{}).Where("session_id = ?", sessionID).Count(&count).Error
+	return count > 0, err
+}
+
+// sessionFromInput เขียนค่าจาก input ลงใน session (ยกเว้นรายการกิจกรรม)
+func sessionFromInput(session *models.Session, input *SessionInput) {
+	session.ClientID = input.ClientID
+	session.ClientGroupID = input.ClientGroupID
+	session.StartsAt = input.StartsAt
+	session.EndsAt = input.EndsAt
+	session.Location = strings.TrimSpace(input.Location)
+	session.Notes = input.Notes
+	if input.Status != "" {
+		session.Status = input.Status
+	}
+}
+
+// parseCalendarBound อ่านวันที่ของปฏิทินได้ทั้ง RFC3339 และ YYYY-MM-DD (ตามเขตเวลา loc)
+// endOfDay ให้วันที่แบบ YYYY-MM-DD หมายถึงสิ้นวันนั้น (รวมทั้งวัน)
+func parseCalendarBound(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ListSessionCalendar คืน session ที่ผู้ใช้เข้าถึงได้ซึ่งคาบเกี่ยวช่วง ?from= ถึง ?to= (ไม่เกิน 366 วัน)
+// วันที่แบบ YYYY-MM-DD ตีความตาม ?tz= (ค่าเริ่มต้น UTC) และ to รวมทั้งวัน
+// กรองเพิ่มได้ด้วย ?client_id= ?client_group_id= ?status=
+func ListSessionCalendar(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tz must be an IANA time zone such as Asia/Bangkok"})
+			return
+		}
+		from, err := parseCalendarBound(c.Query("from"), loc, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD) or RFC3339 time"})
+			return
+		}
+		to, err := parseCalendarBound(c.Query("to"), loc, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD) or RFC3339 time"})
+			return
+		}
+		if !to.After(from) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+			return
+		}
+		if to.Sub(from) > maxCalendarRange {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date range must not exceed 366 days"})
+			return
+		}
+
+		query := preloadSessionDetails(db.Model(&models.Session{})).
+			Scopes(models.SessionsAccessibleBy(userID)).
+			Where("sessions.starts_at < ? AND sessions.ends_at > ?", to, from)
+		if clientID := c.Query("client_id"); clientID != "" {
+			query = query.Where("sessions.client_id = ?", clientID)
+		}
+		if groupID := c.Query("client_group_id"); groupID != "" {
+			query = query.Where("sessions.client_group_id = ?", groupID)
+		}
+		if status := c.Query("status"); status != "" {
+			if !models.ValidSessionStatus(status) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be planned, completed or cancelled"})
+				return
+			}
+			query = query.Where("sessions.status = ?", status)
+		}
+
+		var sessions []models.Session
+		if err := query.Order("sessions.starts_at ASC, sessions.id ASC").Find(&sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "sessions": sessions})
+	}
+}
+
+// CreateSession สร้าง session พร้อมรายการกิจกรรม (ต้องเป็นกิจกรรมที่เผยแพร่แล้ว)
+func CreateSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+
+		var input SessionInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		fields := fieldErrors{}
+		for i, item := range input.Items {
+			if item.ItemID != nil {
+				fields.add(fmt.Sprintf("items[%d].item_id", i), "must not be set when creating a session")
+			}
+		}
+		activities, err := validateSessionInput(db, userID, 0, &input, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		session := models.Session{CreatedByID: userID, Status: models.SessionStatusPlanned}
+		sessionFromInput(&session, &input)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit(clause.Associations).Create(&session).Error; err != nil {
+				return err
+			}
+			return replaceSessionItems(tx, session.ID, input.Items, activities)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondSession(c, db, http.StatusCreated, session.ID)
+	}
+}
+
+// GetSession คืน session ที่ middleware.SessionAccess ตรวจสิทธิ์แล้ว
+func GetSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := c.MustGet("session").(*models.Session)
+		respondSession(c, db, http.StatusOK, session.ID)
+	}
+}
+
+// UpdateSession แก้ไข session ทั้งหมดรวมรายการกิจกรรม (รายการที่ส่ง item_id มาจะคงรายการเดิมไว้)
+// เมื่อบันทึกผลการสังเกตแล้ว เวลาและผู้เข้าร่วมต้องคงเดิม (ตอบ 409)
+func UpdateSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		session := c.MustGet("session").(*models.Session)
+
+		var input SessionInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		fields := fieldErrors{}
+		activities, err := validateSessionInput(db, userID, session.ID, &input, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		if !session.StartsAt.Equal(input.StartsAt) || !session.EndsAt.Equal(input.EndsAt) ||
+			!sameID(session.ClientID, input.ClientID) || !sameID(session.ClientGroupID, input.ClientGroupID) {
+			locked, err := sessionHasObservations(db, session.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if locked {
+				c.JSON(http.StatusConflict, gin.H{"error": errSessionLocked.Error()})
+				return
+			}
+		}
+
+		sessionFromInput(session, &input)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(session).
+				Select("client_id", "client_group_id", "starts_at", "ends_at", "location", "notes", "status").
+				Updates(session).Error; err != nil {
+				return err
+			}
+			return replaceSessionItems(tx, session.ID, input.Items, activities)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondSession(c, db, http.StatusOK, session.ID)
+	}
+}
+
+// RescheduleSession ย้ายเวลาของ session ถ้าไม่ส่ง ends_at จะคงระยะเวลาเดิมไว้
+// session ที่ยกเลิกไปแล้วกลับมาเป็น planned ส่วน session ที่เสร็จแล้วหรือบันทึกผลการสังเกตแล้วย้ายเวลาไม่ได้
+func RescheduleSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := c.MustGet("session").(*models.Session)
+
+		var input SessionRescheduleInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if session.Status == models.SessionStatusCompleted {
+			c.JSON(http.StatusConflict, gin.H{"error": "a completed session cannot be rescheduled"})
+			return
+		}
+		locked, err := sessionHasObservations(db, session.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if locked {
+			c.JSON(http.StatusConflict, gin.H{"error": errSessionLocked.Error()})
+			return
+		}
+		endsAt := input.StartsAt.Add(session.EndsAt.Sub(session.StartsAt))
+		if input.EndsAt != nil {
+			endsAt = *input.EndsAt
+		}
+		if !endsAt.After(input.StartsAt) {
+			respondValidation(c, fieldErrors{"ends_at": "must be after starts_at"})
+			return
+		}
+
+		if err := db.Model(session).Updates(map[string]interface{}{
+			"starts_at": input.StartsAt,
+			"ends_at":   endsAt,
+			"status":    models.SessionStatusPlanned,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondSession(c, db, http.StatusOK, session.ID)
+	}
+}
+
+// DuplicateSession คัดลอก session พร้อมรายการกิจกรรมเป็น session ใหม่สถานะ planned
+// ถ้าไม่ส่ง starts_at จะนัดในเวลาเดิมของสัปดาห์ถัดไป ระยะเวลาเท่าเดิม
+func DuplicateSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		source := c.MustGet("session").(*models.Session)
+
+		var input SessionDuplicateInput
+		if c.Request.ContentLength != 0 && !bindValidJSON(c, &input) {
+			return
+		}
+		startsAt := source.StartsAt.AddDate(0, 0, 7)
+		if input.StartsAt != nil {
+			startsAt = *input.StartsAt
+		}
+
+		var items []models.SessionItem
+		if err := db.Where("session_id = ?", source.ID).Order("position ASC").Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		sourceID := source.ID
+		duplicate := models.Session{
+			ClientID:         source.ClientID,
+			ClientGroupID:    source.ClientGroupID,
+			StartsAt:         startsAt,
+			EndsAt:           startsAt.Add(source.EndsAt.Sub(source.StartsAt)),
+			Location:         source.Location,
+			Notes:            source.Notes,
+			Status:           models.SessionStatusPlanned,
+			CreatedByID:      userID,
+			DuplicatedFromID: &sourceID,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit(clause.Associations).Create(&duplicate).Error; err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				return nil
+			}
+			for i := range items {
+				items[i].ID = 0
+				items[i].SessionID = duplicate.ID
+			}
+			return tx.Omit("Activity").Create(&items).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondSession(c, db, http.StatusCreated, duplicate.ID)
+	}
+}
+
+// ReorderSessionItems เรียงรายการกิจกรรมใหม่ตาม ids รายการที่ไม่ได้ระบุจะต่อท้ายตามลำดับเดิม
+func ReorderSessionItems(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := c.MustGet("session").(*models.Session)
+
+		var input SessionReorderInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+
+		var errInput error
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var current []uint
+			if err := tx.Model(&models.SessionItem{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("session_id = ?", session.ID).
+				Order("position ASC, id ASC").
+				Pluck("id", &current).Error; err != nil {
+				return err
+			}
+			inSession := make(map[uint]bool, len(current))
+			for _, id := range current {
+				inSession[id] = true
+			}
+
+			order := make([]uint, 0, len(current))
+			listed := make(map[uint]bool, len(input.IDs))
+			for _, id := range input.IDs {
+				if !inSession[id] {
+					errInput = fmt.Errorf("item %d does not belong to this session", id)
+					return errInput
+				}
+				if listed[id] {
+					errInput = fmt.Errorf("item %d is listed twice", id)
+					return errInput
+				}
+				listed[id] = true
+				order = append(order, id)
+			}
+			for _, id := range current {
+				if !listed[id] {
+					order = append(order, id)
+				}
+			}
+
+			for i, id := range order {
+				if err := tx.Model(&models.SessionItem{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if errInput != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInput.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondSession(c, db, http.StatusOK, session.ID)
+	}
+}
+
+// DeleteSession ลบ session พร้อมรายการกิจกรรม
+// session ที่บันทึกผลการสังเกตไว้แล้วลบไม่ได้ เพื่อไม่ให้ประวัติของผู้รับบริการหายไป (ใช้สถานะ cancelled แทน)
+func DeleteSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := c.MustGet("session").(*models.Session)
+		hasObservations, err := sessionHasObservations(db, session.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if hasObservations {
+			c.JSON(http.StatusConflict, gin.H{"error": "session has recorded observations; cancel it instead of deleting"})
+			return
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("session_id = ?", session.ID).Delete(&models.SessionItem{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(session).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Session deleted successfully"})
+	}
+}
+
+func respondSession(c *gin.Context, db *gorm.DB, status int, id uint) {
+	var session models.Session
+	if err := preloadSessionDetails(db).First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	c.JSON(status, session)
+}
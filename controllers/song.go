@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SongInput struct {
+	Title         string `json:"title" binding:"required"`
+	Composer      string `json:"composer"`
+	Lyricist      string `json:"lyricist"`
+	Language      string `json:"language"`
+	TempoBPM      *int   `json:"tempo_bpm"`
+	Key           string `json:"key"`
+	TimeSignature string `json:"time_signature"`
+	Lyrics        string `json:"lyrics"`
+	CoverImage    string `json:"cover_image" binding:"omitempty,max=2048,weburl"`
+	AudioURL      string `json:"audio_url" binding:"omitempty,max=2048,weburl"`
+	CoverMediaID  *uint  `json:"cover_media_id"`
+	AudioMediaID  *uint  `json:"audio_media_id"`
+}
+
+func (in SongInput) validate() error {
+	if strings.TrimSpace(in.Title) == "" {
+		return fmt.Errorf("title is required")
+	}
+	if in.TempoBPM != nil && (*in.TempoBPM < 1 || *in.TempoBPM > 400) {
+		return fmt.Errorf("tempo_bpm must be between 1 and 400")
+	}
+	return nil
+}
+
+// applyMedia ตรวจสอบไฟล์ที่อ้างอิง และใช้ URL ของไฟล์แทนข้อความ cover_image/audio_url
+func (in *SongInput) applyMedia(db *gorm.DB, c *gin.Context) error {
+	cover, err := resolveMediaRef(db, c, "cover_media_id", in.CoverMediaID, models.MediaPurposeSongImage)
+	if err != nil {
+		return err
+	}
+	if cover != nil {
+		in.CoverImage = cover.URL
+	}
+
+	audio, err := resolveMediaRef(db, c, "audio_media_id", in.AudioMediaID, models.MediaPurposeSongAudio)
+	if err != nil {
+		return err
+	}
+	if audio != nil {
+		in.AudioURL = audio.URL
+	}
+	return nil
+}
+
+func ListSongs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var songs []models.Song
+
+		query := db.Order("title ASC")
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			like := "%" + q + "%"
+			query = query.Where("title ILIKE ? OR composer ILIKE ? OR lyricist ILIKE ?", like, like, like)
+		}
+		if language := c.Query("language"); language != "" {
+			query = query.Where("language = ?", language)
+		}
+
+		if err := query.Find(&songs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, songs)
+	}
+}
+
+// songID อ่าน :id ของเพลง ถ้าไม่ใช่ตัวเลขตอบ 400 แล้วคืน false
+func songID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func GetSongByID(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := songID(c)
+		if !ok {
+			return
+		}
+		var song models.Song
+		if err := db.Preload("CoverMedia").Preload("AudioMedia").First(&song, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, song)
+	}
+}
+
+// ListSongActivities คืนรายการกิจกรรมทั้งหมดที่ใช้เพลงนี้
+func ListSongActivities(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := songID(c)
+		if !ok {
+			return
+		}
+		var song models.Song
+		if err := db.First(&song, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+			return
+		}
+
+		var activities []models.Activity
+		if err := preloadActivityDetails(db).
+			Joins("JOIN activity_songs ON activity_songs.activity_id = activities.id").
+			Where("activity_songs.song_id = ? AND activities.status = ?", song.ID, models.ActivityStatusPublished).
+			Order("activities.id DESC").
+			Find(&activities).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := localizeActivities(c, db, activities); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, activities)
+	}
+}
+
+func CreateSong(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input SongInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if err := input.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := input.applyMedia(db, c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		song := models.Song{
+			Title:         strings.TrimSpace(input.Title),
+			Composer:      input.Composer,
+			Lyricist:      input.Lyricist,
+			Language:      input.Language,
+			TempoBPM:      input.TempoBPM,
+			Key:           input.Key,
+			TimeSignature: input.TimeSignature,
+			Lyrics:        input.Lyrics,
+			CoverImage:    input.CoverImage,
+			AudioURL:      input.AudioURL,
+			CoverMediaID:  input.CoverMediaID,
+			AudioMediaID:  input.AudioMediaID,
+		}
+		if err := db.Create(&song).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, song)
+	}
+}
+
+func UpdateSong(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := songID(c)
+		if !ok {
+			return
+		}
+		var song models.Song
+		if err := db.First(&song, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+			return
+		}
+
+		var input SongInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if err := input.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := input.applyMedia(db, c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		title := strings.TrimSpace(input.Title)
+		updates := map[string]interface{}{
+			"title":            title,
+			"normalized_title": models.NormalizeSongTitle(title),
+			"composer":         input.Composer,
+			"lyricist":         input.Lyricist,
+			"language":         input.Language,
+			"tempo_bpm":        input.TempoBPM,
+			"musical_key":      input.Key,
+			"time_signature":   input.TimeSignature,
+			"lyrics":           input.Lyrics,
+			"cover_image":      input.CoverImage,
+			"audio_url":        input.AudioURL,
+			"cover_media_id":   input.CoverMediaID,
+			"audio_media_id":   input.AudioMediaID,
+		}
+		if err := db.Model(&song).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		db.First(&song, song.ID)
+		c.JSON(http.StatusOK, song)
+	}
+}
+
+func DeleteSong(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := songID(c)
+		if !ok {
+			return
+		}
+		var song models.Song
+		if err := db.First(&song, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("DELETE FROM activity_songs WHERE song_id = ?", song.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM activity_variant_songs WHERE song_id = ?", song.ID).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&song).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Song deleted successfully"})
+	}
+}
+
+// findSongsByIDs โหลดเพลงตาม ID และแจ้งข้อผิดพลาดถ้ามี ID ที่ไม่พบ
+func findSongsByIDs(db *gorm.DB, ids []uint) ([]models.Song, error) {
+	var songs []models.Song
+	if len(ids) == 0 {
+		return songs, nil
+	}
+	if err := db.Where("id IN ?", ids).Find(&songs).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[uint]bool, len(songs))
+	for _, song := range songs {
+		found[song.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("song_id %d not found", id)
+		}
+	}
+	return songs, nil
+}
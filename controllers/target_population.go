@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"project-backend/i18n"
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TargetPopulationInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+func ListTargetPopulations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var items []models.TargetPopulation
+
+		query := db.Order("name ASC")
+		if name := c.Query("name"); name != "" {
+			query = query.Where("name ILIKE ?", "%"+name+"%")
+		}
+
+		if err := query.Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range items {
+			batch.TargetPopulation(&items[i])
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+func CreateTargetPopulation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TargetPopulationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		item := models.TargetPopulation{
+			Name:        strings.TrimSpace(input.Name),
+			Description: input.Description,
+		}
+		if err := db.Create(&item).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Could not create target population (name may already exist)"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, item)
+	}
+}
+
+func UpdateTargetPopulation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var item models.TargetPopulation
+		if err := db.First(&item, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Target population not found"})
+			return
+		}
+
+		var input TargetPopulationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{
+			"name":        strings.TrimSpace(input.Name),
+			"description": input.Description,
+		}
+		if err := db.Model(&item).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Could not update target population (name may already exist)"})
+			return
+		}
+
+		db.First(&item, item.ID)
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+func DeleteTargetPopulation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var item models.TargetPopulation
+		if err := db.First(&item, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Target population not found"})
+			return
+		}
+
+		var usage int64
+		db.Table("activity_target_populations").Where("target_population_id = ?", item.ID).Count(&usage)
+		if usage > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "Target population is still used by activities",
+				"activity_count": usage,
+			})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("entity_type = ? AND entity_id = ?", i18n.EntityTargetPopulation, item.ID).Delete(&models.Translation{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&item).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Target population deleted successfully"})
+	}
+}
@@ -0,0 +1,884 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-backend/models"
+	"project-backend/related"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// taxonomyKind อธิบายตารางของ taxonomy แต่ละชนิด ให้ handler ชุดเดียวจัดการได้ทั้ง 4 ชนิด
+type taxonomyKind struct {
+	kind       string
+	label      string
+	table      string
+	nameColumn string
+
+	// รายการย่อยเท่านั้น: คอลัมน์ที่ชี้ไปยังรายการหลัก และตาราง many2many ที่ผูกกับกิจกรรม
+	parent       *taxonomyKind
+	parentColumn string
+	linkTable    string
+	linkColumn   string
+	stepColumn   string
+
+	// ตาราง many2many ที่ผูกกับรูปแบบกิจกรรม (ใช้ linkColumn เดียวกัน)
+	variantLinkTable string
+	// คอลัมน์ของผลการสังเกตที่อ้างถึงรายการนี้
+	observationColumn string
+	// เป้าหมายในแผนการบำบัดอ้างถึงรายการนี้ (ดู mergePlanGoals)
+	planGoals bool
+
+	// รายการหลักเท่านั้น
+	child *taxonomyKind
+
+	create func(tx *gorm.DB, name string, parentID uint, sortOrder int) (uint, error)
+	model  func() any
+}
+
+var (
+	goalTaxonomy = &taxonomyKind{
+		kind:       models.TaxonomyKindGoal,
+		label:      "Goal",
+		table:      "activity_goals",
+		nameColumn: "goal_name",
+		create: func(tx *gorm.DB, name string, _ uint, sortOrder int) (uint, error) {
+			goal := models.ActivityGoal{GoalName: name, SortOrder: sortOrder}
+			err := tx.Create(&goal).Error
+			return goal.ID, err
+		},
+		model: func() any { return &models.ActivityGoal{} },
+	}
+	subGoalTaxonomy = &taxonomyKind{
+		kind:         models.TaxonomyKindSubGoal,
+		label:        "Sub-goal",
+		table:        "activity_sub_goals",
+		nameColumn:   "sub_goal_name",
+		parentColumn: "goal_id",
+		linkTable:    "activity_selected_sub_goals",
+		linkColumn:   "activity_sub_goal_id",
+		stepColumn:   "sub_goal_id",
+
+		variantLinkTable:  "activity_variant_sub_goals",
+		observationColumn: "sub_goal_id",
+		planGoals:         true,
+		create: func(tx *gorm.DB, name string, parentID uint, sortOrder int) (uint, error) {
+			sub := models.ActivitySubGoal{GoalID: parentID, SubGoalName: name, SortOrder: sortOrder}
+			err := tx.Create(&sub).Error
+			return sub.ID, err
+		},
+		model: func() any { return &models.ActivitySubGoal{} },
+	}
+	categoryTaxonomy = &taxonomyKind{
+		kind:       models.TaxonomyKindCategory,
+		label:      "Category",
+		table:      "activity_main_categories",
+		nameColumn: "category_name",
+		create: func(tx *gorm.DB, name string, _ uint, sortOrder int) (uint, error) {
+			cat := models.ActivityMainCategory{CategoryName: name, SortOrder: sortOrder}
+			err := tx.Create(&cat).Error
+			return cat.ID, err
+		},
+		model: func() any { return &models.ActivityMainCategory{} },
+	}
+	subCategoryTaxonomy = &taxonomyKind{
+		kind:         models.TaxonomyKindSubCategory,
+		label:        "Sub-category",
+		table:        "activity_sub_categories",
+		nameColumn:   "sub_category_name",
+		parentColumn: "category_id",
+		linkTable:    "activity_selected_sub_categories",
+		linkColumn:   "activity_sub_category_id",
+		create: func(tx *gorm.DB, name string, parentID uint, sortOrder int) (uint, error) {
+			sub := models.ActivitySubCategory{CategoryID: parentID, SubCategoryName: name, SortOrder: sortOrder}
+			err := tx.Create(&sub).Error
+			return sub.ID, err
+		},
+		model: func() any { return &models.ActivitySubCategory{} },
+	}
+
+	// taxonomyKinds จับคู่ :kind ใน URL กับชนิดของ taxonomy
+	taxonomyKinds = map[string]*taxonomyKind{
+		"goals":          goalTaxonomy,
+		"sub-goals":      subGoalTaxonomy,
+		"categories":     categoryTaxonomy,
+		"sub-categories": subCategoryTaxonomy,
+	}
+)
+
+func init() {
+	goalTaxonomy.child, subGoalTaxonomy.parent = subGoalTaxonomy, goalTaxonomy
+	categoryTaxonomy.child, subCategoryTaxonomy.parent = subCategoryTaxonomy, categoryTaxonomy
+}
+
+// taxonomyRow คือแถวของตาราง taxonomy ใด ๆ ใช้ตรวจสอบและบันทึก audit
+type taxonomyRow struct {
+	ID           uint       `json:"id"`
+	Name         string     `json:"name"`
+	ParentID     uint       `json:"parent_id,omitempty"`
+	SortOrder    int        `json:"sort_order"`
+	RetiredAt    *time.Time `json:"retired_at,omitempty"`
+	MergedIntoID *uint      `json:"merged_into_id,omitempty"`
+}
+
+// statusError คือข้อผิดพลาดจากการตรวจสอบภายใน transaction ที่ต้องตอบกลับด้วย HTTP status เฉพาะ
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string { return e.message }
+
+func taxonomyFail(status int, format string, args ...any) error {
+	return &statusError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func respondTaxonomyError(c *gin.Context, err error) {
+	var se *statusError
+	if errors.As(err, &se) {
+		c.JSON(se.status, gin.H{"error": se.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func (k *taxonomyKind) query(tx *gorm.DB) *gorm.DB {
+	cols := fmt.Sprintf("id, %s AS name, sort_order, retired_at, merged_into_id", k.nameColumn)
+	if k.parentColumn != "" {
+		cols += fmt.Sprintf(", %s AS parent_id", k.parentColumn)
+	}
+	return tx.Table(k.table).Select(cols)
+}
+
+// load อ่านรายการพร้อมล็อกแถวไว้จนจบ transaction
+func (k *taxonomyKind) load(tx *gorm.DB, id uint) (*taxonomyRow, error) {
+	var row taxonomyRow
+	err := k.query(tx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, taxonomyFail(http.StatusNotFound, "%s %d not found", k.label, id)
+	}
+	return &row, err
+}
+
+func (k *taxonomyKind) siblings(tx *gorm.DB, parentID uint) *gorm.DB {
+	query := k.query(tx)
+	if k.parentColumn != "" {
+		query = query.Where(k.parentColumn+" = ?", parentID)
+	}
+	return query
+}
+
+// checkName ห้ามชื่อซ้ำกับรายการที่ยังใช้งานอยู่ในกลุ่มเดียวกัน (ไม่สนตัวพิมพ์เล็กใหญ่)
+func (k *taxonomyKind) checkName(tx *gorm.DB, parentID uint, name string, exceptID uint) error {
+	var existing taxonomyRow
+	err := k.siblings(tx, parentID).
+		Where(fmt.Sprintf("LOWER(TRIM(%s)) = LOWER(?)", k.nameColumn), name).
+		Where("retired_at IS NULL AND id <> ?", exceptID).
+		Take(&existing).Error
+	if err == nil {
+		return taxonomyFail(http.StatusConflict, "%s %q already exists (id %d)", k.label, name, existing.ID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// checkParent รายการย่อยต้องอยู่ใต้รายการหลักที่มีอยู่และยังไม่ถูกยกเลิก
+func (k *taxonomyKind) checkParent(tx *gorm.DB, parentID uint) error {
+	if parentID == 0 {
+		return taxonomyFail(http.StatusBadRequest, "parent_id is required")
+	}
+	parent, err := k.parent.load(tx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent.RetiredAt != nil {
+		return taxonomyFail(http.StatusConflict, "%s %d is retired", k.parent.label, parentID)
+	}
+	return nil
+}
+
+func (k *taxonomyKind) nextSortOrder(tx *gorm.DB, parentID uint) (int, error) {
+	var next int
+	query := tx.Table(k.table).Select("COALESCE(MAX(sort_order), -1) + 1")
+	if k.parentColumn != "" {
+		query = query.Where(k.parentColumn+" = ?", parentID)
+	}
+	err := query.Scan(&next).Error
+	return next, err
+}
+
+func (k *taxonomyKind) update(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	return tx.Table(k.table).Where("id = ?", id).Updates(updates).Error
+}
+
+func (k *taxonomyKind) reload(tx *gorm.DB, id uint) (any, error) {
+	entry := k.model()
+	err := tx.First(entry, id).Error
+	return entry, err
+}
+
+func writeTaxonomyAudit(tx *gorm.DB, kind string, entityID uint, action string, before, after any, userID uint) error {
+	entry := models.TaxonomyAuditLog{Kind: kind, EntityID: entityID, Action: action, UserID: userID}
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		entry.Before = string(data)
+	}
+	if after != nil {
+		data, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		entry.After = string(data)
+	}
+	return tx.Create(&entry).Error
+}
+
+func taxonomyKindParam(c *gin.Context) (*taxonomyKind, bool) {
+	kind, ok := taxonomyKinds[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown taxonomy kind; use goals, sub-goals, categories or sub-categories"})
+	}
+	return kind, ok
+}
+
+type TaxonomyEntryInput struct {
+	Name      string `json:"name" binding:"required"`
+	ParentID  uint   `json:"parent_id"`
+	SortOrder *int   `json:"sort_order"`
+}
+
+// CreateTaxonomyEntry สร้างเป้าหมาย เป้าหมายย่อย หมวดหมู่ หรือหมวดหมู่ย่อยใหม่
+// รายการย่อยต้องระบุ parent_id ถ้าไม่ระบุ sort_order จะต่อท้ายรายการเดิมในกลุ่ม
+func CreateTaxonomyEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, ok := taxonomyKindParam(c)
+		if !ok {
+			return
+		}
+		var input TaxonomyEntryInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		if kind.parent == nil {
+			input.ParentID = 0
+		}
+		userID := c.MustGet("user_id").(uint)
+
+		var created any
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if kind.parent != nil {
+				if err := kind.checkParent(tx, input.ParentID); err != nil {
+					return err
+				}
+			}
+			if err := kind.checkName(tx, input.ParentID, name, 0); err != nil {
+				return err
+			}
+
+			sortOrder, err := kind.nextSortOrder(tx, input.ParentID)
+			if err != nil {
+				return err
+			}
+			if input.SortOrder != nil {
+				sortOrder = *input.SortOrder
+			}
+
+			id, err := kind.create(tx, name, input.ParentID, sortOrder)
+			if err != nil {
+				return err
+			}
+			after, err := kind.load(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := writeTaxonomyAudit(tx, kind.kind, id, models.TaxonomyActionCreate, nil, after, userID); err != nil {
+				return err
+			}
+			created, err = kind.reload(tx, id)
+			return err
+		})
+		if err != nil {
+			respondTaxonomyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+type TaxonomyUpdateInput struct {
+	Name      *string `json:"name"`
+	SortOrder *int    `json:"sort_order"`
+	ParentID  *uint   `json:"parent_id"`
+}
+
+// UpdateTaxonomyEntry เปลี่ยนชื่อ ลำดับ หรือย้ายรายการย่อยไปอยู่ใต้รายการหลักอื่น
+// ส่งเฉพาะฟิลด์ที่ต้องการเปลี่ยน
+func UpdateTaxonomyEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, ok := taxonomyKindParam(c)
+		if !ok {
+			return
+		}
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var input TaxonomyUpdateInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.ParentID != nil && kind.parent == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id only applies to sub-goals and sub-categories"})
+			return
+		}
+		userID := c.MustGet("user_id").(uint)
+
+		var updated any
+		err := db.Transaction(func(tx *gorm.DB) error {
+			before, err := kind.load(tx, id)
+			if err != nil {
+				return err
+			}
+			if before.MergedIntoID != nil {
+				return taxonomyFail(http.StatusConflict, "%s %d was merged into %d", kind.label, id, *before.MergedIntoID)
+			}
+
+			updates := map[string]interface{}{}
+			name, parentID := before.Name, before.ParentID
+
+			if input.ParentID != nil && *input.ParentID != before.ParentID {
+				if err := kind.checkParent(tx, *input.ParentID); err != nil {
+					return err
+				}
+				parentID = *input.ParentID
+				updates[kind.parentColumn] = parentID
+				if input.SortOrder == nil {
+					next, err := kind.nextSortOrder(tx, parentID)
+					if err != nil {
+						return err
+					}
+					updates["sort_order"] = next
+				}
+			}
+			if input.Name != nil {
+				name = strings.TrimSpace(*input.Name)
+				if name == "" {
+					return taxonomyFail(http.StatusBadRequest, "name cannot be empty")
+				}
+				updates[kind.nameColumn] = name
+			}
+			if input.SortOrder != nil {
+				updates["sort_order"] = *input.SortOrder
+			}
+			if len(updates) == 0 {
+				return taxonomyFail(http.StatusBadRequest, "Nothing to update")
+			}
+			if before.RetiredAt == nil && (input.Name != nil || input.ParentID != nil) {
+				if err := kind.checkName(tx, parentID, name, id); err != nil {
+					return err
+				}
+			}
+
+			if err := kind.update(tx, id, updates); err != nil {
+				return err
+			}
+			after, err := kind.load(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := writeTaxonomyAudit(tx, kind.kind, id, models.TaxonomyActionUpdate, before, after, userID); err != nil {
+				return err
+			}
+			updated, err = kind.reload(tx, id)
+			return err
+		})
+		if err != nil {
+			respondTaxonomyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+type TaxonomyReorderInput struct {
+	ParentID uint   `json:"parent_id"`
+	IDs      []uint `json:"ids" binding:"required"`
+}
+
+// ReorderTaxonomy จัดลำดับรายการในกลุ่มเดียวกันตาม ids ที่ส่งมา
+// รายการในกลุ่มที่ไม่ได้ระบุจะต่อท้ายตามลำดับเดิม รายการย่อยต้องระบุ parent_id
+func ReorderTaxonomy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, ok := taxonomyKindParam(c)
+		if !ok {
+			return
+		}
+		var input TaxonomyReorderInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if kind.parent == nil {
+			input.ParentID = 0
+		} else if input.ParentID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id is required"})
+			return
+		}
+		userID := c.MustGet("user_id").(uint)
+
+		var order []uint
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var rows []taxonomyRow
+			if err := kind.siblings(tx, input.ParentID).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Order("sort_order ASC, id ASC").Scan(&rows).Error; err != nil {
+				return err
+			}
+
+			before := make([]uint, 0, len(rows))
+			inScope := make(map[uint]bool, len(rows))
+			for _, row := range rows {
+				before = append(before, row.ID)
+				inScope[row.ID] = true
+			}
+
+			listed := make(map[uint]bool, len(input.IDs))
+			for _, id := range input.IDs {
+				if !inScope[id] {
+					return taxonomyFail(http.StatusBadRequest, "%s %d does not belong to this list", kind.label, id)
+				}
+				if listed[id] {
+					return taxonomyFail(http.StatusBadRequest, "%s %d is listed twice", kind.label, id)
+				}
+				listed[id] = true
+				order = append(order, id)
+			}
+			for _, id := range before {
+				if !listed[id] {
+					order = append(order, id)
+				}
+			}
+
+			for position, id := range order {
+				if err := kind.update(tx, id, map[string]interface{}{"sort_order": position}); err != nil {
+					return err
+				}
+			}
+			return writeTaxonomyAudit(tx, kind.kind, input.ParentID, models.TaxonomyActionReorder,
+				gin.H{"ids": before}, gin.H{"ids": order}, userID)
+		})
+		if err != nil {
+			respondTaxonomyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"parent_id": input.ParentID, "ids": order})
+	}
+}
+
+// mergeResult สรุปการรวมรายการ ใช้ทั้งเป็น response และบันทึกใน audit
+type mergeResult struct {
+	MergedIntoID      uint `json:"merged_into_id"`
+	LinksMoved        int  `json:"links_moved"`
+	StepsMoved        int  `json:"steps_moved,omitempty"`
+	VariantsMoved     int  `json:"variants_moved,omitempty"`
+	ObservationsMoved int  `json:"observations_moved,omitempty"`
+	PlanGoalsMoved    int  `json:"plan_goals_moved,omitempty"`
+	PlanGoalsMerged   int  `json:"plan_goals_merged,omitempty"`
+	ChildrenMoved     int  `json:"children_moved,omitempty"`
+	ChildrenMerged    int  `json:"children_merged,omitempty"`
+}
+
+// merge ย้ายทุกสิ่งที่อ้างถึง source ไปยัง target แล้วยกเลิก source โดยจำไว้ว่าถูกรวมเข้ากับ target
+func (k *taxonomyKind) merge(tx *gorm.DB, source, target *taxonomyRow, now time.Time, userID uint) (*mergeResult, error) {
+	result := &mergeResult{MergedIntoID: target.ID}
+
+	if k.child != nil {
+		// รายการย่อยที่ชื่อซ้ำกับรายการย่อยของปลายทางจะถูกรวมเข้าด้วยกัน ที่เหลือย้ายไปอยู่ใต้ปลายทาง
+		var children, targetChildren []taxonomyRow
+		if err := k.child.siblings(tx, source.ID).Order("sort_order ASC, id ASC").Scan(&children).Error; err != nil {
+			return nil, err
+		}
+		if err := k.child.siblings(tx, target.ID).Where("retired_at IS NULL").Scan(&targetChildren).Error; err != nil {
+			return nil, err
+		}
+		byName := make(map[string]*taxonomyRow, len(targetChildren))
+		for i := range targetChildren {
+			byName[strings.ToLower(strings.TrimSpace(targetChildren[i].Name))] = &targetChildren[i]
+		}
+
+		next, err := k.child.nextSortOrder(tx, target.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range children {
+			child := &children[i]
+			if match, ok := byName[strings.ToLower(strings.TrimSpace(child.Name))]; ok && child.RetiredAt == nil {
+				childResult, err := k.child.merge(tx, child, match, now, userID)
+				if err != nil {
+					return nil, err
+				}
+				result.LinksMoved += childResult.LinksMoved
+				result.StepsMoved += childResult.StepsMoved
+				result.VariantsMoved += childResult.VariantsMoved
+				result.ObservationsMoved += childResult.ObservationsMoved
+				result.PlanGoalsMoved += childResult.PlanGoalsMoved
+				result.PlanGoalsMerged += childResult.PlanGoalsMerged
+				result.ChildrenMerged++
+				continue
+			}
+			if err := k.child.update(tx, child.ID, map[string]interface{}{k.child.parentColumn: target.ID, "sort_order": next}); err != nil {
+				return nil, err
+			}
+			next++
+			result.ChildrenMoved++
+		}
+	} else {
+		// เพิ่มลิงก์ไปยังปลายทาง (ข้ามกิจกรรมที่เลือกปลายทางไว้แล้ว) แล้วลบลิงก์เดิม
+		if err := tx.Exec(fmt.Sprintf(
+			"INSERT INTO %[1]s (activity_id, %[2]s) SELECT activity_id, ? FROM %[1]s WHERE %[2]s = ? ON CONFLICT DO NOTHING",
+			k.linkTable, k.linkColumn,
+		), target.ID, source.ID).Error; err != nil {
+			return nil, err
+		}
+		res := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", k.linkTable, k.linkColumn), source.ID)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		result.LinksMoved = int(res.RowsAffected)
+
+		if k.stepColumn != "" {
+			res := tx.Table("activity_steps").Where(k.stepColumn+" = ?", source.ID).Update(k.stepColumn, target.ID)
+			if res.Error != nil {
+				return nil, res.Error
+			}
+			result.StepsMoved = int(res.RowsAffected)
+		}
+
+		if k.variantLinkTable != "" {
+			if err := tx.Exec(fmt.Sprintf(
+				"INSERT INTO %[1]s (activity_variant_id, %[2]s) SELECT activity_variant_id, ? FROM %[1]s WHERE %[2]s = ? ON CONFLICT DO NOTHING",
+				k.variantLinkTable, k.linkColumn,
+			), target.ID, source.ID).Error; err != nil {
+				return nil, err
+			}
+			res := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", k.variantLinkTable, k.linkColumn), source.ID)
+			if res.Error != nil {
+				return nil, res.Error
+			}
+			result.VariantsMoved = int(res.RowsAffected)
+		}
+
+		if k.observationColumn != "" {
+			res := tx.Table("observations").Where(k.observationColumn+" = ?", source.ID).Update(k.observationColumn, target.ID)
+			if res.Error != nil {
+				return nil, res.Error
+			}
+			result.ObservationsMoved = int(res.RowsAffected)
+		}
+
+		if k.planGoals {
+			moved, merged, err := mergePlanGoals(tx, source.ID, target.ID, userID)
+			if err != nil {
+				return nil, err
+			}
+			result.PlanGoalsMoved, result.PlanGoalsMerged = moved, merged
+		}
+	}
+
+	// รายการที่เคยรวมเข้ากับ source ให้ชี้ตรงไปยัง target
+	if err := tx.Table(k.table).Where("merged_into_id = ?", source.ID).Update("merged_into_id", target.ID).Error; err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{"merged_into_id": target.ID}
+	if source.RetiredAt == nil {
+		updates["retired_at"] = now
+	}
+	if err := k.update(tx, source.ID, updates); err != nil {
+		return nil, err
+	}
+
+	return result, writeTaxonomyAudit(tx, k.kind, source.ID, models.TaxonomyActionMerge, source, result, userID)
+}
+
+type TaxonomyMergeInput struct {
+	IntoID uint `json:"into_id" binding:"required"`
+}
+
+// MergeTaxonomyEntry รวมรายการ :id เข้ากับ into_id
+// กิจกรรมและขั้นตอนที่อ้างถึงรายการเดิมจะถูกย้ายไปยังปลายทาง ส่วนรายการเดิมถูกยกเลิกและจำว่าถูกรวมไปที่ใด
+// ผลการสังเกตและเป้าหมายในแผนการบำบัดของเป้าหมายย่อยเดิมถูกย้ายไปยังปลายทางด้วย (ดู mergePlanGoals)
+// การรวมรายการหลักจะย้ายรายการย่อยตามไปด้วย และรวมรายการย่อยที่ชื่อซ้ำกันเข้าด้วยกัน
+func MergeTaxonomyEntry(db *gorm.DB, relatedCache *related.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, ok := taxonomyKindParam(c)
+		if !ok {
+			return
+		}
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		var input TaxonomyMergeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.IntoID == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge an entry into itself"})
+			return
+		}
+		userID := c.MustGet("user_id").(uint)
+
+		var result *mergeResult
+		var target any
+		err := db.Transaction(func(tx *gorm.DB) error {
+			source, err := kind.load(tx, id)
+			if err != nil {
+				return err
+			}
+			if source.MergedIntoID != nil {
+				return taxonomyFail(http.StatusConflict, "%s %d was already merged into %d", kind.label, id, *source.MergedIntoID)
+			}
+			into, err := kind.load(tx, input.IntoID)
+			if err != nil {
+				return err
+			}
+			if into.RetiredAt != nil {
+				return taxonomyFail(http.StatusConflict, "%s %d is retired", kind.label, into.ID)
+			}
+
+			if result, err = kind.merge(tx, source, into, time.Now(), userID); err != nil {
+				return err
+			}
+			target, err = kind.reload(tx, into.ID)
+			return err
+		})
+		if err != nil {
+			respondTaxonomyError(c, err)
+			return
+		}
+
+		relatedCache.Reset()
+		c.JSON(http.StatusOK, gin.H{"result": result, "merged_into": target})
+	}
+}
+
+// RetireTaxonomyEntry ซ่อนรายการจากรายการให้เลือก โดยกิจกรรมเดิมที่อ้างถึงยังคงแสดงได้
+// การยกเลิกรายการหลักจะยกเลิกรายการย่อยที่ยังใช้งานอยู่ไปพร้อมกัน
+func RetireTaxonomyEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, ok := taxonomyKindParam(c)
+		if !ok {
+			return
+		}
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		userID := c.MustGet("user_id").(uint)
+
+		var retired any
+		err := db.Transaction(func(tx *gorm.DB) error {
+			before, err := kind.load(tx, id)
+			if err != nil {
+				return err
+			}
+			if before.RetiredAt != nil {
+				return taxonomyFail(http.StatusConflict, "%s %d is already retired", kind.label, id)
+			}
+
+			// ใช้เวลาเดียวกันกับรายการย่อย เพื่อให้ restore คืนเฉพาะรายการที่ถูกยกเลิกไปพร้อมกัน
+			now := time.Now().Truncate(time.Microsecond)
+			if err := kind.update(tx, id, map[string]interface{}{"retired_at": now}); err != nil {
+				return err
+			}
+			after := gin.H{"retired_at": now}
+			if kind.child != nil {
+				res := tx.Table(kind.child.table).
+					Where(kind.child.parentColumn+" = ? AND retired_at IS NULL", id).
+					Update("retired_at", now)
+				if res.Error != nil {
+					return res.Error
+				}
+				after["children_retired"] = res.RowsAffected
+			}
+
+			if err := writeTaxonomyAudit(tx, kind.kind, id, models.TaxonomyActionRetire, before, after, userID); err != nil {
+				return err
+			}
+			retired, err = kind.reload(tx, id)
+			return err
+		})
+		if err != nil {
+			respondTaxonomyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, retired)
+	}
+}
+
+// RestoreTaxonomyEntry นำรายการที่ถูกยกเลิกกลับมาใช้ (ยกเว้นรายการที่ถูกรวมไปแล้ว)
+// รายการย่อยที่ถูกยกเลิกพร้อมรายการหลักจะกลับมาด้วย
+func RestoreTaxonomyEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, ok := taxonomyKindParam(c)
+		if !ok {
+			return
+		}
+		id, ok := parseIDParam(c)
+		if !ok {
+			return
+		}
+		userID := c.MustGet("user_id").(uint)
+
+		var restored any
+		err := db.Transaction(func(tx *gorm.DB) error {
+			before, err := kind.load(tx, id)
+			if err != nil {
+				return err
+			}
+			if before.RetiredAt == nil {
+				return taxonomyFail(http.StatusConflict, "%s %d is not retired", kind.label, id)
+			}
+			if before.MergedIntoID != nil {
+				return taxonomyFail(http.StatusConflict, "%s %d was merged into %d and cannot be restored", kind.label, id, *before.MergedIntoID)
+			}
+			if kind.parent != nil {
+				if err := kind.checkParent(tx, before.ParentID); err != nil {
+					return err
+				}
+			}
+			if err := kind.checkName(tx, before.ParentID, before.Name, id); err != nil {
+				return err
+			}
+
+			if err := kind.update(tx, id, map[string]interface{}{"retired_at": nil}); err != nil {
+				return err
+			}
+			after := gin.H{"retired_at": nil}
+			if kind.child != nil {
+				res := tx.Table(kind.child.table).
+					Where(kind.child.parentColumn+" = ? AND retired_at = ? AND merged_into_id IS NULL", id, *before.RetiredAt).
+					Update("retired_at", nil)
+				if res.Error != nil {
+					return res.Error
+				}
+				after["children_restored"] = res.RowsAffected
+			}
+
+			if err := writeTaxonomyAudit(tx, kind.kind, id, models.TaxonomyActionRestore, before, after, userID); err != nil {
+				return err
+			}
+			restored, err = kind.reload(tx, id)
+			return err
+		})
+		if err != nil {
+			respondTaxonomyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+// ListTaxonomyAudit คืนประวัติการเปลี่ยนแปลง taxonomy ล่าสุด กรองด้วย ?kind= (goal, sub_goal, category, sub_category)
+// และ ?entity_id= ได้ ใช้ ?before_id= เพื่อดูหน้าถัดไป
+func ListTaxonomyAudit(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var logs []models.TaxonomyAuditLog
+
+		query := db.Order("id DESC").Limit(100)
+		if kind := c.Query("kind"); kind != "" {
+			query = query.Where("kind = ?", kind)
+		}
+		if entityID := c.Query("entity_id"); entityID != "" {
+			query = query.Where("entity_id = ?", entityID)
+		}
+		if beforeID := c.Query("before_id"); beforeID != "" {
+			query = query.Where("id < ?", beforeID)
+		}
+
+		if err := query.Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, logs)
+	}
+}
+
+// findSelectableSubGoals โหลดเป้าหมายย่อยตาม ids ที่ยังไม่ถูกยกเลิก หรือถูกยกเลิกแต่อยู่ใน current (กิจกรรมเลือกไว้อยู่เดิม)
+// คืน error ที่ระบุ id ที่ใช้ไม่ได้ แทนการข้ามไปเงียบ ๆ
+func findSelectableSubGoals(db *gorm.DB, ids, current []uint) ([]models.ActivitySubGoal, error) {
+	var rows []models.ActivitySubGoal
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	if err := db.Where("id IN ?", ids).Where("retired_at IS NULL OR id IN ?", current).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	found := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		found = append(found, r.ID)
+	}
+	return rows, unselectableIDs(ids, found)
+}
+
+// findSelectableSubCategories ทำงานเหมือน findSelectableSubGoals สำหรับหมวดหมู่ย่อย
+func findSelectableSubCategories(db *gorm.DB, ids, current []uint) ([]models.ActivitySubCategory, error) {
+	var rows []models.ActivitySubCategory
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	if err := db.Where("id IN ?", ids).Where("retired_at IS NULL OR id IN ?", current).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	found := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		found = append(found, r.ID)
+	}
+	return rows, unselectableIDs(ids, found)
+}
+
+func unselectableIDs(requested, found []uint) error {
+	ok := make(map[uint]bool, len(found))
+	for _, id := range found {
+		ok[id] = true
+	}
+	missing := make([]string, 0)
+	for _, id := range requested {
+		if !ok[id] {
+			missing = append(missing, strconv.FormatUint(uint64(id), 10))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown or retired ids: %s", strings.Join(missing, ", "))
+}
@@ -0,0 +1,768 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/progress"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PlanGoalInput คือเป้าหมายย่อยหนึ่งข้อในแผน คะแนนเป็น 0-100 แบบเดียวกับ /clients/:id/progress
+// ไม่ส่ง baseline_score จะใช้ค่าที่สังเกตได้ช่วงแรกของแผนตอน review แทน
+type PlanGoalInput struct {
+	SubGoalID       uint     `json:"sub_goal_id" binding:"required"`
+	BaselineScore   *float64 `json:"baseline_score" binding:"omitempty,gte=0,lte=100"`
+	TargetScore     *float64 `json:"target_score" binding:"required,gte=0,lte=100"`
+	TargetCriterion string   `json:"target_criterion" binding:"notblank,max=1000"`
+	ReviewDate      string   `json:"review_date" binding:"required,datetime=2006-01-02"`
+	Status          string   `json:"status" binding:"omitempty,oneof=active met discontinued"`
+}
+
+// TherapyPlanInput คือข้อมูลแผนทั้งหมด goals แทนที่เป้าหมายทั้งหมดตามลำดับที่ส่งมา
+// เป้าหมายที่ใช้เป้าหมายย่อยเดิมจะคง plan_goal_id เดิมไว้
+type TherapyPlanInput struct {
+	Title     string          `json:"title" binding:"notblank,max=200"`
+	StartDate string          `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string          `json:"end_date" binding:"required,datetime=2006-01-02"`
+	Status    string          `json:"status" binding:"omitempty,oneof=active met discontinued"`
+	Notes     string          `json:"notes" binding:"max=20000"`
+	Goals     []PlanGoalInput `json:"goals" binding:"required,min=1,max=30,dive"`
+}
+
+type TherapyPlanStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=active met discontinued"`
+}
+
+// errStalePlan คือกรณีที่แผนถูกแก้ไขไปแล้วระหว่างที่โหลดกับตอนเขียนจริง
+var errStalePlan = errors.New("therapy plan was modified by someone else")
+
+// apply ตรวจวันที่และเป้าหมายย่อยแล้วเขียนลงใน plan คืนเป้าหมายตามลำดับ (ยังไม่มี PlanID)
+// plan.Goals ต้องเป็นเป้าหมายเดิมของแผน (ว่างเมื่อสร้างใหม่) เพื่อให้คงเป้าหมายย่อยที่ถูกยกเลิกไปแล้วไว้ได้
+func (in *TherapyPlanInput) apply(db *gorm.DB, plan *models.TherapyPlan, fields fieldErrors) ([]models.PlanGoal, error) {
+	plan.Title = strings.TrimSpace(in.Title)
+	plan.Notes = in.Notes
+	if in.Status != "" {
+		plan.Status = in.Status
+	} else if plan.Status == "" {
+		plan.Status = models.PlanStatusActive
+	}
+
+	start, _ := time.Parse("2006-01-02", in.StartDate)
+	end, _ := time.Parse("2006-01-02", in.EndDate)
+	if end.Before(start) {
+		fields.add("end_date", "must not be before start_date")
+	}
+	plan.StartDate, plan.EndDate = start, end
+
+	ids := make([]uint, 0, len(in.Goals))
+	for _, g := range in.Goals {
+		ids = append(ids, g.SubGoalID)
+	}
+	var subGoals []models.ActivitySubGoal
+	if err := db.Where("id IN ?", uniqueIDs(ids)).Find(&subGoals).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[uint]models.ActivitySubGoal, len(subGoals))
+	for _, sg := range subGoals {
+		found[sg.ID] = sg
+	}
+	current := make(map[uint]bool, len(plan.Goals))
+	for _, g := range plan.Goals {
+		current[g.SubGoalID] = true
+	}
+
+	goals := make([]models.PlanGoal, 0, len(in.Goals))
+	seen := make(map[uint]bool, len(in.Goals))
+	for i, g := range in.Goals {
+		prefix := fmt.Sprintf("goals[%d].", i)
+		sg, ok := found[g.SubGoalID]
+		switch {
+		case seen[g.SubGoalID]:
+			fields.add(prefix+"sub_goal_id", "sub-goal is already in the plan")
+		case !ok:
+			fields.add(prefix+"sub_goal_id", "sub-goal not found")
+		case sg.RetiredAt != nil && !current[g.SubGoalID]:
+			fields.add(prefix+"sub_goal_id", "sub-goal has been retired")
+		}
+		seen[g.SubGoalID] = true
+
+		if g.BaselineScore != nil && *g.TargetScore <= *g.BaselineScore {
+			fields.add(prefix+"target_score", "must be higher than baseline_score")
+		}
+		review, _ := time.Parse("2006-01-02", g.ReviewDate)
+		if review.Before(start) || review.After(end) {
+			fields.add(prefix+"review_date", "must be between start_date and end_date")
+		}
+
+		status := g.Status
+		if status == "" {
+			status = models.PlanStatusActive
+		}
+		goals = append(goals, models.PlanGoal{
+			Position:        i + 1,
+			SubGoalID:       g.SubGoalID,
+			BaselineScore:   g.BaselineScore,
+			TargetScore:     *g.TargetScore,
+			TargetCriterion: strings.TrimSpace(g.TargetCriterion),
+			ReviewDate:      review,
+			Status:          status,
+		})
+	}
+	return goals, nil
+}
+
+// replacePlanGoals แทนที่เป้าหมายทั้งหมดของแผน เป้าหมายย่อยที่มีอยู่แล้วแก้ไขแถวเดิม ต้องเรียกภายใน Transaction
+func replacePlanGoals(tx *gorm.DB, planID uint, goals []models.PlanGoal) error {
+	var existing []models.PlanGoal
+	if err := tx.Where("plan_id = ?", planID).Find(&existing).Error; err != nil {
+		return err
+	}
+	bySubGoal := make(map[uint]models.PlanGoal, len(existing))
+	for _, g := range existing {
+		bySubGoal[g.SubGoalID] = g
+	}
+
+	keep := make([]uint, 0, len(goals))
+	for i := range goals {
+		goal := &goals[i]
+		goal.PlanID = planID
+		if old, ok := bySubGoal[goal.SubGoalID]; ok {
+			goal.ID, goal.CreatedAt = old.ID, old.CreatedAt
+			if err := tx.Model(goal).
+				Select("position", "baseline_score", "target_score", "target_criterion", "review_date", "status").
+				Updates(goal).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Omit(clause.Associations).Create(goal).Error; err != nil {
+			return err
+		}
+		keep = append(keep, goal.ID)
+	}
+
+	query := tx.Where("plan_id = ?", planID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	return query.Delete(&models.PlanGoal{}).Error
+}
+
+// savePlanVersion เก็บสำเนาของแผนตาม version ปัจจุบัน ต้องเรียกภายใน Transaction หลังบันทึกแผนแล้ว
+func savePlanVersion(tx *gorm.DB, planID, userID uint) error {
+	var plan models.TherapyPlan
+	if err := tx.Preload("Goals", orderedPlanGoals).First(&plan, planID).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.TherapyPlanVersion{
+		PlanID:      plan.ID,
+		Version:     plan.Version,
+		Snapshot:    plan.Snapshot(),
+		ChangedByID: userID,
+	}).Error
+}
+
+// mergePlanGoals ย้ายเป้าหมายในแผนจากเป้าหมายย่อย sourceID ไปยัง targetID เมื่อรวม taxonomy
+// แผนที่มี targetID อยู่แล้วจะลบเป้าหมายของ sourceID ออก เพราะแผนหนึ่งมีเป้าหมายย่อยซ้ำกันไม่ได้
+// (ค่าเดิมยังอยู่ในฉบับก่อนหน้า) แผนที่เปลี่ยนได้ version ใหม่ ต้องเรียกภายใน Transaction
+func mergePlanGoals(tx *gorm.DB, sourceID, targetID, userID uint) (moved, merged int, err error) {
+	var planIDs []uint
+	if err = tx.Model(&models.TherapyPlan{}).
+		Where("id IN (?)", tx.Model(&models.PlanGoal{}).Select("plan_id").Where("sub_goal_id = ?", sourceID)).
+		Pluck("id", &planIDs).Error; err != nil {
+		return 0, 0, err
+	}
+
+	withTarget := tx.Model(&models.PlanGoal{}).Select("plan_id").Where("sub_goal_id = ?", targetID)
+	res := tx.Where("sub_goal_id = ? AND plan_id IN (?)", sourceID, withTarget).Delete(&models.PlanGoal{})
+	if res.Error != nil {
+		return 0, 0, res.Error
+	}
+	merged = int(res.RowsAffected)
+
+	res = tx.Model(&models.PlanGoal{}).Where("sub_goal_id = ?", sourceID).Update("sub_goal_id", targetID)
+	if res.Error != nil {
+		return 0, 0, res.Error
+	}
+	moved = int(res.RowsAffected)
+
+	for _, planID := range planIDs {
+		if err = tx.Model(&models.TherapyPlan{}).Where("id = ?", planID).Updates(map[string]interface{}{
+			"version":       gorm.Expr("version + 1"),
+			"updated_by_id": userID,
+		}).Error; err != nil {
+			return 0, 0, err
+		}
+		if err = savePlanVersion(tx, planID, userID); err != nil {
+			return 0, 0, err
+		}
+	}
+	return moved, merged, nil
+}
+
+// updatePlan บันทึกค่าใน updates เมื่อ version ในฐานข้อมูลยังเท่ากับ plan.Version แล้วเพิ่ม version
+// goals เป็น nil เมื่อไม่ได้แก้เป้าหมาย
+func updatePlan(db *gorm.DB, plan *models.TherapyPlan, userID uint, updates map[string]interface{}, goals []models.PlanGoal) error {
+	updates["version"] = plan.Version + 1
+	updates["updated_by_id"] = userID
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(plan).Where("version = ?", plan.Version).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errStalePlan
+		}
+		if goals != nil {
+			if err := replacePlanGoals(tx, plan.ID, goals); err != nil {
+				return err
+			}
+		}
+		return savePlanVersion(tx, plan.ID, userID)
+	})
+}
+
+// checkPlanIfMatch ตรวจ header If-Match (ถ้าส่งมา) กับ version ของแผน ไม่ตรงตอบ 412 แล้วคืน false
+func checkPlanIfMatch(c *gin.Context, plan *models.TherapyPlan) bool {
+	header := c.GetHeader("If-Match")
+	if strings.TrimSpace(header) == "" {
+		return true
+	}
+	version, ok := parseIfMatch(header, plan.Version)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf(`If-Match must be a plan version such as "%d"`, plan.Version)})
+		return false
+	}
+	if version != plan.Version {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error":           "therapy plan has been modified since it was loaded",
+			"current_version": plan.Version,
+		})
+		return false
+	}
+	return true
+}
+
+// respondPlanUpdateError ตอบข้อผิดพลาดจาก updatePlan
+func respondPlanUpdateError(c *gin.Context, err error) {
+	if errors.Is(err, errStalePlan) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func orderedPlanGoals(db *gorm.DB) *gorm.DB {
+	return db.Order("plan_goals.position ASC")
+}
+
+// localizePlans แทนชื่อเป้าหมายย่อยที่ preload มาด้วยคำแปลตามภาษาของ request
+func localizePlans(c *gin.Context, db *gorm.DB, plans []models.TherapyPlan) error {
+	batch := i18n.NewBatch(c.GetString("locale"))
+	for i := range plans {
+		for j := range plans[i].Goals {
+			if sg := plans[i].Goals[j].SubGoal; sg != nil {
+				batch.SubGoal(sg)
+			}
+		}
+	}
+	return batch.Apply(db)
+}
+
+// ListClientPlans คืนแผนการบำบัดของผู้รับบริการ เรียงจากแผนที่เริ่มล่าสุด ?status= กรองตามสถานะ
+func ListClientPlans(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet("client").(*models.Client)
+		query := db.Where("client_id = ?", client.ID).
+			Preload("Goals", orderedPlanGoals).
+			Preload("Goals.SubGoal")
+		if status := c.Query("status"); status != "" {
+			if !models.ValidPlanStatus(status) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, met or discontinued"})
+				return
+			}
+			query = query.Where("status = ?", status)
+		}
+
+		var plans []models.TherapyPlan
+		if err := query.Order("start_date DESC, id DESC").Find(&plans).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := localizePlans(c, db, plans); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, plans)
+	}
+}
+
+// CreateClientPlan สร้างแผนการบำบัดให้ผู้รับบริการ (ฉบับที่ 1)
+func CreateClientPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		client := c.MustGet("client").(*models.Client)
+
+		var input TherapyPlanInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		plan := models.TherapyPlan{ClientID: client.ID, Version: 1, CreatedByID: userID, UpdatedByID: userID}
+		fields := fieldErrors{}
+		goals, err := input.apply(db, &plan, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit(clause.Associations).Create(&plan).Error; err != nil {
+				return err
+			}
+			if err := replacePlanGoals(tx, plan.ID, goals); err != nil {
+				return err
+			}
+			return savePlanVersion(tx, plan.ID, userID)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		respondPlan(c, db, http.StatusCreated, plan.ID)
+	}
+}
+
+// GetPlan คืนแผนที่ middleware.TherapyPlanAccess ตรวจสิทธิ์แล้ว
+func GetPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		respondPlan(c, db, http.StatusOK, plan.ID)
+	}
+}
+
+// UpdatePlan แก้ไขแผนทั้งหมดรวมเป้าหมาย แล้วเก็บเป็นฉบับใหม่
+// ส่ง If-Match เป็น version ที่โหลดมาได้ เพื่อไม่ให้เขียนทับการแก้ไขของผู้อื่น (ไม่ตรงตอบ 412)
+func UpdatePlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		if !checkPlanIfMatch(c, plan) {
+			return
+		}
+
+		var input TherapyPlanInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if err := db.Where("plan_id = ?", plan.ID).Find(&plan.Goals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		fields := fieldErrors{}
+		goals, err := input.apply(db, plan, fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(fields) > 0 {
+			respondValidation(c, fields)
+			return
+		}
+
+		err = updatePlan(db, plan, userID, map[string]interface{}{
+			"title":      plan.Title,
+			"start_date": plan.StartDate,
+			"end_date":   plan.EndDate,
+			"status":     plan.Status,
+			"notes":      plan.Notes,
+		}, goals)
+		if err != nil {
+			respondPlanUpdateError(c, err)
+			return
+		}
+		respondPlan(c, db, http.StatusOK, plan.ID)
+	}
+}
+
+// UpdatePlanStatus เปลี่ยนสถานะของแผน (active, met, discontinued) แล้วเก็บเป็นฉบับใหม่
+func UpdatePlanStatus(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uint)
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		if !checkPlanIfMatch(c, plan) {
+			return
+		}
+
+		var input TherapyPlanStatusInput
+		if !bindValidJSON(c, &input) {
+			return
+		}
+		if input.Status == plan.Status {
+			respondPlan(c, db, http.StatusOK, plan.ID)
+			return
+		}
+		if err := updatePlan(db, plan, userID, map[string]interface{}{"status": input.Status}, nil); err != nil {
+			respondPlanUpdateError(c, err)
+			return
+		}
+		respondPlan(c, db, http.StatusOK, plan.ID)
+	}
+}
+
+// DeletePlan ลบแผนแบบ soft delete (ประวัติฉบับต่าง ๆ ยังอยู่ในฐานข้อมูล)
+func DeletePlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		if err := db.Delete(plan).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Therapy plan deleted successfully"})
+	}
+}
+
+// ListPlanVersions คืนทุกฉบับของแผน เรียงจากฉบับล่าสุด
+func ListPlanVersions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		var versions []models.TherapyPlanVersion
+		if err := db.Where("plan_id = ?", plan.ID).Order("version DESC").Find(&versions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, versions)
+	}
+}
+
+// GetPlanVersion คืนแผนฉบับที่ :version
+func GetPlanVersion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		number, err := strconv.Atoi(c.Param("version"))
+		if err != nil || number < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan version"})
+			return
+		}
+		var version models.TherapyPlanVersion
+		if err := db.Where("plan_id = ? AND version = ?", plan.ID, number).First(&version).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plan version not found"})
+			return
+		}
+		c.JSON(http.StatusOK, version)
+	}
+}
+
+func respondPlan(c *gin.Context, db *gorm.DB, status int, id uint) {
+	var plan models.TherapyPlan
+	if err := db.Preload("Goals", orderedPlanGoals).Preload("Goals.SubGoal").First(&plan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Therapy plan not found"})
+		return
+	}
+	plans := []models.TherapyPlan{plan}
+	if err := localizePlans(c, db, plans); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, plans[0])
+}
+
+// planActivitySuggestion คือกิจกรรมที่เชื่อมกับเป้าหมายย่อยของแผน พร้อมจำนวนครั้งที่ผู้รับบริการเคยทำ
+type planActivitySuggestion struct {
+	SubGoalID       uint       `json:"-"`
+	ActivityID      uint       `json:"activity_id"`
+	Title           string     `json:"title"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	Difficulty      *int       `json:"difficulty"`
+	RatingAverage   float64    `json:"rating_average"`
+	RatingCount     int        `json:"rating_count"`
+	TimesUsed       int        `json:"times_used"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+}
+
+// ageOn คืนอายุเต็มปี ณ วันที่ on
+func ageOn(dob, on time.Time) int {
+	age := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// GetPlanSuggestions แนะนำกิจกรรมที่เผยแพร่แล้วและเลือกเป้าหมายย่อยของแต่ละเป้าหมายที่ยัง active ในแผน
+// กรองตามช่วงอายุของกิจกรรมเมื่อทราบวันเกิดของผู้รับบริการ เรียงตามคะแนนรีวิว
+// แล้วให้กิจกรรมที่ผู้รับบริการทำน้อยครั้งกว่าขึ้นก่อน ?limit= จำนวนต่อเป้าหมาย (ค่าเริ่มต้น 5 สูงสุด 20)
+func GetPlanSuggestions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+		if err != nil || limit < 1 || limit > 20 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 20"})
+			return
+		}
+
+		var goals []models.PlanGoal
+		if err := db.Where("plan_id = ? AND status = ?", plan.ID, models.PlanStatusActive).
+			Scopes(orderedPlanGoals).Preload("SubGoal").Find(&goals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var client models.Client
+		if err := db.First(&client, plan.ClientID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Client not found"})
+			return
+		}
+
+		subGoalIDs := make([]uint, 0, len(goals))
+		for _, g := range goals {
+			subGoalIDs = append(subGoalIDs, g.SubGoalID)
+		}
+
+		var suggestions []planActivitySuggestion
+		if len(subGoalIDs) > 0 {
+			// จำนวนครั้งที่กิจกรรมอยู่ใน session ของผู้รับบริการ ทั้ง session รายคนและของกลุ่มที่เป็นสมาชิก
+			used := db.Table("session_items AS si").
+				Select("si.activity_id, COUNT(*) AS times_used, MAX(s.starts_at) AS last_used_at").
+				Joins("JOIN sessions s ON s.id = si.session_id").
+				Where("s.status <> ?", models.SessionStatusCancelled).
+				Where("s.client_id = ? OR s.client_group_id IN (SELECT client_group_id FROM client_group_members WHERE client_id = ?)", client.ID, client.ID).
+				Group("si.activity_id")
+
+			query := db.Table("activity_selected_sub_goals AS link").
+				Select(`link.activity_sub_goal_id AS sub_goal_id, activities.id AS activity_id, activities.title,
+					activities.duration_minutes, activities.difficulty, activities.rating_average, activities.rating_count,
+					COALESCE(used.times_used, 0) AS times_used, used.last_used_at`).
+				Joins("JOIN activities ON activities.id = link.activity_id").
+				Joins("LEFT JOIN (?) AS used ON used.activity_id = activities.id", used).
+				Where("link.activity_sub_goal_id IN ?", subGoalIDs).
+				Where("activities.status = ?", models.ActivityStatusPublished)
+			if client.DateOfBirth != nil {
+				age := ageOn(*client.DateOfBirth, time.Now())
+				query = containsFilter(query, "activities.age_min", "activities.age_max", &age)
+			}
+			if err := query.Order("activities.rating_average DESC, times_used ASC, activities.title ASC").
+				Scan(&suggestions).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		batch := i18n.NewBatch(c.GetString("locale"))
+		bySubGoal := map[uint][]*planActivitySuggestion{}
+		for i := range suggestions {
+			s := &suggestions[i]
+			if len(bySubGoal[s.SubGoalID]) == limit {
+				continue
+			}
+			bySubGoal[s.SubGoalID] = append(bySubGoal[s.SubGoalID], s)
+			batch.Add(i18n.EntityActivity, s.ActivityID, "title", &s.Title)
+		}
+		for i := range goals {
+			if goals[i].SubGoal != nil {
+				batch.SubGoal(goals[i].SubGoal)
+			}
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		result := make([]gin.H, 0, len(goals))
+		for _, g := range goals {
+			activities := bySubGoal[g.SubGoalID]
+			if activities == nil {
+				activities = []*planActivitySuggestion{}
+			}
+			result = append(result, gin.H{
+				"plan_goal_id": g.ID,
+				"sub_goal_id":  g.SubGoalID,
+				"sub_goal":     g.SubGoal,
+				"activities":   activities,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"plan_id": plan.ID, "goals": result})
+	}
+}
+
+// planGoalReview คือผลการทบทวนเป้าหมายหนึ่งข้อ คะแนนเป็น 0-100
+// BaselineSource บอกว่า baseline มาจากแผน (plan) จากผลการสังเกตช่วงแรกของแผน (observed) หรือยังไม่มี (none)
+// ProgressPercent คือระยะที่เดินมาแล้วจาก baseline ถึง target (100 ขึ้นไปคือถึงเป้าแล้ว)
+type planGoalReview struct {
+	PlanGoalID      uint                    `json:"plan_goal_id"`
+	SubGoalID       uint                    `json:"sub_goal_id"`
+	SubGoal         *models.ActivitySubGoal `json:"sub_goal,omitempty"`
+	Status          string                  `json:"status"`
+	TargetCriterion string                  `json:"target_criterion"`
+	ReviewDate      time.Time               `json:"review_date"`
+	ReviewDue       bool                    `json:"review_due"`
+	Baseline        *float64                `json:"baseline"`
+	BaselineSource  string                  `json:"baseline_source"`
+	Target          float64                 `json:"target"`
+	Latest          *float64                `json:"latest"`
+	LatestBucket    string                  `json:"latest_bucket,omitempty"`
+	ProgressPercent *float64                `json:"progress_percent"`
+	TargetReached   bool                    `json:"target_reached"`
+	Observations    int                     `json:"observations"`
+	Trend           progress.Trend          `json:"trend"`
+	Data            []*float64              `json:"data"`
+}
+
+// GetPlanReview เทียบผลการสังเกตของผู้รับบริการตั้งแต่วันเริ่มแผนกับ baseline และ target ของแต่ละเป้าหมาย
+//
+//	?bucket=day|week|month   ขนาดช่วงเวลาของกราฟ (ค่าเริ่มต้น week)
+//	?tz=                     เขตเวลาของวันที่ในแผน (ค่าเริ่มต้น UTC)
+//
+// ช่วงเวลาคือ start_date ถึง end_date ของแผนหรือวันนี้ (แล้วแต่อย่างใดถึงก่อน)
+// ค่าล่าสุดคือคะแนนเฉลี่ยของช่วงเวลาล่าสุดที่มีผลการสังเกต
+func GetPlanReview(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := c.MustGet("therapy_plan").(*models.TherapyPlan)
+
+		bucket := c.DefaultQuery("bucket", progress.BucketWeek)
+		if !progress.ValidBucket(bucket) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be day, week or month"})
+			return
+		}
+		loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tz must be an IANA time zone such as Asia/Bangkok"})
+			return
+		}
+
+		now := time.Now().In(loc)
+		from := dateIn(plan.StartDate, loc)
+		to := dateIn(plan.EndDate, loc).AddDate(0, 0, 1)
+		if now.Before(to) {
+			to = now
+		}
+
+		var labels []string
+		var series []*progress.Series
+		if to.After(from) {
+			if labels, err = progress.Labels(from, to, bucket); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			rows, err := progress.Aggregate(db, progress.Query{
+				ClientID: plan.ClientID,
+				Level:    progress.LevelSubGoal,
+				Bucket:   bucket,
+				From:     from,
+				To:       to,
+				Location: loc,
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			series = progress.Build(rows, labels, 1)
+		}
+		bySubGoal := make(map[uint]*progress.Series, len(series))
+		for _, s := range series {
+			bySubGoal[s.ID] = s
+		}
+
+		var goals []models.PlanGoal
+		if err := db.Where("plan_id = ?", plan.ID).Scopes(orderedPlanGoals).Preload("SubGoal").Find(&goals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		batch := i18n.NewBatch(c.GetString("locale"))
+		for i := range goals {
+			if goals[i].SubGoal != nil {
+				batch.SubGoal(goals[i].SubGoal)
+			}
+		}
+		if err := batch.Apply(db); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		reviews := make([]planGoalReview, 0, len(goals))
+		var reached, due int
+		for _, g := range goals {
+			r := reviewPlanGoal(g, bySubGoal[g.SubGoalID], len(labels))
+			r.ReviewDue = g.Status == models.PlanStatusActive && !dateIn(g.ReviewDate, loc).After(today)
+			if r.TargetReached {
+				reached++
+			}
+			if r.ReviewDue {
+				due++
+			}
+			reviews = append(reviews, r)
+		}
+
+		if labels == nil {
+			labels = []string{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"plan_id":  plan.ID,
+			"version":  plan.Version,
+			"status":   plan.Status,
+			"bucket":   bucket,
+			"from":     from,
+			"to":       to,
+			"timezone": loc.String(),
+			"labels":   labels,
+			"goals":    reviews,
+			"summary": gin.H{
+				"goals":           len(goals),
+				"targets_reached": reached,
+				"reviews_due":     due,
+			},
+		})
+	}
+}
+
+// reviewPlanGoal เทียบเส้นคะแนนของเป้าหมายย่อย (nil เมื่อไม่มีผลการสังเกต) กับ baseline และ target ของแผน
+func reviewPlanGoal(g models.PlanGoal, s *progress.Series, buckets int) planGoalReview {
+	r := planGoalReview{
+		PlanGoalID:      g.ID,
+		SubGoalID:       g.SubGoalID,
+		SubGoal:         g.SubGoal,
+		Status:          g.Status,
+		TargetCriterion: g.TargetCriterion,
+		ReviewDate:      g.ReviewDate,
+		Baseline:        g.BaselineScore,
+		BaselineSource:  "plan",
+		Target:          g.TargetScore,
+		Trend:           progress.Trend{Direction: progress.TrendInsufficient},
+		Data:            make([]*float64, buckets),
+	}
+	if s != nil {
+		r.Observations, r.Trend, r.Data = s.Observations, s.Trend, s.Data
+		last := s.Points[len(s.Points)-1]
+		latest := last.AverageScore
+		r.Latest, r.LatestBucket = &latest, last.Bucket
+		r.TargetReached = latest >= g.TargetScore
+		if r.Baseline == nil {
+			r.Baseline, r.BaselineSource = s.Comparison.Baseline, "observed"
+		}
+	}
+	if r.Baseline == nil {
+		r.BaselineSource = "none"
+	}
+	if r.Baseline != nil && r.Latest != nil && g.TargetScore > *r.Baseline {
+		pct := progress.Round((*r.Latest - *r.Baseline) * 100 / (g.TargetScore - *r.Baseline))
+		r.ProgressPercent = &pct
+	}
+	return r
+}
+
+// dateIn คืนเที่ยงคืนของวันที่ของคอลัมน์ date ในเขตเวลา loc
+func dateIn(d time.Time, loc *time.Location) time.Time {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, loc)
+}
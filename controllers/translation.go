@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-backend/config"
+	"project-backend/i18n"
+	"project-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// localizeActivities แทนข้อความของกิจกรรม (รวมขั้นตอนและ taxonomy ที่ preload มา) ด้วยคำแปลตามภาษาของ request
+func localizeActivities(c *gin.Context, db *gorm.DB, activities []models.Activity) error {
+	batch := i18n.NewBatch(c.GetString("locale"))
+	for i := range activities {
+		batch.Activity(&activities[i])
+	}
+	return batch.Apply(db)
+}
+
+// deleteActivityTranslations ลบคำแปลของกิจกรรมและขั้นตอนทั้งหมด ต้องเรียกก่อนลบขั้นตอน
+// (คำแปลของรูปแบบกิจกรรมลบใน deleteActivityVariants)
+func deleteActivityTranslations(tx *gorm.DB, activityID uint) error {
+	steps := tx.Model(&models.ActivityStep{}).Select("id").Where("activity_id = ?", activityID)
+	return tx.Where("(entity_type = ? AND entity_id = ?) OR (entity_type = ? AND entity_id IN (?))",
+		i18n.EntityActivity, activityID, i18n.EntityStep, steps).
+		Delete(&models.Translation{}).Error
+}
+
+// translationLocale ตรวจและจัดรูปแบบภาษาที่ admin ระบุ ต้องเป็นภาษาที่รองรับและไม่ใช่ภาษาต้นฉบับ
+func translationLocale(raw string, cfg *config.LocaleConfig) (string, error) {
+	locale := i18n.Normalize(raw)
+	if locale == "" {
+		return "", fmt.Errorf("invalid locale %q", raw)
+	}
+	if i18n.Language(locale) == i18n.BaseLocale {
+		return "", fmt.Errorf("%s is the source language; edit the original text instead", i18n.BaseLocale)
+	}
+	if !i18n.IsSupported(locale, cfg.Supported) {
+		return "", fmt.Errorf("locale %s is not supported (supported: %s)", locale, strings.Join(cfg.Supported, ", "))
+	}
+	return locale, nil
+}
+
+// ListTranslations คืนคำแปลที่บันทึกไว้ กรองด้วย ?entity_type=, ?entity_id= และ ?locale= ได้
+func ListTranslations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rows []models.Translation
+
+		query := db.Order("entity_type ASC, entity_id ASC, field ASC, locale ASC").Limit(1000)
+		if entityType := c.Query("entity_type"); entityType != "" {
+			query = query.Where("entity_type = ?", entityType)
+		}
+		if entityID := c.Query("entity_id"); entityID != "" {
+			query = query.Where("entity_id = ?", entityID)
+		}
+		if locale := c.Query("locale"); locale != "" {
+			query = query.Where("locale = ?", i18n.Normalize(locale))
+		}
+
+		if err := query.Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, rows)
+	}
+}
+
+type TranslationInput struct {
+	Fields map[string]string `json:"fields" binding:"required"`
+}
+
+// UpsertTranslations บันทึกคำแปลของข้อมูลหนึ่งรายการในภาษาเดียว
+// PUT /admin/translations/:entity_type/:entity_id/:locale {"fields": {"title": "..."}}
+// ส่งค่าว่างเพื่อลบคำแปลของฟิลด์นั้น (กลับไปใช้ภาษา fallback)
+func UpsertTranslations(db *gorm.DB, cfg *config.LocaleConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entity, ok := i18n.Lookup(c.Param("entity_type"))
+		if !ok {
+			types := make([]string, 0, len(i18n.Entities))
+			for _, e := range i18n.Entities {
+				types = append(types, e.Type)
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown entity type; use one of " + strings.Join(types, ", ")})
+			return
+		}
+		entityID, err := strconv.ParseUint(c.Param("entity_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+			return
+		}
+		locale, err := translationLocale(c.Param("locale"), cfg)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var input TranslationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for field := range input.Fields {
+			if !entity.HasField(field) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s has no translatable field %q (fields: %s)", entity.Type, field, strings.Join(entity.Fields, ", "))})
+				return
+			}
+		}
+
+		var count int64
+		if err := db.Table(entity.Table).Where("id = ?", entityID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s %d not found", entity.Type, entityID)})
+			return
+		}
+
+		userID := c.MustGet("user_id").(uint)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for field, value := range input.Fields {
+				value = strings.TrimSpace(value)
+				key := tx.Where("entity_type = ? AND entity_id = ? AND field = ? AND locale = ?", entity.Type, entityID, field, locale)
+				if value == "" {
+					if err := key.Delete(&models.Translation{}).Error; err != nil {
+						return err
+					}
+					continue
+				}
+
+				row := models.Translation{
+					EntityType:  entity.Type,
+					EntityID:    uint(entityID),
+					Field:       field,
+					Locale:      locale,
+					Value:       value,
+					UpdatedByID: userID,
+				}
+				if err := tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "field"}, {Name: "locale"}},
+					DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by_id", "updated_at"}),
+				}).Create(&row).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var rows []models.Translation
+		if err := db.Where("entity_type = ? AND entity_id = ? AND locale = ?", entity.Type, entityID, locale).
+			Order("field ASC").Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, rows)
+	}
+}
+
+type fieldCompleteness struct {
+	EntityType string  `json:"entity_type"`
+	Field      string  `json:"field"`
+	Total      int64   `json:"total"`
+	Translated int64   `json:"translated"`
+	Percent    float64 `json:"percent"`
+}
+
+type activityCompleteness struct {
+	ActivityID    uint     `json:"activity_id"`
+	Title         string   `json:"title"`
+	MissingFields []string `json:"missing_fields,omitempty"`
+	MissingSteps  int      `json:"missing_steps,omitempty"`
+
+	MissingVariants int `json:"missing_variants,omitempty"`
+}
+
+func percent(done, total int64) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(done)*1000/float64(total)) / 10
+}
+
+// TranslationReport รายงานความครบถ้วนของคำแปลในภาษา ?locale= (ค่าเริ่มต้นคือภาษาแรกที่ไม่ใช่ภาษาไทย)
+// นับเฉพาะฟิลด์ที่ข้อความต้นฉบับไม่ว่าง และ taxonomy ที่ยังไม่ถูกยกเลิก
+// คำแปลของภาษาหลัก (เช่น en สำหรับ en-GB) นับว่าครบเพราะถูกใช้เป็น fallback
+// ?limit= จำกัดจำนวนกิจกรรมที่ยังแปลไม่ครบในรายการ (ค่าเริ่มต้น 50)
+func TranslationReport(db *gorm.DB, cfg *config.LocaleConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Query("locale")
+		if raw == "" {
+			for _, l := range cfg.Supported {
+				if i18n.Language(i18n.Normalize(l)) != i18n.BaseLocale {
+					raw = l
+					break
+				}
+			}
+		}
+		locale, err := translationLocale(raw, cfg)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a non-negative number"})
+			return
+		}
+		chain := i18n.Chain(locale)
+
+		fields := []fieldCompleteness{}
+		var total, translated int64
+		for _, entity := range i18n.Entities {
+			for _, field := range entity.Fields {
+				query := db.Table(entity.Table+" AS e").
+					Select("COUNT(DISTINCT e.id) AS total, COUNT(DISTINCT CASE WHEN t.id IS NOT NULL THEN e.id END) AS translated").
+					Joins("LEFT JOIN translations t ON t.entity_type = ? AND t.entity_id = e.id AND t.field = ? AND t.locale IN ? AND t.value <> ''",
+						entity.Type, field, chain).
+					Where(fmt.Sprintf("COALESCE(TRIM(e.%s), '') <> ''", field))
+				if entity.Retirable {
+					query = query.Where("e.retired_at IS NULL")
+				}
+
+				row := fieldCompleteness{EntityType: entity.Type, Field: field}
+				if err := query.Scan(&row).Error; err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				row.EntityType, row.Field = entity.Type, field
+				row.Percent = percent(row.Translated, row.Total)
+				fields = append(fields, row)
+				total += row.Total
+				translated += row.Translated
+			}
+		}
+
+		incomplete, err := incompleteActivities(db, chain, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"locale":                locale,
+			"total":                 total,
+			"translated":            translated,
+			"percent":               percent(translated, total),
+			"fields":                fields,
+			"incomplete_activities": incomplete,
+		})
+	}
+}
+
+// incompleteActivities คืนกิจกรรมที่ยังมีฟิลด์ ขั้นตอน หรือรูปแบบที่ไม่มีคำแปล เรียงจากกิจกรรมล่าสุด
+func incompleteActivities(db *gorm.DB, chain []string, limit int) ([]activityCompleteness, error) {
+	var activities []models.Activity
+	if err := db.Preload("Steps").Preload("Variants").Order("id DESC").Find(&activities).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []models.Translation
+	if err := db.Select("entity_type", "entity_id", "field").
+		Where("entity_type IN ? AND locale IN ? AND value <> ''", []string{i18n.EntityActivity, i18n.EntityStep, i18n.EntityVariant}, chain).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		done[fmt.Sprintf("%s:%d:%s", row.EntityType, row.EntityID, row.Field)] = true
+	}
+	missing := func(entityType string, id uint, field, source string) bool {
+		return strings.TrimSpace(source) != "" && !done[fmt.Sprintf("%s:%d:%s", entityType, id, field)]
+	}
+
+	out := []activityCompleteness{}
+	for _, a := range activities {
+		if len(out) >= limit {
+			break
+		}
+		item := activityCompleteness{ActivityID: a.ID, Title: a.Title}
+		sources := map[string]string{
+			"title":               a.Title,
+			"goal_description":    a.GoalDescription,
+			"equipment":           a.Equipment,
+			"process":             a.Process,
+			"observable_behavior": a.ObservableBehavior,
+			"suggestion":          a.Suggestion,
+			"song":                a.Song,
+			"contraindications":   a.Contraindications,
+			"safety_notes":        a.SafetyNotes,
+		}
+		entity, _ := i18n.Lookup(i18n.EntityActivity)
+		for _, field := range entity.Fields {
+			if missing(i18n.EntityActivity, a.ID, field, sources[field]) {
+				item.MissingFields = append(item.MissingFields, field)
+			}
+		}
+		for _, step := range a.Steps {
+			if missing(i18n.EntityStep, step.ID, "instruction", step.Instruction) ||
+				missing(i18n.EntityStep, step.ID, "facilitator_cue", step.FacilitatorCue) {
+				item.MissingSteps++
+			}
+		}
+		for _, v := range a.Variants {
+			if missing(i18n.EntityVariant, v.ID, "label", v.Label) ||
+				missing(i18n.EntityVariant, v.ID, "process", v.Process) ||
+				missing(i18n.EntityVariant, v.ID, "equipment", v.Equipment) ||
+				missing(i18n.EntityVariant, v.ID, "song", v.Song) {
+				item.MissingVariants++
+			}
+		}
+		if len(item.MissingFields) > 0 || item.MissingSteps > 0 || item.MissingVariants > 0 {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
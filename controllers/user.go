@@ -69,6 +69,7 @@ func GetProfile(db *gorm.DB) gin.HandlerFunc {
 
 		if err := db.
 			Preload("Role").
+			Preload("ProfileMedia.Variants").
 			Omit("password", "deleted_at").
 			First(&user, userID).Error; err != nil {
 
@@ -90,6 +91,8 @@ func GetProfile(db *gorm.DB) gin.HandlerFunc {
 			DateOfBirth string `json:"date_of_birth"`
 			RoleName    string `json:"role_name"`
 			Profile     string `json:"profile"`
+
+			ProfileMedia *models.Media `json:"profile_media"`
 		}
 
 		roleName := ""
@@ -106,6 +109,11 @@ func GetProfile(db *gorm.DB) gin.HandlerFunc {
 			Profile:     user.Profile,
 			DateOfBirth: user.DateOfBirth.Format("2006-01-02"),
 			RoleName:    roleName,
+
+			ProfileMedia: user.ProfileMedia,
+		}
+		if user.ProfileMedia != nil {
+			response.Profile = user.ProfileMedia.URL
 		}
 
 		c.JSON(http.StatusOK, response)
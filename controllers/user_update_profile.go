@@ -2,9 +2,9 @@ package controllers
 
 import (
 	"net/http"
-	"os"
 
 	"project-backend/models"
+	"project-backend/storage"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -15,12 +15,17 @@ type UpdateProfileRequest struct {
 	FirstName   string `json:"first_name"`
 	LastName    string `json:"last_name"`
 	PhoneNumber string `json:"phone_number"`
-	Profile     string `json:"profile"`
 	Password    string `json:"password"`
 	OldPassword string `json:"old_password"`
+
+	// รูปโปรไฟล์ต้องอัปโหลดผ่าน POST /api/media (purpose=profile) แล้วส่ง media_id มา
+	ProfileMediaID *uint `json:"profile_media_id"`
+
+	// Deprecated: client รุ่นเก่าส่ง path/URL ของรูปมาตรงๆ ไม่รับแล้วเพราะตรวจไม่ได้ว่าเป็นไฟล์ของผู้ใช้ ตอบ 400 ให้ใช้ profile_media_id
+	Profile *string `json:"profile"`
 }
 
-func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
+func UpdateProfile(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.MustGet("user_id").(uint)
 		var req UpdateProfileRequest
@@ -29,6 +34,10 @@ func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบข้อมูลไม่ถูกต้อง"})
 			return
 		}
+		if req.Profile != nil && *req.Profile != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่รองรับฟิลด์ profile แล้ว กรุณาอัปโหลดรูปผ่าน POST /api/media (purpose=profile) แล้วส่ง profile_media_id"})
+			return
+		}
 
 		var user models.User
 		if err := db.First(&user, userID).Error; err != nil {
@@ -48,8 +57,22 @@ func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 		if req.PhoneNumber != "" {
 			updates["phone"] = req.PhoneNumber
 		}
-		if req.Profile != "" {
-			updates["profile"] = req.Profile
+
+		var previousMedia *models.Media
+		if req.ProfileMediaID != nil {
+			media, err := resolveMediaRef(db, c, "profile_media_id", req.ProfileMediaID, models.MediaPurposeProfile)
+			if err != nil || media.OwnerID != userID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "รูปโปรไฟล์ไม่ถูกต้อง"})
+				return
+			}
+			if user.ProfileMediaID != nil && *user.ProfileMediaID != media.ID {
+				var old models.Media
+				if err := db.First(&old, *user.ProfileMediaID).Error; err == nil {
+					previousMedia = &old
+				}
+			}
+			updates["profile_media_id"] = media.ID
+			updates["profile"] = media.URL
 		}
 
 		if req.Password != "" {
@@ -72,10 +95,18 @@ func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// ลบรูปโปรไฟล์เดิมที่ไม่ได้ใช้แล้ว
+		if previousMedia != nil {
+			deleteMedia(db, store, c, previousMedia)
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "แก้ไขโปรไฟล์และรหัสผ่านเรียบร้อยแล้ว"})
 	}
 }
-func DeleteProfileImage(db *gorm.DB) gin.HandlerFunc {
+
+// DeleteProfileImage ลบรูปโปรไฟล์ของผู้ใช้ปัจจุบัน
+// ลบได้เฉพาะไฟล์ที่เป็น media ของผู้ใช้เอง ไม่ลบไฟล์ตาม path ที่เก็บในฟิลด์ profile
+func DeleteProfileImage(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		uid, exists := c.Get("user_id")
@@ -95,9 +126,17 @@ func DeleteProfileImage(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
-		if user.Profile != "" {
-			os.Remove(user.Profile)
-			db.Model(&user).Update("profile", "")
+		if user.ProfileMediaID != nil {
+			var media models.Media
+			if err := db.First(&media, *user.ProfileMediaID).Error; err == nil && media.OwnerID == user.ID {
+				if err := deleteMedia(db, store, c, &media); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete profile image"})
+					return
+				}
+			}
+		}
+		if user.Profile != "" || user.ProfileMediaID != nil {
+			db.Model(&user).Updates(map[string]interface{}{"profile": "", "profile_media_id": nil})
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Profile image deleted successfully"})
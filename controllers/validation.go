@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// fieldErrors คือข้อผิดพลาดรายฟิลด์ key เป็นชื่อฟิลด์ตาม JSON เช่น "title" หรือ "steps[1].instruction"
+type fieldErrors map[string]string
+
+func (f fieldErrors) add(field, format string, args ...interface{}) {
+	if _, exists := f[field]; !exists {
+		f[field] = fmt.Sprintf(format, args...)
+	}
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// ให้ชื่อฟิลด์ใน error ตรงกับชื่อใน JSON
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	_ = v.RegisterValidation("weburl", func(fl validator.FieldLevel) bool {
+		return isWebURL(fl.Field().String())
+	})
+}
+
+// isWebURL รับ URL แบบ http(s) ที่มี host หรือ path ของเซิร์ฟเวอร์นี้ (ขึ้นต้นด้วย "/" เช่น /media/...)
+func isWebURL(raw string) bool {
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validationMessage แปลง error ของ validator เป็นข้อความสั้น ๆ
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "weburl":
+		return "must be an http(s) URL or a path starting with /"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "email":
+		return "must be a valid email address"
+	case "datetime":
+		return "must be a date in the format " + fe.Param()
+	}
+	return "failed " + fe.Tag() + " validation"
+}
+
+// toFieldErrors แปลง ValidationErrors เป็น fieldErrors
+// namespace ของ validator ขึ้นต้นด้วยชื่อ struct และมีชื่อ struct ที่ฝังไว้ (เช่น ActivityAttributesInput)
+// ซึ่งไม่มีใน JSON จึงตัดส่วนที่ไม่ใช่ชื่อ JSON ออก
+func toFieldErrors(errs validator.ValidationErrors) fieldErrors {
+	fields := fieldErrors{}
+	for _, fe := range errs {
+		parts := strings.Split(fe.Namespace(), ".")
+		kept := make([]string, 0, len(parts))
+		for _, p := range parts[1:] {
+			if p != "" && !unicode.IsUpper(rune(p[0])) {
+				kept = append(kept, p)
+			}
+		}
+		fields.add(strings.Join(kept, "."), "%s", validationMessage(fe))
+	}
+	return fields
+}
+
+// respondValidation ตอบ 400 พร้อมข้อผิดพลาดรายฟิลด์
+func respondValidation(c *gin.Context, fields fieldErrors) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "validation failed: " + strings.Join(keys, ", "),
+		"fields": fields,
+	})
+}
+
+// validateStruct ตรวจ struct ด้วย binding tag แล้วคืนข้อผิดพลาดรายฟิลด์ (nil ถ้าผ่าน)
+func validateStruct(obj interface{}) (fieldErrors, error) {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil, nil
+	}
+	var errs validator.ValidationErrors
+	if errors.As(err, &errs) {
+		return toFieldErrors(errs), nil
+	}
+	return nil, err
+}
+
+// bindValidJSON อ่าน JSON และตรวจ binding tag ถ้าไม่ผ่านจะตอบ 400 แล้วคืน false
+// JSON ผิดรูปแบบได้ error เดียว ส่วน tag ที่ไม่ผ่านได้ข้อผิดพลาดรายฟิลด์
+func bindValidJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		var errs validator.ValidationErrors
+		if errors.As(err, &errs) {
+			respondValidation(c, toFieldErrors(errs))
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// parseIDParam อ่าน :id เป็นตัวเลข ถ้าไม่ใช่ตอบ 400 แล้วคืน false
+// ต้องแปลงก่อนส่งให้ gorm เสมอ เพราะ First/Delete ตีความสตริงที่ไม่ใช่ตัวเลขเป็นเงื่อนไข SQL ดิบ
+func parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,338 @@
+// Package handout สร้างเอกสาร PDF ขนาด A4 ของกิจกรรมสำหรับพิมพ์ใช้ในการบำบัด
+// รองรับทั้งกิจกรรมเดียวและ booklet หลายกิจกรรม โดยฝังฟอนต์ภาษาไทยลงในไฟล์
+package handout
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"project-backend/models"
+	"project-backend/richtext"
+
+	"github.com/go-pdf/fpdf"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	fontFamily = "Sarabun"
+
+	pageMargin   = 15.0
+	contentWidth = 210.0 - 2*pageMargin
+	lineHeight   = 6.5
+
+	coverMaxHeight = 90.0
+	qrSize         = 35.0
+)
+
+// Fonts คือไฟล์ฟอนต์ TTF ที่ฝังลงใน PDF (ต้องมีทั้งตัวปกติและตัวหนา)
+type Fonts struct {
+	Regular []byte
+	Bold    []byte
+}
+
+// LoadFonts อ่านไฟล์ฟอนต์จาก dir
+func LoadFonts(dir, regular, bold string) (*Fonts, error) {
+	r, err := os.ReadFile(filepath.Join(dir, regular))
+	if err != nil {
+		return nil, fmt.Errorf("handout: read regular font: %w", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, bold))
+	if err != nil {
+		return nil, fmt.Errorf("handout: read bold font: %w", err)
+	}
+	return &Fonts{Regular: r, Bold: b}, nil
+}
+
+// QRCode คือภาพ QR ที่จะพิมพ์ท้ายเอกสาร
+type QRCode struct {
+	Label     string
+	TargetURL string
+	PNG       []byte
+}
+
+// Page คือข้อมูลของกิจกรรมหนึ่งรายการใน PDF
+// Goals ใช้จัดกลุ่มเป้าหมายย่อยที่เลือกตามเป้าหมายหลัก
+type Page struct {
+	Activity models.Activity
+	Goals    []models.ActivityGoal
+	Cover    []byte
+	QRCodes  []QRCode
+}
+
+// Render เขียน PDF ของทุกกิจกรรมลง w โดยแต่ละกิจกรรมเริ่มหน้าใหม่ ใช้ข้อความหัวข้อตาม l (ดู LabelsFor)
+func Render(w io.Writer, fonts *Fonts, pages []Page, l *Labels) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin+5)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fonts.Regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fonts.Bold)
+	pdf.SetTitle(documentTitle(l, pages), true)
+	pdf.SetCreator("Music Therapy", true)
+
+	current := ""
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pageMargin)
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(contentWidth/2, 5, current, "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth/2, 5, fmt.Sprintf(l.Page, pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	for i, page := range pages {
+		current = page.Activity.Title
+		pdf.AddPage()
+		renderActivity(pdf, l, i, page)
+		if err := pdf.Error(); err != nil {
+			return err
+		}
+	}
+
+	return pdf.Output(w)
+}
+
+func documentTitle(l *Labels, pages []Page) string {
+	if len(pages) == 1 {
+		return pages[0].Activity.Title
+	}
+	return fmt.Sprintf(l.Booklet, len(pages))
+}
+
+func renderActivity(pdf *fpdf.Fpdf, l *Labels, index int, page Page) {
+	a := page.Activity
+
+	pdf.SetFont(fontFamily, "B", 20)
+	pdf.MultiCell(contentWidth, 9, a.Title, "", "L", false)
+	pdf.Ln(2)
+
+	if len(page.Cover) > 0 {
+		drawCover(pdf, fmt.Sprintf("cover-%d", index), page.Cover)
+	}
+
+	section(pdf, l.Objective, a.GoalDescription)
+	subGoals(pdf, l, a.SubGoals, page.Goals)
+	equipment(pdf, l, a)
+	steps(pdf, l, a)
+	section(pdf, l.ObservableBehavior, richtext.PlainText(a.ObservableBehavior))
+	section(pdf, l.Suggestion, richtext.PlainText(a.Suggestion))
+	songs(pdf, l, a)
+	qrCodes(pdf, index, page.QRCodes)
+}
+
+func heading(pdf *fpdf.Fpdf, title string) {
+	pdf.Ln(3)
+	pdf.SetFont(fontFamily, "B", 15)
+	pdf.SetFillColor(238, 242, 247)
+	pdf.CellFormat(contentWidth, 8, title, "", 1, "L", true, 0, "")
+	pdf.Ln(1)
+	pdf.SetFont(fontFamily, "", 13)
+}
+
+func section(pdf *fpdf.Fpdf, title, body string) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return
+	}
+	heading(pdf, title)
+	pdf.MultiCell(contentWidth, lineHeight, body, "", "L", false)
+}
+
+func bullet(pdf *fpdf.Fpdf, indent float64, marker, text string) {
+	pdf.SetX(pageMargin + indent)
+	pdf.CellFormat(6, lineHeight, marker, "", 0, "L", false, 0, "")
+	pdf.MultiCell(contentWidth-indent-6, lineHeight, text, "", "L", false)
+}
+
+// subGoals แสดงเป้าหมายย่อยที่เลือก จัดกลุ่มตามเป้าหมายหลักตามลำดับใน goals
+func subGoals(pdf *fpdf.Fpdf, l *Labels, selected []models.ActivitySubGoal, goals []models.ActivityGoal) {
+	if len(selected) == 0 {
+		return
+	}
+	heading(pdf, l.SubGoals)
+
+	byGoal := make(map[uint][]models.ActivitySubGoal)
+	for _, sg := range selected {
+		byGoal[sg.GoalID] = append(byGoal[sg.GoalID], sg)
+	}
+
+	printed := make(map[uint]bool)
+	for _, goal := range goals {
+		items := byGoal[goal.ID]
+		if len(items) == 0 {
+			continue
+		}
+		printed[goal.ID] = true
+		pdf.SetFont(fontFamily, "B", 13)
+		pdf.MultiCell(contentWidth, lineHeight, goal.GoalName, "", "L", false)
+		pdf.SetFont(fontFamily, "", 13)
+		for _, sg := range items {
+			bullet(pdf, 4, "•", sg.SubGoalName)
+		}
+	}
+	for _, sg := range selected {
+		if !printed[sg.GoalID] {
+			bullet(pdf, 4, "•", sg.SubGoalName)
+		}
+	}
+}
+
+func equipment(pdf *fpdf.Fpdf, l *Labels, a models.Activity) {
+	if len(a.EquipmentItems) == 0 {
+		section(pdf, l.Equipment, a.Equipment)
+		return
+	}
+	heading(pdf, l.Equipment)
+	for _, item := range a.EquipmentItems {
+		text := fmt.Sprintf("%s × %d", item.Equipment.Name, item.Quantity)
+		if item.PerGroupSize > 0 {
+			text += fmt.Sprintf(l.PerGroup, item.PerGroupSize)
+		}
+		bullet(pdf, 0, "•", text)
+	}
+}
+
+func steps(pdf *fpdf.Fpdf, l *Labels, a models.Activity) {
+	if len(a.Steps) == 0 {
+		section(pdf, l.Steps, richtext.PlainText(a.Process))
+		return
+	}
+	heading(pdf, l.Steps)
+	for i, step := range a.Steps {
+		text := richtext.PlainText(step.Instruction)
+		if step.DurationSeconds != nil && *step.DurationSeconds > 0 {
+			text += " (" + formatDuration(l, *step.DurationSeconds) + ")"
+		}
+		bullet(pdf, 0, fmt.Sprintf("%d.", i+1), text)
+		if cue := strings.TrimSpace(step.FacilitatorCue); cue != "" {
+			pdf.SetTextColor(90, 90, 90)
+			bullet(pdf, 6, "", l.Facilitator+cue)
+			pdf.SetTextColor(0, 0, 0)
+		}
+	}
+}
+
+func songs(pdf *fpdf.Fpdf, l *Labels, a models.Activity) {
+	if len(a.Songs) == 0 {
+		section(pdf, l.Songs, a.Song)
+		return
+	}
+	heading(pdf, l.Songs)
+	for _, song := range a.Songs {
+		text := song.Title
+		var details []string
+		if song.Composer != "" {
+			details = append(details, song.Composer)
+		}
+		if song.Key != "" {
+			details = append(details, l.Key+song.Key)
+		}
+		if song.TempoBPM != nil {
+			details = append(details, fmt.Sprintf("%d BPM", *song.TempoBPM))
+		}
+		if len(details) > 0 {
+			text += " – " + strings.Join(details, ", ")
+		}
+		bullet(pdf, 0, "•", text)
+	}
+}
+
+func qrCodes(pdf *fpdf.Fpdf, index int, codes []QRCode) {
+	if len(codes) == 0 {
+		return
+	}
+	// เว้นที่ให้หัวข้อ ภาพ และคำอธิบายอยู่หน้าเดียวกัน
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY()+qrSize+24 > pageHeight-pageMargin-5 {
+		pdf.AddPage()
+	}
+	heading(pdf, "QR Code")
+
+	top := pdf.GetY()
+	colWidth := contentWidth / 2
+	for i, code := range codes {
+		x := pageMargin + float64(i%2)*colWidth
+		if i > 0 && i%2 == 0 {
+			top += qrSize + 14
+		}
+		name := fmt.Sprintf("qr-%d-%d", index, i)
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(code.PNG))
+		pdf.ImageOptions(name, x+(colWidth-qrSize)/2, top, qrSize, qrSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+		pdf.SetXY(x, top+qrSize+1)
+		pdf.SetFont(fontFamily, "B", 11)
+		pdf.CellFormat(colWidth, 5, code.Label, "", 2, "C", false, 0, "")
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.CellFormat(colWidth, 4, truncate(code.TargetURL, 60), "", 0, "C", false, 0, code.TargetURL)
+	}
+	pdf.SetXY(pageMargin, top+qrSize+12)
+}
+
+// drawCover วาดภาพปกให้กว้างไม่เกินหน้ากระดาษและสูงไม่เกิน coverMaxHeight
+// ภาพทุกชนิดถูกแปลงเป็น JPEG ก่อน เพราะ PDF ไม่รองรับ WebP และ PNG บางรูปแบบ
+func drawCover(pdf *fpdf.Fpdf, name string, data []byte) {
+	jpg, width, height, err := toJPEG(data)
+	if err != nil || width == 0 || height == 0 {
+		return
+	}
+
+	w := contentWidth
+	h := w * float64(height) / float64(width)
+	if h > coverMaxHeight {
+		h = coverMaxHeight
+		w = h * float64(width) / float64(height)
+	}
+
+	opts := fpdf.ImageOptions{ImageType: "JPG"}
+	pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(jpg))
+	pdf.ImageOptions(name, pageMargin+(contentWidth-w)/2, pdf.GetY(), w, h, false, opts, 0, "")
+	pdf.SetY(pdf.GetY() + h + 3)
+}
+
+func toJPEG(data []byte) ([]byte, int, int, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	bounds := src.Bounds()
+
+	// วางบนพื้นขาวเพื่อไม่ให้ส่วนโปร่งใสกลายเป็นสีดำ
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, src, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, 0, 0, err
+	}
+	return buf.Bytes(), bounds.Dx(), bounds.Dy(), nil
+}
+
+func formatDuration(l *Labels, seconds int) string {
+	m, s := seconds/60, seconds%60
+	switch {
+	case m == 0:
+		return fmt.Sprintf(l.Seconds, s)
+	case s == 0:
+		return fmt.Sprintf(l.Minutes, m)
+	default:
+		return fmt.Sprintf(l.MinutesSeconds, m, s)
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
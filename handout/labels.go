@@ -0,0 +1,71 @@
+package handout
+
+import "strings"
+
+// Labels คือข้อความคงที่ในเอกสาร (หัวข้อ หน่วยเวลา ท้ายกระดาษ) ของแต่ละภาษา
+// ค่าที่มี %d เป็นรูปแบบของ fmt.Sprintf
+type Labels struct {
+	Page               string
+	Booklet            string
+	Objective          string
+	SubGoals           string
+	Equipment          string
+	PerGroup           string
+	Steps              string
+	Facilitator        string
+	ObservableBehavior string
+	Suggestion         string
+	Songs              string
+	Key                string
+	Seconds            string
+	Minutes            string
+	MinutesSeconds     string
+}
+
+var labels = map[string]*Labels{
+	"th": {
+		Page:               "หน้า %d",
+		Booklet:            "ชุดกิจกรรม (%d กิจกรรม)",
+		Objective:          "จุดประสงค์ของกิจกรรม",
+		SubGoals:           "เป้าหมายย่อย",
+		Equipment:          "อุปกรณ์",
+		PerGroup:           " (ต่อกลุ่ม %d คน)",
+		Steps:              "ขั้นตอนการดำเนินกิจกรรม",
+		Facilitator:        "ผู้นำกิจกรรม: ",
+		ObservableBehavior: "พฤติกรรมที่สังเกตได้",
+		Suggestion:         "ข้อเสนอแนะ",
+		Songs:              "เพลง",
+		Key:                "คีย์ ",
+		Seconds:            "%d วินาที",
+		Minutes:            "%d นาที",
+		MinutesSeconds:     "%d นาที %d วินาที",
+	},
+	"en": {
+		Page:               "Page %d",
+		Booklet:            "Activity booklet (%d activities)",
+		Objective:          "Objective",
+		SubGoals:           "Sub-goals",
+		Equipment:          "Equipment",
+		PerGroup:           " (per group of %d)",
+		Steps:              "Procedure",
+		Facilitator:        "Facilitator: ",
+		ObservableBehavior: "Observable behaviour",
+		Suggestion:         "Suggestions",
+		Songs:              "Songs",
+		Key:                "Key ",
+		Seconds:            "%d sec",
+		Minutes:            "%d min",
+		MinutesSeconds:     "%d min %d sec",
+	},
+}
+
+// LabelsFor คืนข้อความของภาษา locale (เช่น "en-GB" ใช้ "en") ถ้าไม่มีจะใช้ภาษาไทย
+func LabelsFor(locale string) *Labels {
+	if l, ok := labels[locale]; ok {
+		return l
+	}
+	if lang, _, _ := strings.Cut(locale, "-"); labels[lang] != nil {
+		return labels[lang]
+	}
+	return labels["th"]
+}
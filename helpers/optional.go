@@ -0,0 +1,31 @@
+package helpers
+
+import "encoding/json"
+
+// Optional คือฟิลด์ JSON ที่แยกได้ 3 กรณี สำหรับ request แบบ PATCH
+//
+//	ไม่ได้ส่งฟิลด์มา   Set = false
+//	ส่ง null           Set = true, Null = true
+//	ส่งค่ามา           Set = true, Value = ค่านั้น
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON ถูกเรียกเฉพาะเมื่อมีฟิลด์นี้ใน JSON (รวมกรณี null)
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		var zero T
+		o.Null, o.Value = true, zero
+		return nil
+	}
+	o.Null = false
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Present คืน true เมื่อส่งค่าที่ไม่ใช่ null มา
+func (o Optional[T]) Present() bool {
+	return o.Set && !o.Null
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// ParseQRLevel แปลงระดับการแก้ไขข้อผิดพลาด (L, M, Q, H) ให้อยู่ในรูปแบบมาตรฐาน
+func ParseQRLevel(level string) (string, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if _, ok := qrLevels[level]; !ok {
+		return "", fmt.Errorf("invalid error-correction level %q (use L, M, Q or H)", level)
+	}
+	return level, nil
+}
+
+// ValidateQRTarget ตรวจว่าเป้าหมายของ QR เป็น URL แบบ http/https ที่สมบูรณ์
+func ValidateQRTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("target must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// RenderQRPNG สร้างภาพ QR แบบ PNG ขนาด size x size พิกเซล
+func RenderQRPNG(content, level string, size int) ([]byte, error) {
+	code, err := qrcode.New(content, qrLevels[level])
+	if err != nil {
+		return nil, err
+	}
+	return code.PNG(size)
+}
+
+// RenderQRSVG สร้างภาพ QR แบบ SVG (vector) ที่แสดงผลขนาด size x size
+func RenderQRSVG(content, level string, size int) (string, error) {
+	code, err := qrcode.New(content, qrLevels[level])
+	if err != nil {
+		return "", err
+	}
+	bitmap := code.Bitmap()
+	n := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		size, size, n, n, n, n, path.String(),
+	), nil
+}
@@ -0,0 +1,167 @@
+package i18n
+
+import (
+	"strings"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type target struct {
+	entityType string
+	id         uint
+	field      string
+	value      *string
+}
+
+type key struct {
+	entityType string
+	id         uint
+	field      string
+}
+
+// Batch รวบรวมข้อความที่ต้องแปลจากหลายรายการ แล้วโหลดคำแปลทั้งหมดด้วย query เดียวใน Apply
+// ข้อความที่ไม่มีคำแปลจะคงเป็นข้อความต้นฉบับ
+type Batch struct {
+	locale  string
+	targets []target
+}
+
+func NewBatch(locale string) *Batch {
+	return &Batch{locale: locale}
+}
+
+// Add เพิ่มข้อความหนึ่งฟิลด์ที่จะถูกแทนด้วยคำแปลเมื่อเรียก Apply
+func (b *Batch) Add(entityType string, id uint, field string, value *string) {
+	if id == 0 || value == nil {
+		return
+	}
+	b.targets = append(b.targets, target{entityType, id, field, value})
+}
+
+// Activity เพิ่มข้อความของกิจกรรม ขั้นตอน รูปแบบ และ taxonomy ที่ preload มาแล้ว
+func (b *Batch) Activity(a *models.Activity) {
+	b.Add(EntityActivity, a.ID, "title", &a.Title)
+	b.Add(EntityActivity, a.ID, "goal_description", &a.GoalDescription)
+	b.Add(EntityActivity, a.ID, "equipment", &a.Equipment)
+	b.Add(EntityActivity, a.ID, "process", &a.Process)
+	b.Add(EntityActivity, a.ID, "observable_behavior", &a.ObservableBehavior)
+	b.Add(EntityActivity, a.ID, "suggestion", &a.Suggestion)
+	b.Add(EntityActivity, a.ID, "song", &a.Song)
+	b.Add(EntityActivity, a.ID, "contraindications", &a.Contraindications)
+	b.Add(EntityActivity, a.ID, "safety_notes", &a.SafetyNotes)
+
+	for i := range a.Steps {
+		step := &a.Steps[i]
+		b.Add(EntityStep, step.ID, "instruction", &step.Instruction)
+		b.Add(EntityStep, step.ID, "facilitator_cue", &step.FacilitatorCue)
+		if step.SubGoal != nil {
+			b.SubGoal(step.SubGoal)
+		}
+	}
+	for i := range a.Variants {
+		b.Variant(&a.Variants[i])
+	}
+	for i := range a.SubGoals {
+		b.SubGoal(&a.SubGoals[i])
+	}
+	for i := range a.SubCategories {
+		b.SubCategory(&a.SubCategories[i])
+	}
+	for i := range a.TargetPopulations {
+		b.TargetPopulation(&a.TargetPopulations[i])
+	}
+}
+
+// Variant เพิ่มข้อความของรูปแบบกิจกรรมและเป้าหมายย่อยที่เลือกไว้
+func (b *Batch) Variant(v *models.ActivityVariant) {
+	b.Add(EntityVariant, v.ID, "label", &v.Label)
+	b.Add(EntityVariant, v.ID, "process", &v.Process)
+	b.Add(EntityVariant, v.ID, "equipment", &v.Equipment)
+	b.Add(EntityVariant, v.ID, "song", &v.Song)
+	for i := range v.SubGoals {
+		b.SubGoal(&v.SubGoals[i])
+	}
+}
+
+func (b *Batch) Goal(g *models.ActivityGoal) {
+	b.Add(EntityGoal, g.ID, "goal_name", &g.GoalName)
+	for i := range g.SubGoals {
+		b.SubGoal(&g.SubGoals[i])
+	}
+}
+
+func (b *Batch) SubGoal(sg *models.ActivitySubGoal) {
+	b.Add(EntitySubGoal, sg.ID, "sub_goal_name", &sg.SubGoalName)
+}
+
+func (b *Batch) Category(c *models.ActivityMainCategory) {
+	b.Add(EntityCategory, c.ID, "category_name", &c.CategoryName)
+	for i := range c.SubCategories {
+		b.SubCategory(&c.SubCategories[i])
+	}
+}
+
+func (b *Batch) SubCategory(sc *models.ActivitySubCategory) {
+	b.Add(EntitySubCategory, sc.ID, "sub_category_name", &sc.SubCategoryName)
+}
+
+func (b *Batch) TargetPopulation(p *models.TargetPopulation) {
+	b.Add(EntityTargetPopulation, p.ID, "name", &p.Name)
+	b.Add(EntityTargetPopulation, p.ID, "description", &p.Description)
+}
+
+// Apply โหลดคำแปลตามลำดับ fallback ของภาษา แล้วแทนข้อความที่เพิ่มไว้
+func (b *Batch) Apply(db *gorm.DB) error {
+	chain := Chain(b.locale)
+	if len(chain) == 0 || len(b.targets) == 0 {
+		return nil
+	}
+
+	idsByType := make(map[string][]uint)
+	seen := make(map[key]bool)
+	for _, t := range b.targets {
+		k := key{t.entityType, t.id, ""}
+		if !seen[k] {
+			seen[k] = true
+			idsByType[t.entityType] = append(idsByType[t.entityType], t.id)
+		}
+	}
+
+	conditions := make([]string, 0, len(idsByType))
+	args := make([]interface{}, 0, len(idsByType)*2)
+	for entityType, ids := range idsByType {
+		conditions = append(conditions, "(entity_type = ? AND entity_id IN ?)")
+		args = append(args, entityType, ids)
+	}
+
+	var rows []models.Translation
+	if err := db.Where("locale IN ?", chain).
+		Where(strings.Join(conditions, " OR "), args...).
+		Find(&rows).Error; err != nil {
+		return err
+	}
+
+	rank := make(map[string]int, len(chain))
+	for i, l := range chain {
+		rank[l] = i
+	}
+	best := make(map[key]models.Translation, len(rows))
+	for _, row := range rows {
+		if row.Value == "" {
+			continue
+		}
+		k := key{row.EntityType, row.EntityID, row.Field}
+		if current, ok := best[k]; !ok || rank[row.Locale] < rank[current.Locale] {
+			best[k] = row
+		}
+	}
+
+	for _, t := range b.targets {
+		if row, ok := best[key{t.entityType, t.id, t.field}]; ok {
+			*t.value = row.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Package i18n เลือกภาษาของ request และแทนข้อความต้นฉบับ (ภาษาไทย) ด้วยคำแปลจากตาราง translations
+//
+// ลำดับการ fallback: ภาษาที่ขอ (เช่น en-GB) → ภาษาหลักของภาษานั้น (en) → ข้อความต้นฉบับภาษาไทย
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BaseLocale คือภาษาของข้อความต้นฉบับที่เก็บในคอลัมน์ของแต่ละตาราง
+const BaseLocale = "th"
+
+// Normalize จัดรูปแบบรหัสภาษาให้เหมือนกัน เช่น "EN_us" → "en-US" คืน "" ถ้ารูปแบบไม่ถูกต้อง
+func Normalize(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if len(parts) == 0 || len(parts[0]) < 2 || len(parts[0]) > 3 || !isLetters(parts[0]) {
+		return ""
+	}
+	out := strings.ToLower(parts[0])
+	if len(parts) > 1 && len(parts[1]) == 2 && isLetters(parts[1]) {
+		out += "-" + strings.ToUpper(parts[1])
+	}
+	return out
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Language คืนภาษาหลักของรหัสภาษา เช่น "en-GB" → "en"
+func Language(locale string) string {
+	if i := strings.IndexByte(locale, '-'); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// Chain คืนลำดับภาษาที่ต้องค้นหาคำแปล (ไม่รวม BaseLocale ซึ่งใช้ข้อความต้นฉบับ)
+func Chain(locale string) []string {
+	if Language(locale) == BaseLocale {
+		return nil
+	}
+	var chain []string
+	for _, l := range []string{locale, Language(locale)} {
+		if l == "" || (len(chain) > 0 && chain[len(chain)-1] == l) {
+			continue
+		}
+		chain = append(chain, l)
+	}
+	return chain
+}
+
+// IsSupported ตรวจว่าภาษา (หรือภาษาหลักของภาษานั้น) อยู่ในรายการที่รองรับ
+func IsSupported(locale string, supported []string) bool {
+	for _, s := range supported {
+		s = Normalize(s)
+		if s == locale || s == Language(locale) {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate เลือกภาษาของ request จากพารามิเตอร์ lang ก่อน แล้วจึงดู Accept-Language
+// ถ้าไม่มีภาษาที่รองรับจะคืน BaseLocale
+func Negotiate(lang, acceptLanguage string, supported []string) string {
+	if l := Normalize(lang); l != "" && IsSupported(l, supported) {
+		return l
+	}
+
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if l := Normalize(tag); l != "" && q > 0 {
+			candidates = append(candidates, candidate{l, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if IsSupported(c.locale, supported) {
+			return c.locale
+		}
+	}
+	return BaseLocale
+}
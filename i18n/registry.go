@@ -0,0 +1,58 @@
+package i18n
+
+import "project-backend/models"
+
+const (
+	EntityActivity    = "activity"
+	EntityStep        = "activity_step"
+	EntityVariant     = "activity_variant"
+	EntityGoal        = models.TaxonomyKindGoal
+	EntitySubGoal     = models.TaxonomyKindSubGoal
+	EntityCategory    = models.TaxonomyKindCategory
+	EntitySubCategory = models.TaxonomyKindSubCategory
+
+	EntityTargetPopulation = "target_population"
+)
+
+// Entity อธิบายข้อมูลที่แปลได้ ชื่อฟิลด์ตรงกับชื่อคอลัมน์ของข้อความต้นฉบับ
+type Entity struct {
+	Type      string
+	Table     string
+	Fields    []string
+	Retirable bool
+}
+
+// Entities คือข้อมูลทั้งหมดที่แปลได้ เรียงตามลำดับที่แสดงในรายงานความครบถ้วน
+var Entities = []Entity{
+	{Type: EntityActivity, Table: "activities", Fields: []string{
+		"title", "goal_description", "equipment", "process", "observable_behavior", "suggestion", "song",
+		"contraindications", "safety_notes",
+	}},
+	{Type: EntityStep, Table: "activity_steps", Fields: []string{"instruction", "facilitator_cue"}},
+	{Type: EntityVariant, Table: "activity_variants", Fields: []string{"label", "process", "equipment", "song"}},
+	{Type: EntityGoal, Table: "activity_goals", Fields: []string{"goal_name"}, Retirable: true},
+	{Type: EntitySubGoal, Table: "activity_sub_goals", Fields: []string{"sub_goal_name"}, Retirable: true},
+	{Type: EntityCategory, Table: "activity_main_categories", Fields: []string{"category_name"}, Retirable: true},
+	{Type: EntitySubCategory, Table: "activity_sub_categories", Fields: []string{"sub_category_name"}, Retirable: true},
+	{Type: EntityTargetPopulation, Table: "target_populations", Fields: []string{"name", "description"}},
+}
+
+// Lookup คืนข้อมูลของ entity ตามชนิด
+func Lookup(entityType string) (Entity, bool) {
+	for _, e := range Entities {
+		if e.Type == entityType {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+// HasField ตรวจว่าฟิลด์นี้แปลได้
+func (e Entity) HasField(field string) bool {
+	for _, f := range e.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
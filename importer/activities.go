@@ -0,0 +1,337 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"project-backend/config"
+	"project-backend/helpers"
+	"project-backend/models"
+	"project-backend/qrcodes"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RowValid   = "valid"
+	RowInvalid = "invalid"
+	RowCreated = "created"
+)
+
+// ErrInvalidRows ถูกคืนเมื่อมีแถวที่ไม่ผ่านการตรวจสอบ ซึ่งจะไม่มีแถวใดถูกบันทึก
+var ErrInvalidRows = errors.New("import contains invalid rows; nothing was saved")
+
+// activityColumns คือคอลัมน์ที่รองรับ ชื่อตรงกับ field JSON ของกิจกรรม
+var activityColumns = map[string]bool{
+	"title":               true,
+	"cover_image":         true,
+	"goal_description":    true,
+	"equipment":           true,
+	"process":             true,
+	"observable_behavior": true,
+	"suggestion":          true,
+	"song":                true,
+	"song_image":          true,
+	"qr_1":                true,
+	"qr_2":                true,
+	"sub_goals":           true,
+	"sub_categories":      true,
+	"steps":               true,
+	"age_min":             true,
+	"age_max":             true,
+	"group_size_min":      true,
+	"group_size_max":      true,
+	"duration_minutes":    true,
+	"difficulty":          true,
+	"contraindications":   true,
+	"safety_notes":        true,
+	"target_populations":  true,
+}
+
+type RowReport struct {
+	Row        int      `json:"row"`
+	Title      string   `json:"title"`
+	Status     string   `json:"status"`
+	ActivityID uint     `json:"activity_id,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+type Report struct {
+	DryRun         bool        `json:"dry_run"`
+	Total          int         `json:"total"`
+	Valid          int         `json:"valid"`
+	Invalid        int         `json:"invalid"`
+	Created        int         `json:"created"`
+	UnknownColumns []string    `json:"unknown_columns,omitempty"`
+	Rows           []RowReport `json:"rows"`
+}
+
+type Options struct {
+	DryRun  bool
+	AdminID uint
+	QR      *config.QRConfig
+	// Validate ตรวจกิจกรรมของแต่ละแถวด้วยกฎเดียวกับการสร้างผ่าน API (controllers.ValidateImportedActivity)
+	Validate func(*models.Activity) []string
+}
+
+// ImportActivities ตรวจสอบทุกแถว ถ้าทุกแถวถูกต้องและไม่ใช่ dry run จะสร้างกิจกรรมทั้งหมดใน Transaction เดียว
+// ถ้ามีแถวใดผิดจะคืน report พร้อม ErrInvalidRows โดยไม่บันทึกอะไรเลย
+func ImportActivities(ctx context.Context, db *gorm.DB, records []Record, opts Options, progress func(processed, total int)) (*Report, error) {
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+
+	tax, err := loadTaxonomy(db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: opts.DryRun, Total: len(records), UnknownColumns: unknownColumns(records)}
+	activities := make([]models.Activity, len(records))
+	seenTitles := make(map[string]int)
+
+	for i, rec := range records {
+		row := RowReport{Row: rec.Row, Title: rec.Get("title")}
+		activities[i], row.Errors = tax.buildActivity(rec, opts.AdminID)
+		if opts.Validate != nil {
+			row.Errors = append(row.Errors, opts.Validate(&activities[i])...)
+		}
+
+		key := strings.ToLower(row.Title)
+		if first, ok := seenTitles[key]; ok && key != "" {
+			row.Warnings = append(row.Warnings, fmt.Sprintf("same title as row %d", first))
+		} else {
+			seenTitles[key] = rec.Row
+		}
+
+		if len(row.Errors) > 0 {
+			row.Status = RowInvalid
+			report.Invalid++
+		} else {
+			row.Status = RowValid
+			report.Valid++
+		}
+		report.Rows = append(report.Rows, row)
+
+		if opts.DryRun {
+			progress(i+1, len(records))
+		}
+	}
+
+	if err := warnExistingTitles(db, report); err != nil {
+		return nil, err
+	}
+
+	if report.Invalid > 0 {
+		return report, ErrInvalidRows
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range activities {
+			if err := tx.Omit("SubGoals.*", "SubCategories.*", "TargetPopulations.*").Create(&activities[i]).Error; err != nil {
+				return fmt.Errorf("row %d: %w", records[i].Row, err)
+			}
+			// คอลัมน์ qr_1/qr_2 คือ URL ที่ QR พาไป ระบบสร้างภาพ QR เองเหมือน qr_N_target ของ API
+			for slot := 1; slot <= len(qrcodes.SlotColumns); slot++ {
+				target := records[i].Get(fmt.Sprintf("qr_%d", slot))
+				if target == "" || opts.QR == nil {
+					continue
+				}
+				if err := qrcodes.Sync(tx, opts.QR, activities[i].ID, slot, target, ""); err != nil {
+					return fmt.Errorf("row %d: qr_%d: %w", records[i].Row, slot, err)
+				}
+			}
+			progress(i+1, len(activities))
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for i := range report.Rows {
+		report.Rows[i].Status = RowCreated
+		report.Rows[i].ActivityID = activities[i].ID
+	}
+	report.Created = len(activities)
+	return report, nil
+}
+
+func (t *taxonomy) buildActivity(rec Record, adminID uint) (models.Activity, []string) {
+	var errs []string
+
+	a := models.Activity{
+		Title:              rec.Get("title"),
+		CoverImage:         rec.Get("cover_image"),
+		GoalDescription:    rec.Get("goal_description"),
+		Equipment:          rec.Get("equipment"),
+		Process:            rec.Get("process"),
+		ObservableBehavior: rec.Get("observable_behavior"),
+		Suggestion:         rec.Get("suggestion"),
+		Song:               rec.Get("song"),
+		SongImage:          rec.Get("song_image"),
+		Contraindications:  rec.Get("contraindications"),
+		SafetyNotes:        rec.Get("safety_notes"),
+		AdminID:            adminID,
+	}
+	for slot := 1; slot <= len(qrcodes.SlotColumns); slot++ {
+		column := fmt.Sprintf("qr_%d", slot)
+		if target := rec.Get(column); target != "" {
+			if err := helpers.ValidateQRTarget(target); err != nil {
+				errs = append(errs, column+": "+err.Error())
+			}
+		}
+	}
+
+	for _, ref := range splitList(rec.Get("sub_goals")) {
+		node, err := t.subGoals.resolve(ref)
+		if err != nil {
+			errs = append(errs, "sub_goals: "+err.Error())
+			continue
+		}
+		a.SubGoals = append(a.SubGoals, models.ActivitySubGoal{ID: node.ID, GoalID: node.ParentID, SubGoalName: node.Name})
+	}
+	for _, ref := range splitList(rec.Get("sub_categories")) {
+		node, err := t.subCategories.resolve(ref)
+		if err != nil {
+			errs = append(errs, "sub_categories: "+err.Error())
+			continue
+		}
+		a.SubCategories = append(a.SubCategories, models.ActivitySubCategory{ID: node.ID, CategoryID: node.ParentID, SubCategoryName: node.Name})
+	}
+
+	numbers := []struct {
+		column string
+		field  **int
+	}{
+		{"age_min", &a.AgeMin},
+		{"age_max", &a.AgeMax},
+		{"group_size_min", &a.GroupSizeMin},
+		{"group_size_max", &a.GroupSizeMax},
+		{"duration_minutes", &a.DurationMinutes},
+		{"difficulty", &a.Difficulty},
+	}
+	for _, n := range numbers {
+		column, field := n.column, n.field
+		raw := rec.Get(column)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s must be a whole number", column))
+			continue
+		}
+		*field = &v
+	}
+
+	for _, ref := range splitList(rec.Get("target_populations")) {
+		tp, err := t.populations.resolve(ref)
+		if err != nil {
+			errs = append(errs, "target_populations: "+err.Error())
+			continue
+		}
+		a.TargetPopulations = append(a.TargetPopulations, tp)
+	}
+
+	for i, instruction := range splitSteps(rec.Get("steps")) {
+		a.Steps = append(a.Steps, models.ActivityStep{Position: i + 1, Instruction: instruction})
+	}
+
+	return a, errs
+}
+
+func unknownColumns(records []Record) []string {
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, rec := range records {
+		for column := range rec.Fields {
+			if !activityColumns[column] && !seen[column] {
+				seen[column] = true
+				unknown = append(unknown, column)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// warnExistingTitles เตือน (ไม่ใช่ error) เมื่อมีกิจกรรมชื่อเดียวกันอยู่ในระบบแล้ว เพื่อกันการนำเข้าไฟล์ซ้ำ
+func warnExistingTitles(db *gorm.DB, report *Report) error {
+	var titles []string
+	for _, row := range report.Rows {
+		if row.Title != "" {
+			titles = append(titles, strings.ToLower(row.Title))
+		}
+	}
+	if len(titles) == 0 {
+		return nil
+	}
+
+	var existing []string
+	if err := db.Model(&models.Activity{}).Where("LOWER(title) IN ?", titles).Pluck("LOWER(title)", &existing).Error; err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(existing))
+	for _, title := range existing {
+		found[title] = true
+	}
+	for i, row := range report.Rows {
+		if found[strings.ToLower(row.Title)] {
+			report.Rows[i].Warnings = append(report.Rows[i].Warnings, "an activity with this title already exists")
+		}
+	}
+	return nil
+}
+
+// splitList แยกค่าที่คั่นด้วยขึ้นบรรทัดใหม่หรือ ; และแยก "1,2,3" เมื่อทุกส่วนเป็นตัวเลข
+// (ชื่อเป้าหมายภาษาไทยอาจมีจุลภาค จึงไม่แยกด้วย , ถ้าไม่ใช่ ID)
+func splitList(value string) []string {
+	var items []string
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == ';' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if pieces := strings.Split(part, ","); len(pieces) > 1 && allNumeric(pieces) {
+			for _, piece := range pieces {
+				items = append(items, strings.TrimSpace(piece))
+			}
+			continue
+		}
+		items = append(items, part)
+	}
+	return items
+}
+
+func allNumeric(values []string) bool {
+	for _, v := range values {
+		if _, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+var stepNumber = regexp.MustCompile(`^\d+[.)]\s*`)
+
+// splitSteps แยกขั้นตอนทีละบรรทัด และตัดเลขลำดับที่พิมพ์นำหน้า (เช่น "1. ") ออก
+func splitSteps(value string) []string {
+	var steps []string
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(stepNumber.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line != "" {
+			steps = append(steps, line)
+		}
+	}
+	return steps
+}
@@ -0,0 +1,185 @@
+// Package importer นำเข้ากิจกรรมจำนวนมากจากไฟล์ CSV, XLSX หรือ JSON
+// โดยตรวจสอบทุกแถวก่อน แล้วบันทึกทั้งหมดใน Transaction เดียว
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// Record คือข้อมูลหนึ่งแถว key เป็นชื่อคอลัมน์ที่ปรับเป็นตัวพิมพ์เล็กและใช้ _ แทนช่องว่างแล้ว
+type Record struct {
+	Row    int
+	Fields map[string]string
+}
+
+func (r Record) Get(column string) string {
+	return strings.TrimSpace(r.Fields[column])
+}
+
+// DetectFormat เลือกรูปแบบไฟล์จาก format ที่ระบุ หรือจากนามสกุลไฟล์
+func DetectFormat(format, filename string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (use csv, xlsx or json)", format)
+	}
+}
+
+// Parse อ่านไฟล์ตาม format แถวแรกของ CSV/XLSX ต้องเป็นหัวตาราง
+// Row ของแต่ละ Record คือเลขแถวในไฟล์ (CSV/XLSX นับหัวตารางเป็นแถว 1, JSON นับจาก 1)
+func Parse(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatXLSX:
+		return parseXLSX(r)
+	case FormatJSON:
+		return parseJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func normalizeColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.Fields(name), "_")
+}
+
+func recordsFromRows(rows [][]string) ([]Record, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, name := range rows[0] {
+		header[i] = normalizeColumn(name)
+	}
+
+	var records []Record
+	for i, row := range rows[1:] {
+		fields := make(map[string]string, len(header))
+		empty := true
+		for j, value := range row {
+			if j >= len(header) || header[j] == "" {
+				continue
+			}
+			fields[header[j]] = value
+			if strings.TrimSpace(value) != "" {
+				empty = false
+			}
+		}
+		// ข้ามแถวว่างที่มักมีท้ายไฟล์ spreadsheet
+		if empty {
+			continue
+		}
+		records = append(records, Record{Row: i + 2, Fields: fields})
+	}
+	return records, nil
+}
+
+func parseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return recordsFromRows(rows)
+}
+
+// parseXLSX อ่านชีตแรกของไฟล์
+func parseXLSX(r io.Reader) ([]Record, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+	rows, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	return recordsFromRows(rows)
+}
+
+// parseJSON อ่าน array ของ object ค่าที่เป็น array (เช่น sub_goals, steps) จะถูกรวมด้วยขึ้นบรรทัดใหม่
+func parseJSON(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var items []map[string]any
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid JSON (expected an array of objects): %w", err)
+	}
+
+	records := make([]Record, 0, len(items))
+	for i, item := range items {
+		fields := make(map[string]string, len(item))
+		for key, value := range item {
+			text, err := jsonValueString(value)
+			if err != nil {
+				return nil, fmt.Errorf("item %d, %s: %w", i+1, key, err)
+			}
+			fields[normalizeColumn(key)] = text
+		}
+		records = append(records, Record{Row: i + 1, Fields: fields})
+	}
+	return records, nil
+}
+
+func jsonValueString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			text, err := jsonValueString(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, text)
+		}
+		return strings.Join(parts, "\n"), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
@@ -0,0 +1,198 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// node คือรายการย่อย (เป้าหมายย่อยหรือหมวดหมู่ย่อย) พร้อมรายการหลักที่สังกัด
+type node struct {
+	ID       uint
+	ParentID uint
+	Name     string
+}
+
+// tree จับคู่ค่าที่อ้างถึงรายการย่อยได้ 3 แบบ: ID, ชื่อ, หรือ "ชื่อหลัก / ชื่อย่อย" เมื่อชื่อย่อยซ้ำกัน
+// มีเฉพาะรายการที่ยังใช้งานอยู่ ส่วน ID ของรายการที่ถูกยกเลิกเก็บใน retired เพื่อแปลงไปยังรายการที่ถูกรวมเข้าไป
+type tree struct {
+	label       string
+	byID        map[uint]node
+	byName      map[string][]node
+	parentIDs   map[string][]uint
+	parentNames map[uint]string
+	retired     map[uint]*uint
+}
+
+type taxonomy struct {
+	subGoals      *tree
+	subCategories *tree
+	populations   *populations
+}
+
+// populations จับคู่กลุ่มเป้าหมายด้วย ID หรือชื่อ
+type populations struct {
+	byID   map[uint]models.TargetPopulation
+	byName map[string]models.TargetPopulation
+}
+
+func (p *populations) resolve(ref string) (models.TargetPopulation, error) {
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		if tp, ok := p.byID[uint(id)]; ok {
+			return tp, nil
+		}
+		return models.TargetPopulation{}, fmt.Errorf("target population id %d not found", id)
+	}
+	if tp, ok := p.byName[nameKey(ref)]; ok {
+		return tp, nil
+	}
+	return models.TargetPopulation{}, fmt.Errorf("target population %q not found", ref)
+}
+
+func newTree(label string) *tree {
+	return &tree{
+		label:       label,
+		byID:        map[uint]node{},
+		byName:      map[string][]node{},
+		parentIDs:   map[string][]uint{},
+		parentNames: map[uint]string{},
+		retired:     map[uint]*uint{},
+	}
+}
+
+func (t *tree) addParent(id uint, name string) {
+	t.parentNames[id] = name
+	key := nameKey(name)
+	t.parentIDs[key] = append(t.parentIDs[key], id)
+}
+
+func (t *tree) add(n node) {
+	t.byID[n.ID] = n
+	key := nameKey(n.Name)
+	t.byName[key] = append(t.byName[key], n)
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (t *tree) resolve(ref string) (node, error) {
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		target := uint(id)
+		for hops := 0; hops <= len(t.retired); hops++ {
+			if n, ok := t.byID[target]; ok {
+				return n, nil
+			}
+			merged, ok := t.retired[target]
+			if !ok {
+				break
+			}
+			if merged == nil {
+				return node{}, fmt.Errorf("%s id %d is retired", t.label, id)
+			}
+			target = *merged
+		}
+		return node{}, fmt.Errorf("%s id %d not found", t.label, id)
+	}
+
+	parent, child := "", ref
+	for _, sep := range []string{">", "/"} {
+		if i := strings.Index(ref, sep); i >= 0 {
+			parent, child = strings.TrimSpace(ref[:i]), strings.TrimSpace(ref[i+len(sep):])
+			break
+		}
+	}
+
+	candidates := t.byName[nameKey(child)]
+	if parent != "" {
+		parents := make(map[uint]bool)
+		for _, id := range t.parentIDs[nameKey(parent)] {
+			parents[id] = true
+		}
+		var filtered []node
+		for _, n := range candidates {
+			if parents[n.ParentID] {
+				filtered = append(filtered, n)
+			}
+		}
+		candidates = filtered
+	}
+
+	switch len(candidates) {
+	case 0:
+		// ชื่อที่มี / อยู่ในตัวเอง (ไม่ได้ตั้งใจระบุรายการหลัก)
+		if parent != "" {
+			if exact := t.byName[nameKey(ref)]; len(exact) == 1 {
+				return exact[0], nil
+			}
+		}
+		return node{}, fmt.Errorf("%s %q not found", t.label, ref)
+	case 1:
+		return candidates[0], nil
+	default:
+		options := make([]string, 0, len(candidates))
+		for _, n := range candidates {
+			options = append(options, fmt.Sprintf("%q (id %d)", t.parentNames[n.ParentID]+" / "+n.Name, n.ID))
+		}
+		return node{}, fmt.Errorf("%s %q is ambiguous; use one of %s", t.label, ref, strings.Join(options, ", "))
+	}
+}
+
+// loadTaxonomy โหลดเป้าหมาย (ActivityGoal) และหมวดหมู่ (ActivityMainCategory) ทั้งหมดพร้อมรายการย่อย และกลุ่มเป้าหมาย
+func loadTaxonomy(db *gorm.DB) (*taxonomy, error) {
+	var goals []models.ActivityGoal
+	if err := db.Preload("SubGoals").Find(&goals).Error; err != nil {
+		return nil, err
+	}
+	var categories []models.ActivityMainCategory
+	if err := db.Preload("SubCategories").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	var targetPopulations []models.TargetPopulation
+	if err := db.Find(&targetPopulations).Error; err != nil {
+		return nil, err
+	}
+
+	t := &taxonomy{
+		subGoals:      newTree("sub-goal"),
+		subCategories: newTree("sub-category"),
+		populations: &populations{
+			byID:   map[uint]models.TargetPopulation{},
+			byName: map[string]models.TargetPopulation{},
+		},
+	}
+	for _, tp := range targetPopulations {
+		t.populations.byID[tp.ID] = tp
+		t.populations.byName[nameKey(tp.Name)] = tp
+	}
+	for _, g := range goals {
+		if g.RetiredAt == nil {
+			t.subGoals.addParent(g.ID, g.GoalName)
+		}
+		for _, sg := range g.SubGoals {
+			if g.RetiredAt != nil || sg.RetiredAt != nil {
+				t.subGoals.retired[sg.ID] = sg.MergedIntoID
+				continue
+			}
+			t.subGoals.add(node{ID: sg.ID, ParentID: g.ID, Name: sg.SubGoalName})
+		}
+	}
+	for _, c := range categories {
+		if c.RetiredAt == nil {
+			t.subCategories.addParent(c.ID, c.CategoryName)
+		}
+		for _, sc := range c.SubCategories {
+			if c.RetiredAt != nil || sc.RetiredAt != nil {
+				t.subCategories.retired[sc.ID] = sc.MergedIntoID
+				continue
+			}
+			t.subCategories.add(node{ID: sc.ID, ParentID: c.ID, Name: sc.SubCategoryName})
+		}
+	}
+	return t, nil
+}
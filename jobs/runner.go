@@ -0,0 +1,116 @@
+// Package jobs รันงานที่ใช้เวลานานใน background และบันทึกสถานะ/ความคืบหน้าลงตาราง background_jobs
+// เพื่อให้ client poll ดูผลได้แทนการรอ request เดียวจนเสร็จ
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Progress รายงานจำนวนรายการที่ทำเสร็จแล้วจากทั้งหมด
+type Progress func(processed, total int)
+
+// Func คืองานที่จะรัน ผลลัพธ์ที่คืนมาจะถูกเก็บเป็น JSON ใน BackgroundJob.Result
+// ถ้าคืนทั้งผลลัพธ์และ error จะเก็บทั้งคู่ (เช่น รายงานของแถวที่ผิดพลาด)
+type Func func(ctx context.Context, progress Progress) (any, error)
+
+type Runner struct {
+	db *gorm.DB
+}
+
+func NewRunner(db *gorm.DB) *Runner {
+	return &Runner{db: db}
+}
+
+// Start บันทึกงานใหม่แล้วเริ่มรันใน goroutine คืน job ที่บันทึกแล้วทันที
+func (r *Runner) Start(jobType string, userID uint, total int, fn Func) (*models.BackgroundJob, error) {
+	job := models.BackgroundJob{
+		Type:        jobType,
+		Status:      models.JobStatusQueued,
+		Total:       total,
+		CreatedByID: userID,
+	}
+	if err := r.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	go r.run(job.ID, fn)
+	return &job, nil
+}
+
+// FailInterrupted ปิดงานที่ค้างสถานะ queued/running จากการ restart ครั้งก่อน
+func (r *Runner) FailInterrupted() {
+	now := time.Now()
+	res := r.db.Model(&models.BackgroundJob{}).
+		Where("status IN ?", []string{models.JobStatusQueued, models.JobStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusFailed,
+			"error":       "interrupted by server restart",
+			"finished_at": now,
+		})
+	if res.Error != nil {
+		log.Printf("jobs: could not mark interrupted jobs: %v", res.Error)
+	} else if res.RowsAffected > 0 {
+		log.Printf("jobs: marked %d interrupted job(s) as failed", res.RowsAffected)
+	}
+}
+
+func (r *Runner) run(jobID uint, fn Func) {
+	started := time.Now()
+	r.update(jobID, map[string]interface{}{"status": models.JobStatusRunning, "started_at": started})
+
+	// บันทึกความคืบหน้าไม่เกินวินาทีละครั้ง เพื่อไม่ให้งานใหญ่เขียนฐานข้อมูลทุกแถว
+	var lastSaved time.Time
+	progress := func(processed, total int) {
+		if processed < total && time.Since(lastSaved) < time.Second {
+			return
+		}
+		lastSaved = time.Now()
+		r.update(jobID, map[string]interface{}{"processed": processed, "total": total})
+	}
+
+	result, err := safeRun(fn, progress)
+
+	updates := map[string]interface{}{
+		"status":      models.JobStatusSucceeded,
+		"finished_at": time.Now(),
+	}
+	if result != nil {
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil && err == nil {
+			err = marshalErr
+		}
+		if marshalErr == nil {
+			updates["result"] = string(data)
+		}
+	}
+	if err != nil {
+		updates["status"] = models.JobStatusFailed
+		updates["error"] = err.Error()
+		log.Printf("jobs: job %d failed: %v", jobID, err)
+	}
+	r.update(jobID, updates)
+}
+
+// safeRun กัน panic ในงานไม่ให้ทำให้ทั้ง server ล่ม
+func safeRun(fn Func, progress Progress) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(context.Background(), progress)
+}
+
+func (r *Runner) update(jobID uint, updates map[string]interface{}) {
+	if err := r.db.Model(&models.BackgroundJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		log.Printf("jobs: could not update job %d: %v", jobID, err)
+	}
+}
@@ -0,0 +1,445 @@
+package library
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"project-backend/models"
+	"project-backend/storage"
+
+	"gorm.io/gorm"
+)
+
+// ExportOptions กำหนดขอบเขตการส่งออก ถ้า ActivityIDs ว่างจะส่งออกทั้งคลัง
+// (รวมเพลงและอุปกรณ์ที่ยังไม่มีกิจกรรมใช้) ถ้าระบุจะส่งออกเฉพาะกิจกรรมนั้นกับสิ่งที่อ้างถึง
+type ExportOptions struct {
+	ActivityIDs []uint
+}
+
+type exporter struct {
+	db    *gorm.DB
+	store storage.Storage
+
+	media    []Media
+	mediaIDs map[uint]string
+	records  map[string]models.Media
+}
+
+// Export เขียน package ลง w และคืน manifest ที่เขียนไป
+func Export(ctx context.Context, db *gorm.DB, store storage.Storage, w io.Writer, opts ExportOptions) (*Manifest, error) {
+	e := &exporter{db: db, store: store, mediaIDs: map[uint]string{}, records: map[string]models.Media{}}
+
+	activities, err := e.activities(opts.ActivityIDs)
+	if err != nil {
+		return nil, err
+	}
+	taxonomy, err := e.taxonomy(activities)
+	if err != nil {
+		return nil, err
+	}
+	songs, err := e.songs(activities, len(opts.ActivityIDs) == 0)
+	if err != nil {
+		return nil, err
+	}
+	equipment, err := e.equipment(activities, len(opts.ActivityIDs) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Format:     FormatName,
+		Version:    FormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Counts: Counts{
+			Activities: len(activities),
+			Songs:      len(songs),
+			Equipment:  len(equipment),
+			Goals:      len(taxonomy.Goals),
+			Categories: len(taxonomy.Categories),
+		},
+	}
+	for _, g := range taxonomy.Goals {
+		manifest.Counts.SubGoals += len(g.SubGoals)
+	}
+	for _, c := range taxonomy.Categories {
+		manifest.Counts.SubCategories += len(c.SubCategories)
+	}
+
+	zw := zip.NewWriter(w)
+
+	// เขียนไฟล์สื่อก่อน เพื่อให้ manifest บันทึกไฟล์ที่หายจาก storage ไว้เป็นคำเตือนได้
+	var written []Media
+	for _, m := range e.media {
+		if err := e.writeMediaFile(ctx, zw, m); err != nil {
+			manifest.Warnings = append(manifest.Warnings, fmt.Sprintf("media %s skipped: %v", m.Key, err))
+			continue
+		}
+		written = append(written, m)
+	}
+	manifest.Counts.Media = len(written)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{fileTaxonomy, taxonomy},
+		{fileEquipment, equipment},
+		{fileSongs, songs},
+		{fileActivities, activities},
+		{fileMedia, written},
+		{fileManifest, manifest},
+	}
+	for _, f := range files {
+		if err := writeJSON(zw, f.name, f.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func writeJSON(zw *zip.Writer, name string, data any) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(fw)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func (e *exporter) writeMediaFile(ctx context.Context, zw *zip.Writer, m Media) error {
+	record := e.records[m.Key]
+	rc, err := e.store.Open(ctx, record.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// ไฟล์สื่อส่วนใหญ่บีบอัดมาแล้ว จึงเก็บแบบไม่บีบอัดซ้ำ
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: m.File, Method: zip.Store, Modified: record.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+// mediaRef จดไฟล์สื่อที่ต้องส่งออกและคืน key ที่ใช้อ้างอิงใน package
+func (e *exporter) mediaRef(id *uint) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	if key, ok := e.mediaIDs[*id]; ok {
+		return key, nil
+	}
+
+	var m models.Media
+	if err := e.db.First(&m, *id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	e.mediaIDs[m.ID] = m.Key
+	e.records[m.Key] = m
+	e.media = append(e.media, Media{
+		Key:          m.Key,
+		Purpose:      m.Purpose,
+		OriginalName: m.OriginalName,
+		ContentType:  m.ContentType,
+		Size:         m.Size,
+		Width:        m.Width,
+		Height:       m.Height,
+		File:         mediaDir + m.Key,
+	})
+	return m.Key, nil
+}
+
+// taxonomy ส่งออก taxonomy ที่ใช้งานอยู่ทั้งหมด ส่วนรายการที่ถูกยกเลิกแล้วส่งออกเฉพาะที่กิจกรรมใน package อ้างถึง
+func (e *exporter) taxonomy(activities []Activity) (*Taxonomy, error) {
+	usedSubGoals := map[uint]bool{}
+	usedSubCategories := map[uint]bool{}
+	for _, a := range activities {
+		for _, id := range a.SubGoalIDs {
+			usedSubGoals[id] = true
+		}
+		for _, id := range a.SubCategoryIDs {
+			usedSubCategories[id] = true
+		}
+		for _, step := range a.Steps {
+			if step.SubGoalID != nil {
+				usedSubGoals[*step.SubGoalID] = true
+			}
+		}
+		for _, v := range a.Variants {
+			for _, id := range v.SubGoalIDs {
+				usedSubGoals[id] = true
+			}
+		}
+	}
+
+	var goals []models.ActivityGoal
+	if err := e.db.Preload("SubGoals", orderByID).Order("id ASC").Find(&goals).Error; err != nil {
+		return nil, err
+	}
+	var categories []models.ActivityMainCategory
+	if err := e.db.Preload("SubCategories", orderByID).Order("id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	t := &Taxonomy{Goals: []Goal{}, Categories: []Category{}}
+	for _, g := range goals {
+		goal := Goal{ID: g.ID, Name: g.GoalName, SubGoals: []Named{}}
+		for _, sg := range g.SubGoals {
+			if sg.RetiredAt == nil || usedSubGoals[sg.ID] {
+				goal.SubGoals = append(goal.SubGoals, Named{ID: sg.ID, Name: sg.SubGoalName})
+			}
+		}
+		if g.RetiredAt == nil || len(goal.SubGoals) > 0 {
+			t.Goals = append(t.Goals, goal)
+		}
+	}
+	for _, c := range categories {
+		category := Category{ID: c.ID, Name: c.CategoryName, SubCategories: []Named{}}
+		for _, sc := range c.SubCategories {
+			if sc.RetiredAt == nil || usedSubCategories[sc.ID] {
+				category.SubCategories = append(category.SubCategories, Named{ID: sc.ID, Name: sc.SubCategoryName})
+			}
+		}
+		if c.RetiredAt == nil || len(category.SubCategories) > 0 {
+			t.Categories = append(t.Categories, category)
+		}
+	}
+	return t, nil
+}
+
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
+
+func (e *exporter) activities(ids []uint) ([]Activity, error) {
+	query := e.db.
+		Preload("SubGoals").
+		Preload("SubCategories").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Variants.SubGoals").
+		Preload("Variants.SelectedEquipment").
+		Preload("Variants.Songs").
+		Preload("EquipmentItems").
+		Preload("Songs").
+		Preload("TargetPopulations", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Preload("QRCodes", func(db *gorm.DB) *gorm.DB {
+			return db.Select("activity_id", "slot", "target_url", "level").Order("slot ASC")
+		}).
+		Order("id ASC")
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var rows []models.Activity
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 && len(rows) != len(uniqueIDs(ids)) {
+		return nil, fmt.Errorf("some activity ids were not found")
+	}
+
+	activities := make([]Activity, 0, len(rows))
+	for _, a := range rows {
+		out := Activity{
+			ID:                 a.ID,
+			Title:              a.Title,
+			Status:             a.Status,
+			CoverImage:         a.CoverImage,
+			GoalDescription:    a.GoalDescription,
+			Equipment:          a.Equipment,
+			Process:            a.Process,
+			ObservableBehavior: a.ObservableBehavior,
+			Suggestion:         a.Suggestion,
+			Song:               a.Song,
+			SongImage:          a.SongImage,
+			SubGoalIDs:         []uint{},
+			SubCategoryIDs:     []uint{},
+			Steps:              []Step{},
+			EquipmentItems:     []EquipmentItem{},
+			SongIDs:            []uint{},
+			QRCodes:            []QRCode{},
+			AgeMin:             a.AgeMin,
+			AgeMax:             a.AgeMax,
+			GroupSizeMin:       a.GroupSizeMin,
+			GroupSizeMax:       a.GroupSizeMax,
+			DurationMinutes:    a.DurationMinutes,
+			Difficulty:         a.Difficulty,
+			Contraindications:  a.Contraindications,
+			SafetyNotes:        a.SafetyNotes,
+		}
+		for _, tp := range a.TargetPopulations {
+			out.TargetPopulations = append(out.TargetPopulations, tp.Name)
+		}
+
+		// คอลัมน์ qr ของช่องที่ระบบสร้าง QR ให้เป็น URL ของระบบต้นทาง จึงไม่ส่งออก
+		generated := map[int]bool{}
+		for _, q := range a.QRCodes {
+			generated[q.Slot] = true
+			out.QRCodes = append(out.QRCodes, QRCode{Slot: q.Slot, TargetURL: q.TargetURL, Level: q.Level})
+		}
+		if !generated[1] {
+			out.QR1 = a.QR1
+		}
+		if !generated[2] {
+			out.QR2 = a.QR2
+		}
+
+		var err error
+		if out.CoverMedia, err = e.mediaRef(a.CoverMediaID); err != nil {
+			return nil, err
+		}
+		if out.SongImageMedia, err = e.mediaRef(a.SongImageMediaID); err != nil {
+			return nil, err
+		}
+		for _, sg := range a.SubGoals {
+			out.SubGoalIDs = append(out.SubGoalIDs, sg.ID)
+		}
+		for _, sc := range a.SubCategories {
+			out.SubCategoryIDs = append(out.SubCategoryIDs, sc.ID)
+		}
+		for _, s := range a.Steps {
+			step := Step{
+				Instruction:     s.Instruction,
+				DurationSeconds: s.DurationSeconds,
+				SubGoalID:       s.SubGoalID,
+				FacilitatorCue:  s.FacilitatorCue,
+				MediaURL:        s.MediaURL,
+			}
+			if step.Media, err = e.mediaRef(s.MediaID); err != nil {
+				return nil, err
+			}
+			out.Steps = append(out.Steps, step)
+		}
+		for _, v := range a.Variants {
+			variant := Variant{
+				Level:     v.Level,
+				Label:     v.Label,
+				Process:   v.Process,
+				Equipment: v.Equipment,
+				Song:      v.Song,
+			}
+			for _, sg := range v.SubGoals {
+				variant.SubGoalIDs = append(variant.SubGoalIDs, sg.ID)
+			}
+			for _, eq := range v.SelectedEquipment {
+				variant.EquipmentIDs = append(variant.EquipmentIDs, eq.ID)
+			}
+			for _, song := range v.Songs {
+				variant.SongIDs = append(variant.SongIDs, song.ID)
+			}
+			out.Variants = append(out.Variants, variant)
+		}
+		for _, item := range a.EquipmentItems {
+			out.EquipmentItems = append(out.EquipmentItems, EquipmentItem{
+				EquipmentID:  item.EquipmentID,
+				Quantity:     item.Quantity,
+				PerGroupSize: item.PerGroupSize,
+			})
+		}
+		for _, song := range a.Songs {
+			out.SongIDs = append(out.SongIDs, song.ID)
+		}
+		activities = append(activities, out)
+	}
+	return activities, nil
+}
+
+func (e *exporter) songs(activities []Activity, all bool) ([]Song, error) {
+	query := e.db.Order("id ASC")
+	if !all {
+		query = query.Where("id IN ?", referencedIDs(activities, func(a Activity) []uint { return a.SongIDs }))
+	}
+	var rows []models.Song
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	songs := make([]Song, 0, len(rows))
+	for _, s := range rows {
+		out := Song{
+			ID:            s.ID,
+			Title:         s.Title,
+			Composer:      s.Composer,
+			Lyricist:      s.Lyricist,
+			Language:      s.Language,
+			TempoBPM:      s.TempoBPM,
+			Key:           s.Key,
+			TimeSignature: s.TimeSignature,
+			Lyrics:        s.Lyrics,
+			CoverImage:    s.CoverImage,
+			AudioURL:      s.AudioURL,
+		}
+		var err error
+		if out.CoverMedia, err = e.mediaRef(s.CoverMediaID); err != nil {
+			return nil, err
+		}
+		if out.AudioMedia, err = e.mediaRef(s.AudioMediaID); err != nil {
+			return nil, err
+		}
+		songs = append(songs, out)
+	}
+	return songs, nil
+}
+
+func (e *exporter) equipment(activities []Activity, all bool) ([]Equipment, error) {
+	query := e.db.Order("id ASC")
+	if !all {
+		query = query.Where("id IN ?", referencedIDs(activities, func(a Activity) []uint {
+			ids := make([]uint, 0, len(a.EquipmentItems))
+			for _, item := range a.EquipmentItems {
+				ids = append(ids, item.EquipmentID)
+			}
+			return ids
+		}))
+	}
+	var rows []models.Equipment
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	equipment := make([]Equipment, 0, len(rows))
+	for _, item := range rows {
+		equipment = append(equipment, Equipment{ID: item.ID, Name: item.Name, Type: item.Type, ImageURL: item.ImageURL})
+	}
+	return equipment, nil
+}
+
+// referencedIDs รวม ID ที่กิจกรรมอ้างถึง คืน slice ที่มีอย่างน้อยหนึ่งค่าเพื่อใช้กับ IN ได้เสมอ
+func referencedIDs(activities []Activity, pick func(Activity) []uint) []uint {
+	var ids []uint
+	for _, a := range activities {
+		ids = append(ids, pick(a)...)
+	}
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return []uint{0}
+	}
+	return ids
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,176 @@
+// Package library ส่งออกและนำเข้าคลังกิจกรรมทั้งชุดเป็นไฟล์ zip (package) ที่มีเวอร์ชัน
+// ใช้สำรองข้อมูล ย้ายข้อมูลระหว่าง staging/production หรือส่งชุดกิจกรรมให้คลินิกอื่น
+//
+// โครงสร้างไฟล์ใน package (FormatVersion 1):
+//
+//	manifest.json    ชื่อรูปแบบ เวอร์ชัน วันที่ส่งออก และจำนวนรายการ
+//	taxonomy.json    เป้าหมาย/เป้าหมายย่อย และหมวดหมู่/หมวดหมู่ย่อยทั้งหมด
+//	equipment.json   อุปกรณ์ที่ถูกอ้างอิง
+//	songs.json       เพลงที่ถูกอ้างอิง
+//	activities.json  กิจกรรมพร้อมขั้นตอน อุปกรณ์ เพลง QR และกลุ่มเป้าหมาย
+//	media.json       ข้อมูลไฟล์สื่อ
+//	media/<key>      ไฟล์ต้นฉบับของสื่อแต่ละไฟล์
+//
+// ID ทุกตัวใน package เป็น ID ของระบบต้นทาง ใช้อ้างอิงกันภายใน package เท่านั้น
+// ตอนนำเข้าจะจับคู่ taxonomy ตามชื่อ และสร้าง ID ใหม่ของระบบปลายทาง
+package library
+
+import "time"
+
+const (
+	FormatName    = "music-therapy-library"
+	FormatVersion = 1
+
+	fileManifest   = "manifest.json"
+	fileTaxonomy   = "taxonomy.json"
+	fileEquipment  = "equipment.json"
+	fileSongs      = "songs.json"
+	fileActivities = "activities.json"
+	fileMedia      = "media.json"
+	mediaDir       = "media/"
+)
+
+type Manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Counts     Counts    `json:"counts"`
+	Warnings   []string  `json:"warnings,omitempty"`
+}
+
+type Counts struct {
+	Activities    int `json:"activities"`
+	Songs         int `json:"songs"`
+	Equipment     int `json:"equipment"`
+	Media         int `json:"media"`
+	Goals         int `json:"goals"`
+	SubGoals      int `json:"sub_goals"`
+	Categories    int `json:"categories"`
+	SubCategories int `json:"sub_categories"`
+}
+
+type Taxonomy struct {
+	Goals      []Goal     `json:"goals"`
+	Categories []Category `json:"categories"`
+}
+
+type Goal struct {
+	ID       uint    `json:"id"`
+	Name     string  `json:"name"`
+	SubGoals []Named `json:"sub_goals"`
+}
+
+type Category struct {
+	ID            uint    `json:"id"`
+	Name          string  `json:"name"`
+	SubCategories []Named `json:"sub_categories"`
+}
+
+type Named struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type Equipment struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// Media อ้างอิงด้วย Key ซึ่งไม่ซ้ำกันข้ามระบบ (uuid) จึงนำเข้าซ้ำได้โดยไม่เกิดไฟล์ซ้ำ
+type Media struct {
+	Key          string `json:"key"`
+	Purpose      string `json:"purpose"`
+	OriginalName string `json:"original_name,omitempty"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	File         string `json:"file"`
+}
+
+type Song struct {
+	ID            uint   `json:"id"`
+	Title         string `json:"title"`
+	Composer      string `json:"composer,omitempty"`
+	Lyricist      string `json:"lyricist,omitempty"`
+	Language      string `json:"language,omitempty"`
+	TempoBPM      *int   `json:"tempo_bpm,omitempty"`
+	Key           string `json:"key,omitempty"`
+	TimeSignature string `json:"time_signature,omitempty"`
+	Lyrics        string `json:"lyrics,omitempty"`
+	CoverImage    string `json:"cover_image,omitempty"`
+	AudioURL      string `json:"audio_url,omitempty"`
+	CoverMedia    string `json:"cover_media,omitempty"`
+	AudioMedia    string `json:"audio_media,omitempty"`
+}
+
+type Activity struct {
+	ID                 uint            `json:"id"`
+	Title              string          `json:"title"`
+	Status             string          `json:"status,omitempty"`
+	CoverImage         string          `json:"cover_image,omitempty"`
+	GoalDescription    string          `json:"goal_description,omitempty"`
+	Equipment          string          `json:"equipment,omitempty"`
+	Process            string          `json:"process,omitempty"`
+	ObservableBehavior string          `json:"observable_behavior,omitempty"`
+	Suggestion         string          `json:"suggestion,omitempty"`
+	Song               string          `json:"song,omitempty"`
+	SongImage          string          `json:"song_image,omitempty"`
+	QR1                string          `json:"qr_1,omitempty"`
+	QR2                string          `json:"qr_2,omitempty"`
+	CoverMedia         string          `json:"cover_media,omitempty"`
+	SongImageMedia     string          `json:"song_image_media,omitempty"`
+	SubGoalIDs         []uint          `json:"sub_goal_ids"`
+	SubCategoryIDs     []uint          `json:"sub_category_ids"`
+	Steps              []Step          `json:"steps"`
+	EquipmentItems     []EquipmentItem `json:"equipment_items"`
+	SongIDs            []uint          `json:"song_ids"`
+	QRCodes            []QRCode        `json:"qr_codes"`
+	Variants           []Variant       `json:"variants,omitempty"`
+
+	AgeMin            *int   `json:"age_min,omitempty"`
+	AgeMax            *int   `json:"age_max,omitempty"`
+	GroupSizeMin      *int   `json:"group_size_min,omitempty"`
+	GroupSizeMax      *int   `json:"group_size_max,omitempty"`
+	DurationMinutes   *int   `json:"duration_minutes,omitempty"`
+	Difficulty        *int   `json:"difficulty,omitempty"`
+	Contraindications string `json:"contraindications,omitempty"`
+	SafetyNotes       string `json:"safety_notes,omitempty"`
+	// TargetPopulations เก็บเป็นชื่อ ตอนนำเข้าจะจับคู่ตามชื่อหรือสร้างใหม่
+	TargetPopulations []string `json:"target_populations,omitempty"`
+}
+
+type Step struct {
+	Instruction     string `json:"instruction"`
+	DurationSeconds *int   `json:"duration_seconds,omitempty"`
+	SubGoalID       *uint  `json:"sub_goal_id,omitempty"`
+	FacilitatorCue  string `json:"facilitator_cue,omitempty"`
+	MediaURL        string `json:"media_url,omitempty"`
+	Media           string `json:"media,omitempty"`
+}
+
+// Variant อ้างอิงอุปกรณ์และเพลงด้วย ID ในแพ็กเกจ ซึ่งต้องอยู่ใน EquipmentItems/SongIDs ของกิจกรรมเดียวกัน
+type Variant struct {
+	Level        string `json:"level"`
+	Label        string `json:"label"`
+	Process      string `json:"process,omitempty"`
+	Equipment    string `json:"equipment,omitempty"`
+	Song         string `json:"song,omitempty"`
+	SubGoalIDs   []uint `json:"sub_goal_ids,omitempty"`
+	EquipmentIDs []uint `json:"equipment_ids,omitempty"`
+	SongIDs      []uint `json:"song_ids,omitempty"`
+}
+
+type EquipmentItem struct {
+	EquipmentID  uint `json:"equipment_id"`
+	Quantity     int  `json:"quantity"`
+	PerGroupSize int  `json:"per_group_size"`
+}
+
+type QRCode struct {
+	Slot      int    `json:"slot"`
+	TargetURL string `json:"target_url"`
+	Level     string `json:"level"`
+}
@@ -0,0 +1,690 @@
+package library
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"project-backend/config"
+	"project-backend/helpers"
+	"project-backend/models"
+	"project-backend/qrcodes"
+	"project-backend/storage"
+	"project-backend/thumbnail"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ActionMatched  = "matched"
+	ActionCreated  = "created"
+	ActionConflict = "conflict"
+	ActionSkipped  = "skipped"
+	ActionInvalid  = "invalid"
+
+	// OnDuplicateSkip ข้ามกิจกรรมที่มีชื่อซ้ำกับกิจกรรมในระบบ (ค่าเริ่มต้น ทำให้นำเข้า package เดิมซ้ำได้)
+	OnDuplicateSkip = "skip"
+	// OnDuplicateCreate สร้างกิจกรรมใหม่แม้ชื่อซ้ำ
+	OnDuplicateCreate = "create"
+)
+
+var (
+	// ErrConflicts ถูกคืนเมื่อจับคู่ taxonomy ไม่ได้และไม่ได้เลือก SkipConflicts
+	ErrConflicts = errors.New("library package has taxonomy conflicts; nothing was imported")
+	// ErrInvalidActivities ถูกคืนเมื่อมีกิจกรรมที่ไม่ผ่านการตรวจสอบ ดูรายละเอียดใน ActivityResult.Errors
+	ErrInvalidActivities = errors.New("library package has invalid activities; nothing was imported")
+
+	errDryRun = errors.New("dry run")
+)
+
+type ImportOptions struct {
+	DryRun bool
+	// SkipConflicts นำเข้าต่อโดยตัดการอ้างอิง taxonomy ที่จับคู่ไม่ได้ออก
+	SkipConflicts bool
+	OnDuplicate   string
+	// OwnerID เป็นเจ้าของไฟล์สื่อและ admin ของกิจกรรมที่สร้างใหม่
+	OwnerID uint
+	QR      *config.QRConfig
+	// MaxMediaBytes คือขนาดสูงสุดของไฟล์สื่อแต่ละไฟล์ ใช้ค่าเดียวกับการอัปโหลด (StorageConfig.MaxUploadBytes) ต้องกำหนดเสมอ
+	MaxMediaBytes int64
+	// Validate ตรวจกิจกรรมด้วยกฎเดียวกับการสร้างผ่าน API (controllers.ValidateImportedActivity)
+	Validate func(*models.Activity) []string
+}
+
+type TaxonomyResult struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Parent    string `json:"parent,omitempty"`
+	PackageID uint   `json:"package_id"`
+	LocalID   uint   `json:"local_id,omitempty"`
+	Action    string `json:"action"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type ItemCounts struct {
+	Matched int `json:"matched"`
+	Created int `json:"created"`
+}
+
+type ActivityResult struct {
+	PackageID  uint     `json:"package_id"`
+	Title      string   `json:"title"`
+	Action     string   `json:"action"`
+	ActivityID uint     `json:"activity_id,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun         bool             `json:"dry_run"`
+	PackageVersion int              `json:"package_version"`
+	ExportedAt     time.Time        `json:"exported_at"`
+	Taxonomy       []TaxonomyResult `json:"taxonomy"`
+	Conflicts      []TaxonomyResult `json:"conflicts"`
+	Equipment      ItemCounts       `json:"equipment"`
+	Songs          ItemCounts       `json:"songs"`
+	Media          ItemCounts       `json:"media"`
+	Populations    ItemCounts       `json:"target_populations"`
+	Activities     []ActivityResult `json:"activities"`
+	Created        int              `json:"created"`
+	Skipped        int              `json:"skipped"`
+	Invalid        int              `json:"invalid"`
+
+	// NewMediaIDs คือไฟล์สื่อที่สร้างใหม่ ผู้เรียกส่งเข้าคิวสร้างภาพย่อได้ทันที
+	NewMediaIDs []uint `json:"-"`
+}
+
+// Package คือ package ที่อ่านและตรวจสอบแล้ว พร้อมนำเข้า
+type Package struct {
+	zip        *zip.Reader
+	files      map[string]*zip.File
+	manifest   Manifest
+	taxonomy   Taxonomy
+	equipment  []Equipment
+	songs      []Song
+	activities []Activity
+	media      map[string]Media
+}
+
+// Open อ่านและตรวจสอบ package (รูปแบบ เวอร์ชัน และไฟล์สื่อที่อ้างถึง) โดยยังไม่แตะฐานข้อมูล
+func Open(r io.ReaderAt, size int64) (*Package, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package: %w", err)
+	}
+	p := &Package{zip: zr, media: map[string]Media{}}
+
+	if err := p.readJSON(fileManifest, &p.manifest); err != nil {
+		return nil, err
+	}
+	if p.manifest.Format != FormatName {
+		return nil, fmt.Errorf("invalid package: unknown format %q", p.manifest.Format)
+	}
+	if p.manifest.Version < 1 || p.manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported package version %d (this server reads up to version %d)", p.manifest.Version, FormatVersion)
+	}
+
+	var media []Media
+	for name, target := range map[string]any{
+		fileTaxonomy:   &p.taxonomy,
+		fileEquipment:  &p.equipment,
+		fileSongs:      &p.songs,
+		fileActivities: &p.activities,
+		fileMedia:      &media,
+	} {
+		if err := p.readJSON(name, target); err != nil {
+			return nil, err
+		}
+	}
+
+	p.files = make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		p.files[f.Name] = f
+	}
+	for _, m := range media {
+		if m.Key == "" || path.Base(m.Key) != m.Key || p.files[m.File] == nil {
+			return nil, fmt.Errorf("invalid package: media %q has no file", m.Key)
+		}
+		if _, ok := models.MediaContentTypes[m.Purpose]; !ok {
+			return nil, fmt.Errorf("invalid package: media %q has unknown purpose %q", m.Key, m.Purpose)
+		}
+		p.media[m.Key] = m
+	}
+	return p, nil
+}
+
+func (p *Package) readJSON(name string, target any) error {
+	f, err := p.zip.Open(name)
+	if err != nil {
+		return fmt.Errorf("invalid package: missing %s", name)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(target); err != nil {
+		return fmt.Errorf("invalid package: %s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *Package) Manifest() Manifest {
+	return p.manifest
+}
+
+func (p *Package) ActivityCount() int {
+	return len(p.activities)
+}
+
+// Import นำเข้า package ทั้งหมดใน Transaction เดียว
+// ถ้า DryRun จะทำทุกขั้นตอนแล้ว rollback (ไม่เขียนไฟล์สื่อลง storage) เพื่อดูรายงานก่อนนำเข้าจริง
+func Import(ctx context.Context, db *gorm.DB, store storage.Storage, p *Package, opts ImportOptions, progress func(processed, total int)) (*ImportReport, error) {
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+	if opts.OnDuplicate == "" {
+		opts.OnDuplicate = OnDuplicateSkip
+	}
+	if opts.OnDuplicate != OnDuplicateSkip && opts.OnDuplicate != OnDuplicateCreate {
+		return nil, fmt.Errorf("on_duplicate must be %q or %q", OnDuplicateSkip, OnDuplicateCreate)
+	}
+
+	report := &ImportReport{
+		DryRun:         opts.DryRun,
+		PackageVersion: p.manifest.Version,
+		ExportedAt:     p.manifest.ExportedAt,
+		Taxonomy:       []TaxonomyResult{},
+		Conflicts:      []TaxonomyResult{},
+		Activities:     []ActivityResult{},
+	}
+	im := &importer{ctx: ctx, store: store, pkg: p, opts: opts, report: report, media: map[string]uint{}, populations: map[string]uint{}}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		im.tx = tx
+		if err := im.reconcileTaxonomy(); err != nil {
+			return err
+		}
+		if len(report.Conflicts) > 0 && !opts.SkipConflicts {
+			return ErrConflicts
+		}
+		if err := im.importEquipment(); err != nil {
+			return err
+		}
+		if err := im.importSongs(); err != nil {
+			return err
+		}
+		for i, a := range p.activities {
+			if err := im.importActivity(a); err != nil {
+				return fmt.Errorf("activity %q: %w", a.Title, err)
+			}
+			progress(i+1, len(p.activities))
+		}
+		if report.Invalid > 0 {
+			return ErrInvalidActivities
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && err != errDryRun {
+		// Transaction ถูก rollback แล้ว จึงลบไฟล์ที่เขียนลง storage ไปแล้วด้วย
+		for _, key := range im.storedKeys {
+			store.Delete(context.Background(), key)
+		}
+		report.NewMediaIDs = nil
+		return report, err
+	}
+	if opts.DryRun {
+		report.NewMediaIDs = nil
+	}
+	return report, nil
+}
+
+type importer struct {
+	ctx    context.Context
+	tx     *gorm.DB
+	store  storage.Storage
+	pkg    *Package
+	opts   ImportOptions
+	report *ImportReport
+
+	subGoals      map[uint]uint
+	subCategories map[uint]uint
+	equipment     map[uint]uint
+	songs         map[uint]uint
+	media         map[string]uint
+	populations   map[string]uint
+	storedKeys    []string
+}
+
+func (im *importer) record(result TaxonomyResult) {
+	im.report.Taxonomy = append(im.report.Taxonomy, result)
+	if result.Action == ActionConflict {
+		im.report.Conflicts = append(im.report.Conflicts, result)
+	}
+}
+
+func (im *importer) importEquipment() error {
+	im.equipment = make(map[uint]uint, len(im.pkg.equipment))
+	for _, e := range im.pkg.equipment {
+		var local models.Equipment
+		err := im.tx.Where("LOWER(name) = LOWER(?)", strings.TrimSpace(e.Name)).First(&local).Error
+		switch {
+		case err == nil:
+			im.report.Equipment.Matched++
+		case err == gorm.ErrRecordNotFound:
+			local = models.Equipment{Name: strings.TrimSpace(e.Name), Type: e.Type, ImageURL: e.ImageURL}
+			if err := im.tx.Create(&local).Error; err != nil {
+				return err
+			}
+			im.report.Equipment.Created++
+		default:
+			return err
+		}
+		im.equipment[e.ID] = local.ID
+	}
+	return nil
+}
+
+// targetPopulation จับคู่กลุ่มเป้าหมายตามชื่อ (ไม่สนตัวพิมพ์) ถ้าไม่พบจึงสร้างใหม่ ชื่อว่างคืน 0
+func (im *importer) targetPopulation(name string) (uint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, nil
+	}
+	key := strings.ToLower(name)
+	if id, ok := im.populations[key]; ok {
+		return id, nil
+	}
+
+	var local models.TargetPopulation
+	err := im.tx.Where("LOWER(name) = ?", key).First(&local).Error
+	switch {
+	case err == nil:
+		im.report.Populations.Matched++
+	case err == gorm.ErrRecordNotFound:
+		local = models.TargetPopulation{Name: name}
+		if err := im.tx.Create(&local).Error; err != nil {
+			return 0, err
+		}
+		im.report.Populations.Created++
+	default:
+		return 0, err
+	}
+	im.populations[key] = local.ID
+	return local.ID, nil
+}
+
+// importSongs จับคู่เพลงด้วยชื่อที่ normalize แล้ว (แบบเดียวกับการรวมเพลงซ้ำ) ถ้าไม่พบจึงสร้างใหม่
+func (im *importer) importSongs() error {
+	im.songs = make(map[uint]uint, len(im.pkg.songs))
+	for _, s := range im.pkg.songs {
+		var local models.Song
+		err := im.tx.Where("normalized_title = ?", models.NormalizeSongTitle(s.Title)).Order("id ASC").First(&local).Error
+		switch {
+		case err == nil:
+			im.report.Songs.Matched++
+		case err == gorm.ErrRecordNotFound:
+			local = models.Song{
+				Title:         s.Title,
+				Composer:      s.Composer,
+				Lyricist:      s.Lyricist,
+				Language:      s.Language,
+				TempoBPM:      s.TempoBPM,
+				Key:           s.Key,
+				TimeSignature: s.TimeSignature,
+				Lyrics:        s.Lyrics,
+				CoverImage:    s.CoverImage,
+				AudioURL:      s.AudioURL,
+			}
+			if local.CoverMediaID, err = im.mediaRef(s.CoverMedia); err != nil {
+				return err
+			}
+			if local.AudioMediaID, err = im.mediaRef(s.AudioMedia); err != nil {
+				return err
+			}
+			if local.CoverMediaID != nil {
+				local.CoverImage = models.MediaURL(s.CoverMedia)
+			}
+			if local.AudioMediaID != nil {
+				local.AudioURL = models.MediaURL(s.AudioMedia)
+			}
+			if err := im.tx.Create(&local).Error; err != nil {
+				return err
+			}
+			im.report.Songs.Created++
+		default:
+			return err
+		}
+		im.songs[s.ID] = local.ID
+	}
+	return nil
+}
+
+// mediaRef คืน media_id ของไฟล์ในระบบปลายทาง ถ้ายังไม่มี key นี้จะคัดลอกไฟล์ลง storage และสร้าง record
+func (im *importer) mediaRef(key string) (*uint, error) {
+	if key == "" {
+		return nil, nil
+	}
+	if id, ok := im.media[key]; ok {
+		return &id, nil
+	}
+	m, ok := im.pkg.media[key]
+	if !ok {
+		return nil, nil
+	}
+
+	var local models.Media
+	err := im.tx.Where("media_key = ?", key).First(&local).Error
+	if err == nil {
+		im.media[key] = local.ID
+		im.report.Media.Matched++
+		return &local.ID, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	contentType, size, err := im.checkMediaFile(m)
+	if err != nil {
+		return nil, fmt.Errorf("media %s: %w", key, err)
+	}
+
+	now := time.Now()
+	local = models.Media{
+		Key:          m.Key,
+		OwnerID:      im.opts.OwnerID,
+		Purpose:      m.Purpose,
+		OriginalName: m.OriginalName,
+		ContentType:  contentType,
+		Size:         size,
+		Width:        m.Width,
+		Height:       m.Height,
+		Backend:      im.store.Name(),
+		StorageKey:   fmt.Sprintf("%s/%04d/%02d/%s", m.Purpose, now.Year(), now.Month(), m.Key),
+	}
+	if thumbnail.NeedsVariants(m.Purpose) {
+		local.VariantStatus = models.VariantStatusPending
+	}
+
+	if !im.opts.DryRun {
+		if err := im.copyMediaFile(m, &local); err != nil {
+			return nil, fmt.Errorf("media %s: %w", key, err)
+		}
+	}
+	// ลบ record ที่เคยถูก soft delete ด้วย key เดียวกันออก เพื่อไม่ให้ชน unique index
+	if err := im.tx.Unscoped().Where("media_key = ?", key).Delete(&models.Media{}).Error; err != nil {
+		return nil, err
+	}
+	if err := im.tx.Create(&local).Error; err != nil {
+		return nil, err
+	}
+
+	im.media[key] = local.ID
+	im.report.Media.Created++
+	im.report.NewMediaIDs = append(im.report.NewMediaIDs, local.ID)
+	return &local.ID, nil
+}
+
+// checkMediaFile ตรวจไฟล์สื่อใน package ด้วยกฎเดียวกับ UploadMedia ไม่เชื่อ content_type และ size ที่ package ระบุ
+// ชนิดไฟล์ตรวจจากเนื้อหาเทียบกับชนิดที่ purpose รับได้ ขนาดใช้ขนาดจริงของไฟล์ใน zip
+// (archive/zip คืน error ถ้าอ่านได้เกินขนาดนี้) และต้องไม่เกิน MaxMediaBytes
+func (im *importer) checkMediaFile(m Media) (string, int64, error) {
+	zf := im.pkg.files[m.File]
+	if zf.UncompressedSize64 == 0 {
+		return "", 0, fmt.Errorf("file is empty")
+	}
+	if zf.UncompressedSize64 > uint64(im.opts.MaxMediaBytes) {
+		return "", 0, fmt.Errorf("file is too large (max %d MB)", im.opts.MaxMediaBytes>>20)
+	}
+	size := int64(zf.UncompressedSize64)
+
+	f, err := zf.Open()
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(f, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", 0, err
+	}
+	contentType := http.DetectContentType(sniff[:n])
+	if _, ok := models.MediaContentTypes[m.Purpose][contentType]; !ok {
+		return "", 0, fmt.Errorf("unsupported file type %s for purpose %s", contentType, m.Purpose)
+	}
+	return contentType, size, nil
+}
+
+func (im *importer) copyMediaFile(m Media, local *models.Media) error {
+	f, err := im.pkg.files[m.File].Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := im.store.Put(im.ctx, local.StorageKey, f, local.Size, local.ContentType); err != nil {
+		return err
+	}
+	im.storedKeys = append(im.storedKeys, local.StorageKey)
+	return nil
+}
+
+func (im *importer) importActivity(a Activity) error {
+	result := ActivityResult{PackageID: a.ID, Title: a.Title}
+
+	if im.opts.OnDuplicate == OnDuplicateSkip {
+		var count int64
+		if err := im.tx.Model(&models.Activity{}).Where("LOWER(title) = LOWER(?)", a.Title).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			result.Action = ActionSkipped
+			result.Warnings = append(result.Warnings, "an activity with this title already exists")
+			im.report.Activities = append(im.report.Activities, result)
+			im.report.Skipped++
+			return nil
+		}
+	}
+
+	activity := models.Activity{
+		Title:              a.Title,
+		CoverImage:         a.CoverImage,
+		GoalDescription:    a.GoalDescription,
+		Equipment:          a.Equipment,
+		Process:            a.Process,
+		ObservableBehavior: a.ObservableBehavior,
+		Suggestion:         a.Suggestion,
+		Song:               a.Song,
+		SongImage:          a.SongImage,
+		QR1:                a.QR1,
+		QR2:                a.QR2,
+		AgeMin:             a.AgeMin,
+		AgeMax:             a.AgeMax,
+		GroupSizeMin:       a.GroupSizeMin,
+		GroupSizeMax:       a.GroupSizeMax,
+		DurationMinutes:    a.DurationMinutes,
+		Difficulty:         a.Difficulty,
+		Contraindications:  a.Contraindications,
+		SafetyNotes:        a.SafetyNotes,
+		AdminID:            im.opts.OwnerID,
+	}
+	if models.ValidActivityStatus(a.Status) {
+		activity.Status = a.Status
+	}
+
+	var err error
+	if activity.CoverMediaID, err = im.mediaRef(a.CoverMedia); err != nil {
+		return err
+	}
+	if activity.CoverMediaID != nil {
+		activity.CoverImage = models.MediaURL(a.CoverMedia)
+	}
+	if activity.SongImageMediaID, err = im.mediaRef(a.SongImageMedia); err != nil {
+		return err
+	}
+	if activity.SongImageMediaID != nil {
+		activity.SongImage = models.MediaURL(a.SongImageMedia)
+	}
+
+	for _, id := range a.SubGoalIDs {
+		local, ok := im.subGoals[id]
+		if !ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("sub-goal %d was not imported (conflict)", id))
+			continue
+		}
+		activity.SubGoals = append(activity.SubGoals, models.ActivitySubGoal{ID: local})
+	}
+	for _, id := range a.SubCategoryIDs {
+		local, ok := im.subCategories[id]
+		if !ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("sub-category %d was not imported (conflict)", id))
+			continue
+		}
+		activity.SubCategories = append(activity.SubCategories, models.ActivitySubCategory{ID: local})
+	}
+	for _, id := range a.SongIDs {
+		if local, ok := im.songs[id]; ok {
+			activity.Songs = append(activity.Songs, models.Song{ID: local})
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("song %d is missing from the package", id))
+		}
+	}
+	for _, name := range a.TargetPopulations {
+		local, err := im.targetPopulation(name)
+		if err != nil {
+			return err
+		}
+		if local != 0 {
+			activity.TargetPopulations = append(activity.TargetPopulations, models.TargetPopulation{ID: local})
+		}
+	}
+	for _, item := range a.EquipmentItems {
+		local, ok := im.equipment[item.EquipmentID]
+		if !ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("equipment %d is missing from the package", item.EquipmentID))
+			continue
+		}
+		activity.EquipmentItems = append(activity.EquipmentItems, models.ActivityEquipment{
+			EquipmentID:  local,
+			Quantity:     item.Quantity,
+			PerGroupSize: item.PerGroupSize,
+		})
+	}
+	for i, s := range a.Steps {
+		step := models.ActivityStep{
+			Position:        i + 1,
+			Instruction:     s.Instruction,
+			DurationSeconds: s.DurationSeconds,
+			FacilitatorCue:  s.FacilitatorCue,
+			MediaURL:        s.MediaURL,
+		}
+		if s.SubGoalID != nil {
+			if local, ok := im.subGoals[*s.SubGoalID]; ok {
+				step.SubGoalID = &local
+			}
+		}
+		if step.MediaID, err = im.mediaRef(s.Media); err != nil {
+			return err
+		}
+		activity.Steps = append(activity.Steps, step)
+	}
+	activityEquipment := make(map[uint]bool, len(activity.EquipmentItems))
+	for _, item := range activity.EquipmentItems {
+		activityEquipment[item.EquipmentID] = true
+	}
+	activitySongs := make(map[uint]bool, len(activity.Songs))
+	for _, song := range activity.Songs {
+		activitySongs[song.ID] = true
+	}
+	for i, v := range a.Variants {
+		if !models.ValidVariantLevel(v.Level) || strings.TrimSpace(v.Label) == "" {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("variant %d has an invalid level or empty label", i+1))
+			continue
+		}
+		variant := models.ActivityVariant{
+			Position:  len(activity.Variants) + 1,
+			Level:     v.Level,
+			Label:     v.Label,
+			Process:   v.Process,
+			Equipment: v.Equipment,
+			Song:      v.Song,
+		}
+		for _, id := range v.SubGoalIDs {
+			if local, ok := im.subGoals[id]; ok {
+				variant.SubGoals = append(variant.SubGoals, models.ActivitySubGoal{ID: local})
+			}
+		}
+		for _, id := range v.EquipmentIDs {
+			if local, ok := im.equipment[id]; ok && activityEquipment[local] {
+				variant.SelectedEquipment = append(variant.SelectedEquipment, models.Equipment{ID: local})
+			} else {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("variant %d: equipment %d is not in the activity's equipment items", i+1, id))
+			}
+		}
+		for _, id := range v.SongIDs {
+			if local, ok := im.songs[id]; ok && activitySongs[local] {
+				variant.Songs = append(variant.Songs, models.Song{ID: local})
+			} else {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("variant %d: song %d is not in the activity's songs", i+1, id))
+			}
+		}
+		activity.Variants = append(activity.Variants, variant)
+	}
+
+	// ตรวจ QR ด้วยกฎเดียวกับ API ก่อนบันทึก URL หรือระดับที่ไม่ถูกต้องทำให้ทั้งกิจกรรมเป็น invalid
+	qrs := make([]QRCode, 0, len(a.QRCodes))
+	for _, q := range a.QRCodes {
+		if _, ok := qrcodes.SlotColumns[q.Slot]; !ok {
+			continue
+		}
+		if err := helpers.ValidateQRTarget(strings.TrimSpace(q.TargetURL)); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("qr_%d_target %v", q.Slot, err))
+			continue
+		}
+		q.TargetURL = strings.TrimSpace(q.TargetURL)
+		if q.Level != "" {
+			level, err := helpers.ParseQRLevel(q.Level)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("qr_%d_level %v", q.Slot, err))
+				continue
+			}
+			q.Level = level
+		}
+		qrs = append(qrs, q)
+	}
+	if im.opts.Validate != nil {
+		result.Errors = append(result.Errors, im.opts.Validate(&activity)...)
+	}
+	if len(result.Errors) > 0 {
+		result.Action = ActionInvalid
+		im.report.Activities = append(im.report.Activities, result)
+		im.report.Invalid++
+		return nil
+	}
+
+	if err := im.tx.Omit("SubGoals.*", "SubCategories.*", "Songs.*", "TargetPopulations.*", "EquipmentItems.Equipment", "Variants.SubGoals.*", "Variants.SelectedEquipment.*", "Variants.Songs.*").Create(&activity).Error; err != nil {
+		return err
+	}
+
+	for _, q := range qrs {
+		if im.opts.QR == nil {
+			continue
+		}
+		if err := qrcodes.Sync(im.tx, im.opts.QR, activity.ID, q.Slot, q.TargetURL, q.Level); err != nil {
+			return err
+		}
+	}
+
+	result.Action = ActionCreated
+	result.ActivityID = activity.ID
+	im.report.Activities = append(im.report.Activities, result)
+	im.report.Created++
+	return nil
+}
@@ -0,0 +1,255 @@
+package library
+
+import (
+	"fmt"
+	"strings"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// entry คือรายการ taxonomy หนึ่งรายการ ใช้ได้ทั้งฝั่ง package และฝั่งระบบ
+// รายการในระบบที่ถูกยกเลิกจะมี Retired และถ้าถูกรวมเข้ากับรายการอื่นจะมี MergedInto
+type entry struct {
+	ID         uint
+	Name       string
+	Retired    bool
+	MergedInto uint
+	Children   []entry
+}
+
+// taxonomyKind กำหนดวิธีสร้างรายการใหม่ของ taxonomy แต่ละชนิด
+type taxonomyKind struct {
+	parent      string
+	child       string
+	createGroup func(tx *gorm.DB, name string) (uint, error)
+	createChild func(tx *gorm.DB, parentID uint, name string) (uint, error)
+}
+
+var goalKind = taxonomyKind{
+	parent: "goal",
+	child:  "sub_goal",
+	createGroup: func(tx *gorm.DB, name string) (uint, error) {
+		goal := models.ActivityGoal{GoalName: name, SortOrder: nextSortOrder(tx, "activity_goals", "", 0)}
+		err := tx.Create(&goal).Error
+		return goal.ID, err
+	},
+	createChild: func(tx *gorm.DB, parentID uint, name string) (uint, error) {
+		sub := models.ActivitySubGoal{GoalID: parentID, SubGoalName: name, SortOrder: nextSortOrder(tx, "activity_sub_goals", "goal_id", parentID)}
+		err := tx.Create(&sub).Error
+		return sub.ID, err
+	},
+}
+
+var categoryKind = taxonomyKind{
+	parent: "category",
+	child:  "sub_category",
+	createGroup: func(tx *gorm.DB, name string) (uint, error) {
+		cat := models.ActivityMainCategory{CategoryName: name, SortOrder: nextSortOrder(tx, "activity_main_categories", "", 0)}
+		err := tx.Create(&cat).Error
+		return cat.ID, err
+	},
+	createChild: func(tx *gorm.DB, parentID uint, name string) (uint, error) {
+		sub := models.ActivitySubCategory{CategoryID: parentID, SubCategoryName: name, SortOrder: nextSortOrder(tx, "activity_sub_categories", "category_id", parentID)}
+		err := tx.Create(&sub).Error
+		return sub.ID, err
+	},
+}
+
+// nextSortOrder ให้รายการที่สร้างใหม่ต่อท้ายรายการเดิมในกลุ่มเดียวกัน
+func nextSortOrder(tx *gorm.DB, table, parentColumn string, parentID uint) int {
+	var next int
+	query := tx.Table(table).Select("COALESCE(MAX(sort_order), -1) + 1")
+	if parentColumn != "" {
+		query = query.Where(parentColumn+" = ?", parentID)
+	}
+	query.Scan(&next)
+	return next
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func mergedInto(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// reconcileTaxonomy จับคู่ taxonomy ใน package กับระบบตามชื่อ
+// ชื่อที่ไม่มีในระบบจะถูกสร้างใหม่ ชื่อที่ตรงกับรายการที่ถูกรวมไปแล้วจะจับคู่กับรายการปลายทางของการรวม
+// ส่วนชื่อที่จับคู่ได้มากกว่าหนึ่งรายการ ตรงกับรายการที่ถูกยกเลิก หรือเป็นรายการย่อยที่อยู่ใต้รายการหลักอื่นในระบบ
+// จะถูกรายงานเป็น conflict และไม่ถูกจับคู่
+func (im *importer) reconcileTaxonomy() error {
+	var goals []models.ActivityGoal
+	if err := im.tx.Preload("SubGoals", models.TaxonomyOrder).Order("id ASC").Find(&goals).Error; err != nil {
+		return err
+	}
+	var categories []models.ActivityMainCategory
+	if err := im.tx.Preload("SubCategories", models.TaxonomyOrder).Order("id ASC").Find(&categories).Error; err != nil {
+		return err
+	}
+
+	localGoals := make([]entry, 0, len(goals))
+	for _, g := range goals {
+		lg := entry{ID: g.ID, Name: g.GoalName, Retired: g.RetiredAt != nil, MergedInto: mergedInto(g.MergedIntoID)}
+		for _, sg := range g.SubGoals {
+			lg.Children = append(lg.Children, entry{ID: sg.ID, Name: sg.SubGoalName, Retired: sg.RetiredAt != nil, MergedInto: mergedInto(sg.MergedIntoID)})
+		}
+		localGoals = append(localGoals, lg)
+	}
+	localCategories := make([]entry, 0, len(categories))
+	for _, c := range categories {
+		lc := entry{ID: c.ID, Name: c.CategoryName, Retired: c.RetiredAt != nil, MergedInto: mergedInto(c.MergedIntoID)}
+		for _, sc := range c.SubCategories {
+			lc.Children = append(lc.Children, entry{ID: sc.ID, Name: sc.SubCategoryName, Retired: sc.RetiredAt != nil, MergedInto: mergedInto(sc.MergedIntoID)})
+		}
+		localCategories = append(localCategories, lc)
+	}
+
+	pkgGoals := make([]entry, 0, len(im.pkg.taxonomy.Goals))
+	for _, g := range im.pkg.taxonomy.Goals {
+		pkgGoals = append(pkgGoals, entry{ID: g.ID, Name: g.Name, Children: namedEntries(g.SubGoals)})
+	}
+	pkgCategories := make([]entry, 0, len(im.pkg.taxonomy.Categories))
+	for _, c := range im.pkg.taxonomy.Categories {
+		pkgCategories = append(pkgCategories, entry{ID: c.ID, Name: c.Name, Children: namedEntries(c.SubCategories)})
+	}
+
+	var err error
+	if im.subGoals, err = im.reconcile(goalKind, pkgGoals, localGoals); err != nil {
+		return err
+	}
+	im.subCategories, err = im.reconcile(categoryKind, pkgCategories, localCategories)
+	return err
+}
+
+func namedEntries(items []Named) []entry {
+	out := make([]entry, 0, len(items))
+	for _, n := range items {
+		out = append(out, entry{ID: n.ID, Name: n.Name})
+	}
+	return out
+}
+
+// followMerge ไล่ MergedInto จนถึงรายการปลายทางที่ยังใช้งานอยู่ คืน false ถ้าปลายทางถูกยกเลิกโดยไม่ได้รวม
+func followMerge(e entry, byID map[uint]entry) (entry, bool) {
+	for seen := 0; e.Retired && e.MergedInto != 0 && seen < len(byID); seen++ {
+		next, ok := byID[e.MergedInto]
+		if !ok {
+			return e, false
+		}
+		e = next
+	}
+	return e, !e.Retired
+}
+
+// reconcile คืน map จาก ID รายการย่อยใน package ไปเป็น ID ในระบบ
+func (im *importer) reconcile(kind taxonomyKind, incoming, local []entry) (map[uint]uint, error) {
+	mapping := make(map[uint]uint)
+
+	groupsByID := make(map[uint]entry)
+	childrenByID := make(map[uint]entry)
+	groupsByName := make(map[string][]entry)
+	childParents := make(map[string][]string)
+	for _, g := range local {
+		groupsByID[g.ID] = g
+		for _, child := range g.Children {
+			childrenByID[child.ID] = child
+			if !g.Retired && !child.Retired {
+				childParents[nameKey(child.Name)] = append(childParents[nameKey(child.Name)], g.Name)
+			}
+		}
+		groupsByName[nameKey(g.Name)] = append(groupsByName[nameKey(g.Name)], g)
+	}
+
+	for _, g := range incoming {
+		result := TaxonomyResult{Kind: kind.parent, Name: g.Name, PackageID: g.ID}
+		target, detail, ok := pickLocal(groupsByName[nameKey(g.Name)], groupsByID)
+
+		switch {
+		case ok:
+			result.Action, result.LocalID, result.Detail = ActionMatched, target.ID, detail
+		case detail != "":
+			result.Action = ActionConflict
+			result.Detail = fmt.Sprintf("%s; its %d %s(s) were not mapped", detail, len(g.Children), kind.child)
+			im.record(result)
+			continue
+		default:
+			id, err := kind.createGroup(im.tx, strings.TrimSpace(g.Name))
+			if err != nil {
+				return nil, err
+			}
+			target = entry{ID: id, Name: g.Name}
+			result.Action, result.LocalID = ActionCreated, id
+		}
+		im.record(result)
+
+		children := make(map[string][]entry)
+		for _, child := range target.Children {
+			children[nameKey(child.Name)] = append(children[nameKey(child.Name)], child)
+		}
+
+		for _, child := range g.Children {
+			childResult := TaxonomyResult{Kind: kind.child, Name: child.Name, Parent: g.Name, PackageID: child.ID}
+			found, detail, ok := pickLocal(children[nameKey(child.Name)], childrenByID)
+
+			switch {
+			case ok:
+				childResult.Action, childResult.LocalID, childResult.Detail = ActionMatched, found.ID, detail
+				mapping[child.ID] = found.ID
+			case detail != "":
+				childResult.Action = ActionConflict
+				childResult.Detail = fmt.Sprintf("%s under %q", detail, target.Name)
+			case len(childParents[nameKey(child.Name)]) > 0:
+				childResult.Action = ActionConflict
+				childResult.Detail = fmt.Sprintf("exists locally under %s %q", kind.parent, strings.Join(childParents[nameKey(child.Name)], `", "`))
+			default:
+				id, err := kind.createChild(im.tx, target.ID, strings.TrimSpace(child.Name))
+				if err != nil {
+					return nil, err
+				}
+				childResult.Action, childResult.LocalID = ActionCreated, id
+				mapping[child.ID] = id
+			}
+			im.record(childResult)
+		}
+	}
+	return mapping, nil
+}
+
+// pickLocal เลือกรายการในระบบจากรายการที่ชื่อตรงกัน โดยให้รายการที่ใช้งานอยู่มาก่อน
+// คืน ok=false พร้อม detail เมื่อเป็น conflict และคืน ok=false โดยไม่มี detail เมื่อไม่พบรายการเลย
+func pickLocal(matches []entry, byID map[uint]entry) (entry, string, bool) {
+	var active, retired []entry
+	for _, m := range matches {
+		if m.Retired {
+			retired = append(retired, m)
+		} else {
+			active = append(active, m)
+		}
+	}
+
+	switch {
+	case len(active) == 1:
+		return active[0], "", true
+	case len(active) > 1:
+		ids := make([]string, 0, len(active))
+		for _, m := range active {
+			ids = append(ids, fmt.Sprint(m.ID))
+		}
+		return entry{}, fmt.Sprintf("%d local entries share this name (ids %s)", len(active), strings.Join(ids, ", ")), false
+	case len(retired) == 1:
+		target, ok := followMerge(retired[0], byID)
+		if !ok {
+			return entry{}, fmt.Sprintf("local id %d with this name is retired", retired[0].ID), false
+		}
+		return target, fmt.Sprintf("local id %d was merged into %q", retired[0].ID, target.Name), true
+	case len(retired) > 1:
+		return entry{}, fmt.Sprintf("%d retired local entries share this name", len(retired)), false
+	}
+	return entry{}, "", false
+}
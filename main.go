@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
 
 	"project-backend/config"
 	"project-backend/db"
+	"project-backend/handout"
+	"project-backend/jobs"
+	"project-backend/migrations"
 	"project-backend/models"
+	"project-backend/recommend"
+	"project-backend/related"
 	"project-backend/router"
 	"project-backend/seeds"
+	"project-backend/storage"
+	"project-backend/thumbnail"
 
 	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
@@ -48,8 +56,38 @@ func main() {
 		&models.ActivitySubGoal{},
 		&models.ActivityMainCategory{},
 		&models.ActivitySubCategory{},
+		&models.ActivityStep{},
+		&models.ActivityVariant{},
+		&models.Equipment{},
+		&models.ActivityEquipment{},
+		&models.Song{},
+		&models.Media{},
+		&models.MediaVariant{},
+		&models.ActivityQRCode{},
+		&models.BackgroundJob{},
+		&models.TaxonomyAuditLog{},
+		&models.Translation{},
+		&models.TargetPopulation{},
 		&models.UserFavorite{},
 		&models.UserReadHistory{},
+		&models.ActivityCooccurrence{},
+		&models.ActivityPopularity{},
+		&models.ActivityReview{},
+		&models.ReviewReport{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.Client{},
+		&models.ClientGuardian{},
+		&models.ClientGroup{},
+		&models.Session{},
+		&models.SessionItem{},
+		&models.RatingScale{},
+		&models.RatingScaleLevel{},
+		&models.Observation{},
+		&models.TherapyPlan{},
+		&models.PlanGoal{},
+		&models.TherapyPlanVersion{},
+		&models.DataMigration{},
 	)
 
 	if err != nil {
@@ -57,15 +95,58 @@ func main() {
 	}
 
 	runDatabaseSeeds(gormDB)
+	runDataMigrations(gormDB)
 
 	log.Println("Database connection and migration successful.")
 
+	storageCfg := config.GetStorageConfig()
+	store, err := storage.New(storageCfg)
+	if err != nil {
+		log.Fatalf("Storage initialization failed: %v", err)
+	}
+	models.SetMediaBaseURL(storageCfg.PublicBaseURL)
+	log.Printf("Using %s storage for uploads", store.Name())
+
+	thumbs := thumbnail.NewWorker(gormDB, store, storageCfg.ThumbnailWorkers)
+	thumbs.Start(context.Background())
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	r := router.SetupRouter(gormDB)
+	pdfCfg := config.GetPDFConfig()
+	fonts, err := handout.LoadFonts(pdfCfg.FontDir, pdfCfg.FontRegular, pdfCfg.FontBold)
+	if err != nil {
+		log.Printf("PDF export disabled: %v", err)
+	}
+
+	jobRunner := jobs.NewRunner(gormDB)
+	jobRunner.FailInterrupted()
+
+	relatedCfg := config.GetRelatedConfig()
+
+	recommendCfg := config.GetRecommendConfig()
+	recommender := recommend.NewScheduler(gormDB, recommendCfg)
+	recommender.Start(context.Background())
+
+	r := router.SetupRouter(gormDB, router.Services{
+		Store:      store,
+		StorageCfg: storageCfg,
+		Thumbs:     thumbs,
+		QR:         config.GetQRConfig(),
+		PDF:        pdfCfg,
+		Fonts:      fonts,
+		Jobs:       jobRunner,
+		Import:     config.GetImportConfig(),
+		Locale:     config.GetLocaleConfig(),
+		Related:    related.NewCache(relatedCfg.CacheTTL),
+		RelatedCfg: relatedCfg,
+
+		Recommender:  recommender,
+		RecommendCfg: recommendCfg,
+		Observation:  config.GetObservationConfig(),
+	})
 	log.Printf("Starting HTTP server on port %s in %s mode", port, os.Getenv("GIN_MODE"))
 
 	if err := r.Run(":" + port); err != nil {
@@ -88,6 +169,18 @@ func runDatabaseSeeds(gormDB *gorm.DB) {
 	if err := seeds.SeedMainCategories(gormDB); err != nil {
 		log.Printf("Error seeding MainCategories: %v", err)
 	}
+	if err := seeds.SyncTaxonomySequences(gormDB); err != nil {
+		log.Printf("Error syncing taxonomy sequences: %v", err)
+	}
+	if err := seeds.SeedEquipment(gormDB); err != nil {
+		log.Printf("Error seeding Equipment: %v", err)
+	}
+	if err := seeds.SeedTargetPopulations(gormDB); err != nil {
+		log.Printf("Error seeding TargetPopulations: %v", err)
+	}
+	if err := seeds.SeedRatingScales(gormDB); err != nil {
+		log.Printf("Error seeding RatingScales: %v", err)
+	}
 
 	var adminCount int64
 
@@ -117,3 +210,28 @@ func runDatabaseSeeds(gormDB *gorm.DB) {
 
 	log.Println("Seeding process completed.")
 }
+
+func runDataMigrations(gormDB *gorm.DB) {
+	log.Println("Starting data migrations...")
+
+	if err := migrations.RunOnce(gormDB, "activity_steps", migrations.MigrateProcessToSteps); err != nil {
+		log.Printf("Error migrating activity process to steps: %v", err)
+	}
+	if err := migrations.RunOnce(gormDB, "activity_equipment", migrations.MigrateEquipmentText); err != nil {
+		log.Printf("Error migrating activity equipment text: %v", err)
+	}
+	if err := migrations.RunOnce(gormDB, "activity_songs", migrations.MigrateSongText); err != nil {
+		log.Printf("Error migrating activity song text: %v", err)
+	}
+	if err := migrations.RunOnce(gormDB, "variant_references", migrations.MigrateVariantReferences); err != nil {
+		log.Printf("Error migrating variant equipment and song references: %v", err)
+	}
+	if err := migrations.RunOnce(gormDB, "activity_richtext", migrations.MigrateRichText); err != nil {
+		log.Printf("Error migrating activity rich text: %v", err)
+	}
+	if err := migrations.RunOnce(gormDB, "step_variant_richtext", migrations.MigrateStepVariantRichText); err != nil {
+		log.Printf("Error migrating step and variant rich text: %v", err)
+	}
+
+	log.Println("Data migrations completed.")
+}
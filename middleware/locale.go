@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"project-backend/config"
+	"project-backend/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale เลือกภาษาของ request จาก ?lang= หรือ header Accept-Language แล้วเก็บไว้ใน context ("locale")
+// controller ใช้ค่านี้แทนข้อความต้นฉบับด้วยคำแปล
+func Locale(cfg *config.LocaleConfig) gin.HandlerFunc {
+	supported := []string{i18n.BaseLocale}
+	if cfg != nil {
+		supported = cfg.Supported
+	}
+
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"), supported)
+		c.Set("locale", locale)
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
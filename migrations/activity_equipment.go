@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"log"
+	"strings"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateEquipmentText จับคู่ข้อความ Equipment เดิมของกิจกรรมกับ catalog แบบ best-effort
+// (ชื่ออุปกรณ์ปรากฏอยู่ในข้อความ) เฉพาะกิจกรรมที่ยังไม่มีการเชื่อมอุปกรณ์
+// ข้อความเดิมยังคงเก็บไว้ใน Activity.Equipment เพื่อให้ตรวจทานภายหลังได้
+// ต้องเรียกผ่าน RunOnce เท่านั้น ถ้าเรียกทุกครั้งที่เปิดเซิร์ฟเวอร์ อุปกรณ์ที่ผู้ดูแลถอดออกจนหมดจะถูกเชื่อมกลับจากข้อความเดิม
+func MigrateEquipmentText(db *gorm.DB) error {
+	var catalog []models.Equipment
+	if err := db.Find(&catalog).Error; err != nil {
+		return err
+	}
+	if len(catalog) == 0 {
+		return nil
+	}
+
+	var activities []models.Activity
+	if err := db.Select("id", "equipment").
+		Where("COALESCE(TRIM(equipment), '') <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM activity_equipments WHERE activity_equipments.activity_id = activities.id)").
+		Find(&activities).Error; err != nil {
+		return err
+	}
+
+	matched := 0
+	for _, activity := range activities {
+		text := strings.ToLower(activity.Equipment)
+
+		var links []models.ActivityEquipment
+		for _, item := range catalog {
+			if !containsEquipmentName(text, strings.ToLower(item.Name), catalog) {
+				continue
+			}
+			links = append(links, models.ActivityEquipment{
+				ActivityID:  activity.ID,
+				EquipmentID: item.ID,
+				Quantity:    1,
+			})
+		}
+		if len(links) == 0 {
+			continue
+		}
+		if err := db.Omit("Equipment").Create(&links).Error; err != nil {
+			return err
+		}
+		matched++
+	}
+
+	if matched > 0 {
+		log.Printf("Linked catalog equipment for %d of %d activities", matched, len(activities))
+	}
+	return nil
+}
+
+// containsEquipmentName คืนค่า true ถ้า name อยู่ในข้อความ และไม่ได้เป็นเพียงส่วนหนึ่ง
+// ของชื่ออุปกรณ์ที่ยาวกว่าที่ตรงกันอยู่แล้ว (เช่น "กลอง" ใน "กลองมือ")
+func containsEquipmentName(text, name string, catalog []models.Equipment) bool {
+	if name == "" || !strings.Contains(text, name) {
+		return false
+	}
+	for _, other := range catalog {
+		longer := strings.ToLower(other.Name)
+		if longer == name || !strings.Contains(longer, name) || !strings.Contains(text, longer) {
+			continue
+		}
+		if strings.Count(text, name) <= strings.Count(text, longer) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"log"
+
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/richtext"
+
+	"gorm.io/gorm"
+)
+
+// richTextFields คือคอลัมน์ของกิจกรรมที่เก็บเป็น Markdown และส่งออกเป็น HTML ที่กรองแล้ว
+var richTextFields = []string{"process", "observable_behavior", "suggestion"}
+
+// MigrateRichText แปลงข้อความ HTML เดิมที่ผู้ดูแลคัดลอกมาวางใน Process/ObservableBehavior/Suggestion
+// (รวมคำแปลของฟิลด์เหล่านี้) เป็น Markdown ข้อความธรรมดาไม่ถูกแก้ไข
+// ต้องเรียกผ่าน RunOnce เท่านั้น เพราะ Markdown ที่ผู้ดูแลบันทึกหลังจากนี้อาจมีแท็ก inline (เช่น <br>)
+// ถ้าแปลงซ้ำจะกลายเป็นข้อความที่ escape เครื่องหมาย Markdown ทิ้ง
+func MigrateRichText(db *gorm.DB) error {
+	var activities []models.Activity
+	if err := db.Select("id", "process", "observable_behavior", "suggestion").
+		Where("process ~ '<[A-Za-z/]' OR observable_behavior ~ '<[A-Za-z/]' OR suggestion ~ '<[A-Za-z/]'").
+		Find(&activities).Error; err != nil {
+		return err
+	}
+
+	converted := 0
+	for _, activity := range activities {
+		current := map[string]string{
+			"process":             activity.Process,
+			"observable_behavior": activity.ObservableBehavior,
+			"suggestion":          activity.Suggestion,
+		}
+		updates := map[string]interface{}{}
+		for _, field := range richTextFields {
+			if richtext.LooksLikeHTML(current[field]) {
+				updates[field] = richtext.FromHTML(current[field])
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := db.Model(&models.Activity{}).Where("id = ?", activity.ID).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+		converted++
+	}
+
+	var translations []models.Translation
+	if err := db.Where("entity_type = ? AND field IN ?", i18n.EntityActivity, richTextFields).
+		Where("value ~ '<[A-Za-z/]'").
+		Find(&translations).Error; err != nil {
+		return err
+	}
+	translated := 0
+	for _, t := range translations {
+		if !richtext.LooksLikeHTML(t.Value) {
+			continue
+		}
+		if err := db.Model(&models.Translation{}).Where("id = ?", t.ID).UpdateColumn("value", richtext.FromHTML(t.Value)).Error; err != nil {
+			return err
+		}
+		translated++
+	}
+
+	if converted > 0 || translated > 0 {
+		log.Printf("Converted HTML text of %d activities and %d translations to Markdown", converted, translated)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"log"
+	"strings"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateSongText สร้างรายการเพลงจากข้อความ Activity.Song เดิม โดยรวมชื่อที่สะกดต่างกันเล็กน้อย
+// (ช่องว่าง ตัวพิมพ์ เครื่องหมายคำพูด) ให้เป็นเพลงเดียวกัน แล้วเชื่อมกับกิจกรรม
+// ทำเฉพาะกิจกรรมที่ยังไม่มีเพลงเชื่อมอยู่
+// ต้องเรียกผ่าน RunOnce เท่านั้น ถ้าเรียกทุกครั้งที่เปิดเซิร์ฟเวอร์ เพลงที่ผู้ดูแลถอดออกจนหมดจะถูกสร้างและเชื่อมกลับจากข้อความเดิม
+func MigrateSongText(db *gorm.DB) error {
+	var activities []models.Activity
+	if err := db.Select("id", "song", "song_image").
+		Where("COALESCE(TRIM(song), '') <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM activity_songs WHERE activity_songs.activity_id = activities.id)").
+		Order("id ASC").
+		Find(&activities).Error; err != nil {
+		return err
+	}
+	if len(activities) == 0 {
+		return nil
+	}
+
+	// จัดกลุ่มตามชื่อที่ normalize แล้ว และเลือกการสะกดที่พบบ่อยที่สุดเป็นชื่อเพลง
+	type songGroup struct {
+		spellings   map[string]int
+		coverImage  string
+		activityIDs []uint
+	}
+	groups := map[string]*songGroup{}
+	var order []string
+	for _, activity := range activities {
+		key := models.NormalizeSongTitle(activity.Song)
+		if key == "" {
+			continue
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &songGroup{spellings: map[string]int{}}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.spellings[strings.TrimSpace(activity.Song)]++
+		if group.coverImage == "" {
+			group.coverImage = activity.SongImage
+		}
+		group.activityIDs = append(group.activityIDs, activity.ID)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		created := 0
+		for _, key := range order {
+			group := groups[key]
+
+			var song models.Song
+			err := tx.Where("normalized_title = ?", key).Order("id ASC").First(&song).Error
+			if err == gorm.ErrRecordNotFound {
+				song = models.Song{Title: mostCommonSpelling(group.spellings), CoverImage: group.coverImage}
+				if err := tx.Create(&song).Error; err != nil {
+					return err
+				}
+				created++
+			} else if err != nil {
+				return err
+			}
+
+			for _, activityID := range group.activityIDs {
+				if err := tx.Exec(
+					"INSERT INTO activity_songs (activity_id, song_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+					activityID, song.ID,
+				).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		log.Printf("Migrated song text of %d activities into %d songs (%d new)", len(activities), len(order), created)
+		return nil
+	})
+}
+
+func mostCommonSpelling(spellings map[string]int) string {
+	best, bestCount := "", 0
+	for spelling, count := range spellings {
+		if count > bestCount || (count == bestCount && spelling < best) {
+			best, bestCount = spelling, count
+		}
+	}
+	return best
+}
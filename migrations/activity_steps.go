@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"log"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateProcessToSteps ย้ายข้อความ Process เดิมของกิจกรรมที่ยังไม่มีขั้นตอน
+// ไปเป็นขั้นตอนแรก (ขั้นตอนเดียว) ใน activity_steps
+// ต้องเรียกผ่าน RunOnce เท่านั้น ถ้าเรียกทุกครั้งที่เปิดเซิร์ฟเวอร์ กิจกรรมที่ผู้ดูแลลบขั้นตอนออกหมดจะได้ขั้นตอนจาก Process เดิมกลับมา
+func MigrateProcessToSteps(db *gorm.DB) error {
+	var activities []models.Activity
+	if err := db.Select("id", "process").
+		Where("COALESCE(TRIM(process), '') <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM activity_steps WHERE activity_steps.activity_id = activities.id)").
+		Find(&activities).Error; err != nil {
+		return err
+	}
+
+	for _, activity := range activities {
+		step := models.ActivityStep{
+			ActivityID:  activity.ID,
+			Position:    1,
+			Instruction: activity.Process,
+		}
+		if err := db.Create(&step).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(activities) > 0 {
+		log.Printf("Migrated process text of %d activities into steps", len(activities))
+	}
+	return nil
+}
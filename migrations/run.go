@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"log"
+	"time"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RunOnce เรียก migrate ครั้งเดียวต่อฐานข้อมูล แล้วบันทึก name ไว้ในตาราง data_migrations ใน Transaction เดียวกัน
+// ถ้า migrate ล้มเหลวจะ rollback และลองใหม่ในการเปิดเซิร์ฟเวอร์ครั้งถัดไป
+// เซิร์ฟเวอร์หลายตัวที่เปิดพร้อมกันจะรอกันที่ row ของ name นี้ จึงมีตัวเดียวที่ได้ทำ
+func RunOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.DataMigration{Name: name, AppliedAt: time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := migrate(tx); err != nil {
+			return err
+		}
+		log.Printf("Data migration %s applied", name)
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"log"
+
+	"project-backend/i18n"
+	"project-backend/models"
+	"project-backend/richtext"
+
+	"gorm.io/gorm"
+)
+
+// MigrateStepVariantRichText แปลงข้อความ HTML เดิมใน ActivityStep.Instruction และ ActivityVariant.Process
+// (รวมคำแปลของฟิลด์เหล่านี้) เป็น Markdown แบบเดียวกับ MigrateRichText
+// ต้องเรียกผ่าน RunOnce เท่านั้น ด้วยเหตุผลเดียวกับ MigrateRichText
+func MigrateStepVariantRichText(db *gorm.DB) error {
+	var steps []models.ActivityStep
+	if err := db.Select("id", "instruction").Where("instruction ~ '<[A-Za-z/]'").Find(&steps).Error; err != nil {
+		return err
+	}
+	converted := 0
+	for _, step := range steps {
+		if !richtext.LooksLikeHTML(step.Instruction) {
+			continue
+		}
+		if err := db.Model(&models.ActivityStep{}).Where("id = ?", step.ID).UpdateColumn("instruction", richtext.FromHTML(step.Instruction)).Error; err != nil {
+			return err
+		}
+		converted++
+	}
+
+	var variants []models.ActivityVariant
+	if err := db.Select("id", "process").Where("process ~ '<[A-Za-z/]'").Find(&variants).Error; err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if !richtext.LooksLikeHTML(variant.Process) {
+			continue
+		}
+		if err := db.Model(&models.ActivityVariant{}).Where("id = ?", variant.ID).UpdateColumn("process", richtext.FromHTML(variant.Process)).Error; err != nil {
+			return err
+		}
+		converted++
+	}
+
+	var translations []models.Translation
+	if err := db.Where("(entity_type = ? AND field = ?) OR (entity_type = ? AND field = ?)",
+		i18n.EntityStep, "instruction", i18n.EntityVariant, "process").
+		Where("value ~ '<[A-Za-z/]'").
+		Find(&translations).Error; err != nil {
+		return err
+	}
+	translated := 0
+	for _, t := range translations {
+		if !richtext.LooksLikeHTML(t.Value) {
+			continue
+		}
+		if err := db.Model(&models.Translation{}).Where("id = ?", t.ID).UpdateColumn("value", richtext.FromHTML(t.Value)).Error; err != nil {
+			return err
+		}
+		translated++
+	}
+
+	if converted > 0 || translated > 0 {
+		log.Printf("Converted HTML text of %d steps/variants and %d translations to Markdown", converted, translated)
+	}
+	return nil
+}
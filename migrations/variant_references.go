@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"log"
+	"strings"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateVariantReferences จับคู่ข้อความ Equipment/Song เดิมของรูปแบบกิจกรรมกับอุปกรณ์และเพลง
+// ของกิจกรรมหลักแบบ best-effort (ชื่ออุปกรณ์หรือชื่อเพลงปรากฏอยู่ในข้อความ) ข้อความเดิมยังคงเก็บไว้
+// ต้องเรียกผ่าน RunOnce เท่านั้น ถ้าเรียกทุกครั้งที่เปิดเซิร์ฟเวอร์ รายการที่ผู้ดูแลถอดออกจะถูกเชื่อมกลับจากข้อความเดิม
+func MigrateVariantReferences(db *gorm.DB) error {
+	var variants []models.ActivityVariant
+	if err := db.Select("id", "activity_id", "equipment", "song").
+		Where("COALESCE(TRIM(equipment), '') <> '' OR COALESCE(TRIM(song), '') <> ''").
+		Find(&variants).Error; err != nil {
+		return err
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+
+	activityIDs := make([]uint, 0, len(variants))
+	for _, v := range variants {
+		activityIDs = append(activityIDs, v.ActivityID)
+	}
+	var activities []models.Activity
+	if err := db.Select("id").
+		Preload("EquipmentItems.Equipment").
+		Preload("Songs").
+		Where("id IN ?", activityIDs).
+		Find(&activities).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]models.Activity, len(activities))
+	for _, a := range activities {
+		byID[a.ID] = a
+	}
+
+	matched := 0
+	for _, v := range variants {
+		activity := byID[v.ActivityID]
+
+		if text := strings.ToLower(v.Equipment); strings.TrimSpace(text) != "" {
+			catalog := make([]models.Equipment, 0, len(activity.EquipmentItems))
+			for _, item := range activity.EquipmentItems {
+				catalog = append(catalog, item.Equipment)
+			}
+			for _, item := range catalog {
+				if !containsEquipmentName(text, strings.ToLower(item.Name), catalog) {
+					continue
+				}
+				if err := db.Exec(
+					"INSERT INTO activity_variant_equipment (activity_variant_id, equipment_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+					v.ID, item.ID,
+				).Error; err != nil {
+					return err
+				}
+				matched++
+			}
+		}
+
+		if text := models.NormalizeSongTitle(v.Song); text != "" {
+			for _, song := range activity.Songs {
+				title := models.NormalizeSongTitle(song.Title)
+				if title == "" || !strings.Contains(text, title) {
+					continue
+				}
+				if err := db.Exec(
+					"INSERT INTO activity_variant_songs (activity_variant_id, song_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+					v.ID, song.ID,
+				).Error; err != nil {
+					return err
+				}
+				matched++
+			}
+		}
+	}
+
+	if matched > 0 {
+		log.Printf("Linked %d equipment/song references from the text of %d variants", matched, len(variants))
+	}
+	return nil
+}
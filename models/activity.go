@@ -1,6 +1,22 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"project-backend/richtext"
+)
+
+const (
+	// ActivityStatusDraft คือกิจกรรมที่ยังไม่เผยแพร่ ไม่แสดงในรายการ การค้นหา และการแนะนำสำหรับสมาชิก
+	ActivityStatusDraft     = "draft"
+	ActivityStatusPublished = "published"
+)
+
+// ValidActivityStatus ตรวจว่าสถานะของกิจกรรมถูกต้อง
+func ValidActivityStatus(status string) bool {
+	return status == ActivityStatusDraft || status == ActivityStatusPublished
+}
 
 type Activity struct {
 	ID                 uint      `json:"activity_id" gorm:"primaryKey;autoIncrement"`
@@ -10,34 +26,190 @@ type Activity struct {
 	CoverImage         string    `json:"cover_image" gorm:"type:text"`
 	GoalDescription    string    `json:"goal_description" gorm:"type:text"`
 	Equipment          string    `json:"equipment" gorm:"type:text"`
-	Process            string    `json:"process" gorm:"type:text"`
-	ObservableBehavior string    `json:"observable_behavior" gorm:"type:text"`
-	Suggestion         string    `json:"suggestion" gorm:"type:text"`
+	Process            string    `json:"process_markdown" gorm:"type:text"`
+	ObservableBehavior string    `json:"observable_behavior_markdown" gorm:"type:text"`
+	Suggestion         string    `json:"suggestion_markdown" gorm:"type:text"`
 	Song               string    `json:"song" gorm:"type:text"`
 	SongImage          string    `json:"song_image" gorm:"type:text"`
 	QR1                string    `json:"qr_1" gorm:"type:text"`
 	QR2                string    `json:"qr_2" gorm:"type:text"`
+	CoverMediaID       *uint     `json:"cover_media_id"`
+	SongImageMediaID   *uint     `json:"song_image_media_id"`
 	AdminID            uint      `json:"admin_id" gorm:"not null"`
+	Status             string    `json:"status" gorm:"type:text;not null;default:published;index"`
+
+	// Version เพิ่มขึ้นทุกครั้งที่แก้ไขผ่าน PUT/PATCH ใช้เป็น ETag และตรวจ If-Match เพื่อกันการเขียนทับกัน
+	Version int `json:"version" gorm:"not null;default:1"`
+
+	// DerivedFromID คือกิจกรรมต้นแบบเมื่อกิจกรรมนี้ถูกสร้างด้วยการ clone
+	DerivedFromID *uint             `json:"derived_from_id"`
+	DerivedFrom   *ActivitySummary  `json:"derived_from,omitempty" gorm:"foreignKey:DerivedFromID;constraint:OnDelete:SET NULL"`
+	Derivatives   []ActivitySummary `json:"derivatives,omitempty" gorm:"foreignKey:DerivedFromID"`
+
+	// ข้อมูลสำหรับเลือกกิจกรรมให้เหมาะกับผู้เข้าร่วม ค่าว่าง (null) หมายถึงไม่จำกัด
+	AgeMin            *int   `json:"age_min" gorm:"index"`
+	AgeMax            *int   `json:"age_max" gorm:"index"`
+	GroupSizeMin      *int   `json:"group_size_min"`
+	GroupSizeMax      *int   `json:"group_size_max"`
+	DurationMinutes   *int   `json:"duration_minutes"`
+	Difficulty        *int   `json:"difficulty"`
+	Contraindications string `json:"contraindications" gorm:"type:text"`
+	SafetyNotes       string `json:"safety_notes" gorm:"type:text"`
+
+	// คะแนนรีวิวรวม คำนวณใหม่จากรีวิวที่แสดงอยู่ทุกครั้งที่รีวิวเปลี่ยน
+	RatingAverage float64 `json:"rating_average" gorm:"not null;default:0"`
+	RatingCount   int     `json:"rating_count" gorm:"not null;default:0"`
 
-	SubGoals      []ActivitySubGoal     `json:"selected_sub_goals" gorm:"many2many:activity_selected_sub_goals;"`
-	SubCategories []ActivitySubCategory `json:"selected_sub_categories" gorm:"many2many:activity_selected_sub_categories;"`
+	SubGoals          []ActivitySubGoal     `json:"selected_sub_goals" gorm:"many2many:activity_selected_sub_goals;"`
+	SubCategories     []ActivitySubCategory `json:"selected_sub_categories" gorm:"many2many:activity_selected_sub_categories;"`
+	Steps             []ActivityStep        `json:"steps" gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	EquipmentItems    []ActivityEquipment   `json:"equipment_items" gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	Songs             []Song                `json:"songs" gorm:"many2many:activity_songs;"`
+	QRCodes           []ActivityQRCode      `json:"qr_codes" gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	TargetPopulations []TargetPopulation    `json:"target_populations" gorm:"many2many:activity_target_populations;"`
+	Variants          []ActivityVariant     `json:"variants" gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+
+	CoverMedia     *Media `json:"cover_media,omitempty" gorm:"foreignKey:CoverMediaID;constraint:OnDelete:SET NULL"`
+	SongImageMedia *Media `json:"song_image_media,omitempty" gorm:"foreignKey:SongImageMediaID;constraint:OnDelete:SET NULL"`
+}
+
+// MarshalJSON ส่ง Process/ObservableBehavior/Suggestion ออกไปทั้งต้นฉบับ Markdown (*_markdown)
+// และ HTML ที่กรองแล้ว (*_html) ให้หน้าเว็บแสดง *_html ได้โดยตรง
+// HTML สร้างตอนส่งออก จึงตรงกับข้อความที่แปลภาษาแล้วเสมอ
+// process/observable_behavior/suggestion เดิมยังส่งอยู่ (ค่าเดียวกับ *_markdown) ให้ client รุ่นเก่า
+func (a Activity) MarshalJSON() ([]byte, error) {
+	type activityFields Activity
+	return json.Marshal(struct {
+		activityFields
+		ProcessHTML            string `json:"process_html"`
+		ObservableBehaviorHTML string `json:"observable_behavior_html"`
+		SuggestionHTML         string `json:"suggestion_html"`
+
+		// Deprecated: ใช้ *_markdown หรือ *_html แทน
+		LegacyProcess            string `json:"process"`
+		LegacyObservableBehavior string `json:"observable_behavior"`
+		LegacySuggestion         string `json:"suggestion"`
+	}{
+		activityFields:           activityFields(a),
+		ProcessHTML:              richtext.Render(a.Process),
+		ObservableBehaviorHTML:   richtext.Render(a.ObservableBehavior),
+		SuggestionHTML:           richtext.Render(a.Suggestion),
+		LegacyProcess:            a.Process,
+		LegacyObservableBehavior: a.ObservableBehavior,
+		LegacySuggestion:         a.Suggestion,
+	})
 }
 
+// ActivitySummary คือข้อมูลย่อของกิจกรรม (อ่านจากตาราง activities) ใช้แสดงสายการ clone
+type ActivitySummary struct {
+	ID            uint   `json:"activity_id"`
+	Title         string `json:"title"`
+	Status        string `json:"status"`
+	DerivedFromID *uint  `json:"-"`
+}
+
+func (ActivitySummary) TableName() string { return "activities" }
+
+// ActivityStep คือขั้นตอนการดำเนินกิจกรรมแบบเรียงลำดับ (แทนที่ Process แบบข้อความเดียว)
+// Instruction เก็บเป็น Markdown เช่นเดียวกับ Activity.Process
+type ActivityStep struct {
+	ID              uint   `json:"step_id" gorm:"primaryKey;autoIncrement"`
+	ActivityID      uint   `json:"activity_id" gorm:"index;not null"`
+	Position        int    `json:"position" gorm:"not null"`
+	Instruction     string `json:"instruction" gorm:"type:text;not null"`
+	DurationSeconds *int   `json:"duration_seconds"`
+	SubGoalID       *uint  `json:"sub_goal_id" gorm:"index"`
+	FacilitatorCue  string `json:"facilitator_cue" gorm:"type:text"`
+	MediaURL        string `json:"media_url" gorm:"type:text"`
+	MediaID         *uint  `json:"media_id"`
+
+	SubGoal *ActivitySubGoal `json:"sub_goal,omitempty" gorm:"foreignKey:SubGoalID"`
+	Media   *Media           `json:"media,omitempty" gorm:"foreignKey:MediaID;constraint:OnDelete:SET NULL"`
+}
+
+// MarshalJSON ส่ง Instruction (Markdown) ออกไปพร้อม HTML ที่กรองแล้ว (instruction_html) เหมือน Activity.Process
+func (s ActivityStep) MarshalJSON() ([]byte, error) {
+	type stepFields ActivityStep
+	return json.Marshal(struct {
+		stepFields
+		InstructionHTML string `json:"instruction_html"`
+	}{
+		stepFields:      stepFields(s),
+		InstructionHTML: richtext.Render(s.Instruction),
+	})
+}
+
+const (
+	VariantLevelEasier = "easier"
+	VariantLevelHarder = "harder"
+)
+
+// ValidVariantLevel ตรวจว่าระดับของรูปแบบกิจกรรมถูกต้อง
+func ValidVariantLevel(level string) bool {
+	return level == VariantLevelEasier || level == VariantLevelHarder
+}
+
+// ActivityVariant คือรูปแบบที่ปรับให้ง่ายขึ้นหรือยากขึ้นของกิจกรรมหลัก
+// Process/Equipment/Song ที่ว่างหมายถึงใช้ข้อความเดียวกับกิจกรรมหลัก Process เก็บเป็น Markdown
+// อุปกรณ์และเพลงที่รูปแบบนี้ใช้อ้างอิงรายการของกิจกรรมหลัก (SelectedEquipment ⊆ EquipmentItems, Songs ⊆ Songs)
+// ส่วน Equipment/Song แบบข้อความเก็บไว้เป็นหมายเหตุเพิ่มเติมเหมือน Activity.Equipment/Activity.Song
+type ActivityVariant struct {
+	ID         uint      `json:"variant_id" gorm:"primaryKey;autoIncrement"`
+	ActivityID uint      `json:"activity_id" gorm:"index;not null"`
+	Position   int       `json:"position" gorm:"not null"`
+	Level      string    `json:"level" gorm:"type:text;not null;index"`
+	Label      string    `json:"label" gorm:"type:text;not null"`
+	Process    string    `json:"process" gorm:"type:text"`
+	Equipment  string    `json:"equipment" gorm:"type:text"`
+	Song       string    `json:"song" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	SubGoals          []ActivitySubGoal `json:"sub_goals" gorm:"many2many:activity_variant_sub_goals;"`
+	SelectedEquipment []Equipment       `json:"selected_equipment" gorm:"many2many:activity_variant_equipment;"`
+	Songs             []Song            `json:"songs" gorm:"many2many:activity_variant_songs;"`
+}
+
+// MarshalJSON ส่ง Process (Markdown) ออกไปพร้อม HTML ที่กรองแล้ว (process_html) เหมือน Activity.Process
+func (v ActivityVariant) MarshalJSON() ([]byte, error) {
+	type variantFields ActivityVariant
+	return json.Marshal(struct {
+		variantFields
+		ProcessHTML string `json:"process_html"`
+	}{
+		variantFields: variantFields(v),
+		ProcessHTML:   richtext.Render(v.Process),
+	})
+}
+
+// taxonomy (เป้าหมาย/หมวดหมู่และรายการย่อย) จัดการได้จากหน้า admin
+// รายการที่ถูกยกเลิก (RetiredAt) ไม่แสดงในรายการให้เลือก แต่กิจกรรมเดิมที่อ้างถึงยังแสดงได้ตามปกติ
+// MergedIntoID ชี้ไปยังรายการที่ถูกรวมเข้าไป ใช้แปลง ID เก่าจากไฟล์นำเข้าให้เป็นรายการปัจจุบัน
+
 type ActivityGoal struct {
-	ID       uint              `json:"goal_id" gorm:"primaryKey"`
-	GoalName string            `json:"goal_name" gorm:"type:text;not null"`
-	SubGoals []ActivitySubGoal `json:"sub_goals" gorm:"foreignKey:GoalID"`
+	ID           uint              `json:"goal_id" gorm:"primaryKey"`
+	GoalName     string            `json:"goal_name" gorm:"type:text;not null"`
+	SortOrder    int               `json:"sort_order" gorm:"not null;default:0"`
+	RetiredAt    *time.Time        `json:"retired_at,omitempty"`
+	MergedIntoID *uint             `json:"merged_into_id,omitempty"`
+	SubGoals     []ActivitySubGoal `json:"sub_goals" gorm:"foreignKey:GoalID"`
 }
 
 type ActivitySubGoal struct {
-	ID          uint   `json:"sub_goal_id" gorm:"primaryKey"`
-	GoalID      uint   `json:"goal_id"`
-	SubGoalName string `json:"sub_goal_name" gorm:"type:text;not null"`
+	ID           uint       `json:"sub_goal_id" gorm:"primaryKey"`
+	GoalID       uint       `json:"goal_id"`
+	SubGoalName  string     `json:"sub_goal_name" gorm:"type:text;not null"`
+	SortOrder    int        `json:"sort_order" gorm:"not null;default:0"`
+	RetiredAt    *time.Time `json:"retired_at,omitempty"`
+	MergedIntoID *uint      `json:"merged_into_id,omitempty"`
 }
 
 type ActivityMainCategory struct {
-	ID           uint   `json:"category_id" gorm:"primaryKey"`
-	CategoryName string `json:"category_name" gorm:"type:text;not null"`
+	ID           uint       `json:"category_id" gorm:"primaryKey"`
+	CategoryName string     `json:"category_name" gorm:"type:text;not null"`
+	SortOrder    int        `json:"sort_order" gorm:"not null;default:0"`
+	RetiredAt    *time.Time `json:"retired_at,omitempty"`
+	MergedIntoID *uint      `json:"merged_into_id,omitempty"`
 
 	SubCategories []ActivitySubCategory `json:"sub_categories" gorm:"foreignKey:CategoryID"`
 }
@@ -45,6 +217,9 @@ type ActivityMainCategory struct {
 type ActivitySubCategory struct {
 	ID uint `json:"sub_category_id" gorm:"primaryKey"`
 
-	CategoryID      uint   `json:"category_id" gorm:"column:category_id"`
-	SubCategoryName string `json:"sub_category_name" gorm:"type:text;not null"`
+	CategoryID      uint       `json:"category_id" gorm:"column:category_id"`
+	SubCategoryName string     `json:"sub_category_name" gorm:"type:text;not null"`
+	SortOrder       int        `json:"sort_order" gorm:"not null;default:0"`
+	RetiredAt       *time.Time `json:"retired_at,omitempty"`
+	MergedIntoID    *uint      `json:"merged_into_id,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import "fmt"
+
+const (
+	// ระดับความยากของกิจกรรม 1 (ง่าย) ถึง 5 (ยาก)
+	DifficultyMin = 1
+	DifficultyMax = 5
+)
+
+// ValidateAttributes ตรวจช่วงอายุ (ปี) ขนาดกลุ่ม ระยะเวลา (นาที) และระดับความยากของกิจกรรม
+func (a *Activity) ValidateAttributes() error {
+	if err := validateRange("age", a.AgeMin, a.AgeMax, 0); err != nil {
+		return err
+	}
+	if err := validateRange("group_size", a.GroupSizeMin, a.GroupSizeMax, 1); err != nil {
+		return err
+	}
+	if a.DurationMinutes != nil && *a.DurationMinutes <= 0 {
+		return fmt.Errorf("duration_minutes must be greater than 0")
+	}
+	if a.Difficulty != nil && (*a.Difficulty < DifficultyMin || *a.Difficulty > DifficultyMax) {
+		return fmt.Errorf("difficulty must be between %d and %d", DifficultyMin, DifficultyMax)
+	}
+	return nil
+}
+
+func validateRange(name string, min, max *int, lowest int) error {
+	if min != nil && *min < lowest {
+		return fmt.Errorf("%s_min must be at least %d", name, lowest)
+	}
+	if max != nil && *max < lowest {
+		return fmt.Errorf("%s_max must be at least %d", name, lowest)
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("%s_min must not be greater than %s_max", name, name)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// BackgroundJob คืองานที่ใช้เวลานาน (เช่น นำเข้ากิจกรรม) ที่ทำใน background
+// client ใช้ Processed/Total เพื่อแสดงความคืบหน้า และอ่านผลลัพธ์จาก Result เมื่อเสร็จ
+type BackgroundJob struct {
+	ID          uint       `json:"job_id" gorm:"primaryKey;autoIncrement"`
+	Type        string     `json:"type" gorm:"type:text;not null;index"`
+	Status      string     `json:"status" gorm:"type:text;not null;index"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Result      string     `json:"-" gorm:"type:jsonb;default:null"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedByID uint       `json:"created_by_id" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+
+	ResultJSON json.RawMessage `json:"result,omitempty" gorm:"-"`
+}
+
+func (j *BackgroundJob) AfterFind(tx *gorm.DB) error {
+	if j.Result != "" {
+		j.ResultJSON = json.RawMessage(j.Result)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization คือหน่วยงาน (คลินิก โรงเรียน ฯลฯ) ที่นักบำบัดหลายคนใช้ข้อมูลผู้รับบริการร่วมกัน
+type Organization struct {
+	ID        uint      `json:"organization_id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Members []OrganizationMember `json:"members,omitempty" gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE"`
+}
+
+// OrganizationMember คือนักบำบัดที่เป็นสมาชิกของหน่วยงาน สมาชิกทุกคนเข้าถึงผู้รับบริการของหน่วยงานได้
+type OrganizationMember struct {
+	OrganizationID uint      `json:"organization_id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"primaryKey;index"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	User *ReviewAuthor `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Client คือผู้รับบริการ (เด็กหรือผู้ใหญ่) ที่นักบำบัดดูแล
+// เจ้าของมีได้อย่างใดอย่างหนึ่ง: นักบำบัด (OwnerUserID) หรือหน่วยงาน (OrganizationID)
+// ลบแบบ soft delete เพื่อไม่ให้ประวัติการบำบัดที่อ้างถึงผู้รับบริการหายไป
+type Client struct {
+	ID             uint           `json:"client_id" gorm:"primaryKey;autoIncrement"`
+	OwnerUserID    *uint          `json:"owner_user_id" gorm:"index"`
+	OrganizationID *uint          `json:"organization_id" gorm:"index"`
+	CreatedByID    uint           `json:"created_by_id" gorm:"not null"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Name คือชื่อจริงหรือนามแฝง (IsPseudonym = true) ที่ใช้เรียกผู้รับบริการในระบบ
+	Name          string     `json:"name" gorm:"type:text;not null"`
+	IsPseudonym   bool       `json:"is_pseudonym" gorm:"not null;default:false"`
+	DateOfBirth   *time.Time `json:"date_of_birth" gorm:"type:date"`
+	DiagnosisTags []string   `json:"diagnosis_tags" gorm:"type:jsonb;not null;default:'[]';serializer:json"`
+	Notes         string     `json:"notes" gorm:"type:text"`
+
+	Guardians    []ClientGuardian `json:"guardians" gorm:"foreignKey:ClientID;constraint:OnDelete:CASCADE"`
+	Organization *Organization    `json:"organization,omitempty" gorm:"foreignKey:OrganizationID;constraint:OnDelete:RESTRICT"`
+}
+
+// ClientGuardian คือผู้ปกครองหรือผู้ติดต่อของผู้รับบริการ เรียงตาม Position
+type ClientGuardian struct {
+	ID           uint      `json:"guardian_id" gorm:"primaryKey;autoIncrement"`
+	ClientID     uint      `json:"client_id" gorm:"not null;index"`
+	Position     int       `json:"position" gorm:"not null"`
+	Name         string    `json:"name" gorm:"type:text;not null"`
+	Relationship string    `json:"relationship" gorm:"type:text"`
+	Phone        string    `json:"phone" gorm:"type:text"`
+	Email        string    `json:"email" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ClientsAccessibleBy จำกัด query ของ clients ให้เหลือเฉพาะผู้รับบริการที่ผู้ใช้เป็นเจ้าของ
+// หรืออยู่ในหน่วยงานที่ผู้ใช้เป็นสมาชิก ใช้กับ db.Scopes
+func ClientsAccessibleBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("clients.owner_user_id = ? OR clients.organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)", userID, userID)
+	}
+}
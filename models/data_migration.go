@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// DataMigration บันทึก migration ข้อมูลที่ทำไปแล้ว (ดู migrations.RunOnce) เพื่อไม่ให้ทำซ้ำทุกครั้งที่เปิดเซิร์ฟเวอร์
+type DataMigration struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:text"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
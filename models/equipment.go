@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Equipment คือรายการอุปกรณ์/เครื่องดนตรีกลางที่ใช้อ้างอิงจากกิจกรรม
+type Equipment struct {
+	ID        uint      `json:"equipment_id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"type:text;not null;uniqueIndex"`
+	Type      string    `json:"type" gorm:"type:text;index"`
+	ImageURL  string    `json:"image_url" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ActivityEquipment เชื่อมกิจกรรมกับอุปกรณ์ (many-to-many) พร้อมจำนวนที่ต้องใช้
+// Quantity ชิ้นต่อผู้เข้าร่วม PerGroupSize คน ถ้า PerGroupSize เป็น 0 หมายถึงใช้ Quantity ชิ้นทั้งกลุ่ม
+type ActivityEquipment struct {
+	ActivityID   uint `json:"activity_id" gorm:"primaryKey"`
+	EquipmentID  uint `json:"equipment_id" gorm:"primaryKey;index"`
+	Quantity     int  `json:"quantity" gorm:"not null;default:1"`
+	PerGroupSize int  `json:"per_group_size" gorm:"not null;default:0"`
+
+	Equipment Equipment `json:"equipment" gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Media purposes กำหนดว่าไฟล์อัปโหลดถูกใช้กับอะไร และใครมีสิทธิ์อัปโหลด
+const (
+	MediaPurposeProfile       = "profile"
+	MediaPurposeActivityCover = "activity_cover"
+	MediaPurposeSongImage     = "song_image"
+	MediaPurposeSongAudio     = "song_audio"
+	MediaPurposeStep          = "step"
+)
+
+// ImageContentTypes และ AudioContentTypes คือชนิดไฟล์ (ตรวจจากเนื้อหาด้วย http.DetectContentType) พร้อมนามสกุลที่ใช้ตั้งชื่อไฟล์
+var ImageContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+var AudioContentTypes = map[string]string{
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"application/ogg": ".ogg",
+}
+
+// MediaContentTypes คือชนิดไฟล์ที่แต่ละ purpose รับได้ ใช้ทั้งตอนอัปโหลดและนำเข้า package
+var MediaContentTypes = map[string]map[string]string{
+	MediaPurposeProfile:       ImageContentTypes,
+	MediaPurposeActivityCover: ImageContentTypes,
+	MediaPurposeSongImage:     ImageContentTypes,
+	MediaPurposeStep:          ImageContentTypes,
+	MediaPurposeSongAudio:     AudioContentTypes,
+}
+
+// สถานะการสร้างภาพย่อย (variants) ของไฟล์ภาพ
+const (
+	VariantStatusPending = "pending"
+	VariantStatusReady   = "ready"
+	VariantStatusFailed  = "failed"
+)
+
+// Media คือไฟล์ที่อัปโหลดเข้าระบบ ตัวไฟล์อยู่ใน storage backend ส่วนตารางนี้เก็บเจ้าของและข้อมูลไฟล์
+// ส่วนอื่นของระบบอ้างอิงไฟล์ผ่าน media_id เท่านั้น ไม่อ้างอิง path โดยตรง
+type Media struct {
+	ID            uint           `json:"media_id" gorm:"primaryKey;autoIncrement"`
+	Key           string         `json:"key" gorm:"column:media_key;type:text;not null;uniqueIndex"`
+	OwnerID       uint           `json:"owner_id" gorm:"index;not null"`
+	Purpose       string         `json:"purpose" gorm:"type:text;not null;index"`
+	OriginalName  string         `json:"original_name" gorm:"type:text"`
+	ContentType   string         `json:"content_type" gorm:"type:text;not null"`
+	Size          int64          `json:"size" gorm:"not null"`
+	Width         int            `json:"width,omitempty"`
+	Height        int            `json:"height,omitempty"`
+	Backend       string         `json:"-" gorm:"type:text;not null"`
+	StorageKey    string         `json:"-" gorm:"type:text;not null"`
+	VariantStatus string         `json:"variant_status,omitempty" gorm:"type:text;index"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	URL      string         `json:"url" gorm:"-"`
+	Variants []MediaVariant `json:"variants,omitempty" gorm:"foreignKey:MediaID;constraint:OnDelete:CASCADE"`
+}
+
+// MediaVariant คือภาพที่ย่อขนาดจากไฟล์ต้นฉบับ (thumb, card, full) สำหรับแสดงผลบนมือถือ
+type MediaVariant struct {
+	ID          uint   `json:"-" gorm:"primaryKey;autoIncrement"`
+	MediaID     uint   `json:"-" gorm:"not null;uniqueIndex:idx_media_variant_name"`
+	Name        string `json:"name" gorm:"type:text;not null;uniqueIndex:idx_media_variant_name"`
+	Key         string `json:"-" gorm:"column:media_key;type:text;not null;uniqueIndex"`
+	StorageKey  string `json:"-" gorm:"type:text;not null"`
+	ContentType string `json:"content_type" gorm:"type:text;not null"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+
+	URL string `json:"url" gorm:"-"`
+}
+
+func (v *MediaVariant) AfterFind(tx *gorm.DB) error {
+	v.URL = MediaURL(v.Key)
+	return nil
+}
+
+var mediaBaseURL string
+
+// SetMediaBaseURL กำหนด prefix ของ URL สาธารณะที่ระบบสร้างให้ (เช่น https://api.example.com)
+// ถ้าไม่กำหนดจะได้ URL แบบ relative เช่น "/media/<key>"
+func SetMediaBaseURL(base string) {
+	mediaBaseURL = strings.TrimRight(base, "/")
+}
+
+// PublicURL ต่อ path ของ API เข้ากับ base URL สาธารณะ
+func PublicURL(path string) string {
+	return mediaBaseURL + path
+}
+
+// MediaURL คืน URL สาธารณะของไฟล์จาก key
+func MediaURL(key string) string {
+	return PublicURL("/media/" + key)
+}
+
+func (m *Media) AfterFind(tx *gorm.DB) error {
+	m.URL = MediaURL(m.Key)
+	return nil
+}
+
+func (m *Media) AfterCreate(tx *gorm.DB) error {
+	m.URL = MediaURL(m.Key)
+	return nil
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ระดับการช่วยเหลือ (prompt) ที่ผู้รับบริการต้องใช้ เรียงจากน้อยไปมาก
+const (
+	PromptIndependent     = "independent"
+	PromptGestural        = "gestural"
+	PromptVerbal          = "verbal"
+	PromptVisual          = "visual"
+	PromptModel           = "model"
+	PromptPartialPhysical = "partial_physical"
+	PromptFullPhysical    = "full_physical"
+)
+
+// PromptLevels คือระดับการช่วยเหลือทั้งหมดตามลำดับ
+var PromptLevels = []string{
+	PromptIndependent, PromptGestural, PromptVerbal, PromptVisual,
+	PromptModel, PromptPartialPhysical, PromptFullPhysical,
+}
+
+// ValidPromptLevel ตรวจว่าระดับการช่วยเหลือถูกต้อง (ค่าว่างหมายถึงไม่ได้บันทึก)
+func ValidPromptLevel(level string) bool {
+	if level == "" {
+		return true
+	}
+	for _, l := range PromptLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// RatingScale คือมาตรวัดที่ใช้ให้คะแนนผลการสังเกต เช่น 1-5 ระดับ แต่ละระดับมีคำอธิบายของตนเอง
+// มาตรวัดที่ IsDefault ใช้เมื่อไม่ได้ระบุ rating_scale_id (มีได้มาตรวัดเดียว)
+type RatingScale struct {
+	ID          uint      `json:"rating_scale_id" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"type:text;not null;unique"`
+	Description string    `json:"description" gorm:"type:text"`
+	IsDefault   bool      `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Levels []RatingScaleLevel `json:"levels" gorm:"foreignKey:RatingScaleID;constraint:OnDelete:CASCADE"`
+}
+
+// RatingScaleLevel คือค่าหนึ่งระดับของมาตรวัด
+type RatingScaleLevel struct {
+	RatingScaleID uint   `json:"-" gorm:"primaryKey"`
+	Value         int    `json:"value" gorm:"primaryKey;autoIncrement:false"`
+	Label         string `json:"label" gorm:"type:text;not null"`
+}
+
+// Range คืนค่าต่ำสุดและสูงสุดของมาตรวัด (ต้อง preload Levels มาแล้ว)
+func (s RatingScale) Range() (min, max int) {
+	for i, level := range s.Levels {
+		if i == 0 || level.Value < min {
+			min = level.Value
+		}
+		if i == 0 || level.Value > max {
+			max = level.Value
+		}
+	}
+	return min, max
+}
+
+// HasLevel ตรวจว่า value เป็นระดับหนึ่งของมาตรวัด (ต้อง preload Levels มาแล้ว)
+func (s RatingScale) HasLevel(value int) bool {
+	for _, level := range s.Levels {
+		if level.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Observation คือผลการสังเกตผู้รับบริการหนึ่งคนใน session หนึ่งครั้ง
+// ผูกกับกิจกรรม (ผ่านรายการใน session หรือระบุ activity_id) และเป้าหมายย่อยที่สังเกตได้
+// Rating ตีความตามมาตรวัด RatingScaleID ส่วน PromptLevel คือระดับการช่วยเหลือที่ต้องใช้
+type Observation struct {
+	ID            uint      `json:"observation_id" gorm:"primaryKey;autoIncrement"`
+	SessionID     uint      `json:"session_id" gorm:"not null;index"`
+	SessionItemID *uint     `json:"session_item_id" gorm:"index"`
+	ClientID      uint      `json:"client_id" gorm:"not null;index"`
+	ActivityID    *uint     `json:"activity_id" gorm:"index"`
+	SubGoalID     *uint     `json:"sub_goal_id" gorm:"index"`
+	RatingScaleID uint      `json:"rating_scale_id" gorm:"not null"`
+	Rating        *int      `json:"rating"`
+	PromptLevel   string    `json:"prompt_level" gorm:"type:text"`
+	Notes         string    `json:"notes" gorm:"type:text"`
+	ObservedAt    time.Time `json:"observed_at" gorm:"not null;index"`
+	RecordedByID  uint      `json:"recorded_by_id" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	SessionItem *SessionItem     `json:"-" gorm:"foreignKey:SessionItemID;constraint:OnDelete:SET NULL"`
+	Activity    *ActivitySummary `json:"activity,omitempty" gorm:"foreignKey:ActivityID;constraint:OnDelete:SET NULL"`
+	SubGoal     *ActivitySubGoal `json:"sub_goal,omitempty" gorm:"foreignKey:SubGoalID;constraint:OnDelete:SET NULL"`
+	RatingScale *RatingScale     `json:"-" gorm:"foreignKey:RatingScaleID;constraint:OnDelete:RESTRICT"`
+}
+
+// ObservationsAccessibleBy จำกัด query ของ observations ให้เหลือเฉพาะของ session ที่ผู้ใช้เข้าถึงได้
+func ObservationsAccessibleBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		sessions := db.Session(&gorm.Session{NewDB: true}).Model(&Session{}).Select("sessions.id").Scopes(SessionsAccessibleBy(userID))
+		return db.Where("observations.session_id IN (?)", sessions)
+	}
+}
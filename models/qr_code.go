@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActivityQRCode คือ QR code ที่ระบบสร้างจาก URL เป้าหมายของกิจกรรม (ช่อง 1 หรือ 2)
+// เก็บภาพขนาดเริ่มต้นไว้ และสร้างใหม่ทุกครั้งที่เป้าหมายหรือระดับเปลี่ยน
+type ActivityQRCode struct {
+	ID         uint      `json:"-" gorm:"primaryKey;autoIncrement"`
+	ActivityID uint      `json:"activity_id" gorm:"not null;uniqueIndex:idx_activity_qr_slot"`
+	Slot       int       `json:"slot" gorm:"not null;uniqueIndex:idx_activity_qr_slot"`
+	TargetURL  string    `json:"target_url" gorm:"type:text;not null"`
+	Level      string    `json:"level" gorm:"type:text;not null"`
+	Size       int       `json:"size" gorm:"not null"`
+	PNG        []byte    `json:"-" gorm:"type:bytea"`
+	SVG        string    `json:"-" gorm:"type:text"`
+	UpdatedAt  time.Time `json:"generated_at" gorm:"autoUpdateTime"`
+
+	PNGURL string `json:"png_url" gorm:"-"`
+	SVGURL string `json:"svg_url" gorm:"-"`
+}
+
+// QRCodeURL คืน URL ของภาพ QR ของกิจกรรม format เป็น "png" หรือ "svg"
+func QRCodeURL(activityID uint, slot int, format string) string {
+	url := PublicURL(fmt.Sprintf("/api/activities/%d/qr/%d", activityID, slot))
+	if format != "png" {
+		url += "?format=" + format
+	}
+	return url
+}
+
+func (q *ActivityQRCode) AfterFind(tx *gorm.DB) error {
+	q.PNGURL = QRCodeURL(q.ActivityID, q.Slot, "png")
+	q.SVGURL = QRCodeURL(q.ActivityID, q.Slot, "svg")
+	return nil
+}
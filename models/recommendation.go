@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ActivityCooccurrence คือความถี่ที่ผู้ใช้คนเดียวกันอ่านหรือกด favorite ทั้งสองกิจกรรม (item-to-item)
+// Score เป็น cosine similarity ของชุดผู้ใช้ของสองกิจกรรม คำนวณใหม่ทั้งตารางเป็นระยะโดย package recommend
+type ActivityCooccurrence struct {
+	ActivityID        uint      `json:"activity_id" gorm:"primaryKey;autoIncrement:false"`
+	RelatedActivityID uint      `json:"related_activity_id" gorm:"primaryKey;autoIncrement:false"`
+	UserCount         int       `json:"user_count" gorm:"not null"`
+	Score             float64   `json:"score" gorm:"not null"`
+	ComputedAt        time.Time `json:"computed_at"`
+}
+
+// ActivityPopularity คือความนิยมของกิจกรรมในช่วงเวลาล่าสุด ใช้แนะนำผู้ใช้ใหม่ที่ยังไม่มีประวัติ
+type ActivityPopularity struct {
+	ActivityID uint      `json:"activity_id" gorm:"primaryKey;autoIncrement:false"`
+	Readers    int       `json:"readers" gorm:"not null"`
+	Favorites  int       `json:"favorites" gorm:"not null"`
+	Score      float64   `json:"score" gorm:"not null;index"`
+	ComputedAt time.Time `json:"computed_at"`
+}
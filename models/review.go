@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	ReviewStatusVisible = "visible"
+	ReviewStatusHidden  = "hidden"
+
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+
+	RatingMin = 1
+	RatingMax = 5
+)
+
+// ActivityReview คือคะแนน (1-5 ดาว) และความเห็นของสมาชิกต่อกิจกรรม หนึ่งคนมีได้หนึ่งรีวิวต่อกิจกรรม
+// รีวิวที่ admin ซ่อน (hidden) ไม่แสดงต่อสาธารณะและไม่นับในคะแนนเฉลี่ยของกิจกรรม
+type ActivityReview struct {
+	ID           uint       `json:"review_id" gorm:"primaryKey;autoIncrement"`
+	ActivityID   uint       `json:"activity_id" gorm:"not null;uniqueIndex:idx_review_user_activity,priority:2;index"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_review_user_activity,priority:1"`
+	Rating       int        `json:"rating" gorm:"not null"`
+	Comment      string     `json:"comment" gorm:"type:text"`
+	Status       string     `json:"status" gorm:"type:text;not null;default:visible;index"`
+	HiddenReason string     `json:"hidden_reason,omitempty" gorm:"type:text"`
+	HiddenByID   *uint      `json:"hidden_by_id,omitempty"`
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Author  *ReviewAuthor  `json:"author,omitempty" gorm:"foreignKey:UserID"`
+	Reports []ReviewReport `json:"reports,omitempty" gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE"`
+}
+
+// ReviewAuthor คือข้อมูลผู้เขียนรีวิวที่เปิดเผยได้ (อ่านจากตาราง users)
+type ReviewAuthor struct {
+	ID        uint   `json:"id"`
+	FirstName string `json:"first_name" gorm:"column:firstname"`
+	LastName  string `json:"last_name" gorm:"column:lastname"`
+	Profile   string `json:"profile" gorm:"column:profile"`
+}
+
+func (ReviewAuthor) TableName() string { return "users" }
+
+// ReviewReport คือการแจ้งรีวิวไม่เหมาะสมโดยสมาชิก หนึ่งคนแจ้งรีวิวเดียวกันได้ครั้งเดียว
+type ReviewReport struct {
+	ID           uint       `json:"report_id" gorm:"primaryKey;autoIncrement"`
+	ReviewID     uint       `json:"review_id" gorm:"not null;uniqueIndex:idx_report_review_user,priority:1"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_report_review_user,priority:2"`
+	Reason       string     `json:"reason" gorm:"type:text;not null"`
+	Status       string     `json:"status" gorm:"type:text;not null;default:open;index"`
+	ResolvedByID *uint      `json:"resolved_by_id,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SessionStatusPlanned   = "planned"
+	SessionStatusCompleted = "completed"
+	SessionStatusCancelled = "cancelled"
+)
+
+// ValidSessionStatus ตรวจว่าสถานะของ session ถูกต้อง
+func ValidSessionStatus(status string) bool {
+	return status == SessionStatusPlanned || status == SessionStatusCompleted || status == SessionStatusCancelled
+}
+
+// ClientGroup คือกลุ่มผู้รับบริการที่เข้า session ร่วมกัน เจ้าของเป็นแบบเดียวกับ Client
+// (นักบำบัดหรือหน่วยงาน อย่างใดอย่างหนึ่ง)
+type ClientGroup struct {
+	ID             uint           `json:"client_group_id" gorm:"primaryKey;autoIncrement"`
+	OwnerUserID    *uint          `json:"owner_user_id" gorm:"index"`
+	OrganizationID *uint          `json:"organization_id" gorm:"index"`
+	CreatedByID    uint           `json:"created_by_id" gorm:"not null"`
+	Name           string         `json:"name" gorm:"type:text;not null"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Members []Client `json:"members" gorm:"many2many:client_group_members;"`
+}
+
+// Session คือแผนการบำบัดหนึ่งครั้งสำหรับผู้รับบริการหนึ่งคน (ClientID) หรือหนึ่งกลุ่ม (ClientGroupID)
+// สิทธิ์เข้าถึง session ตามสิทธิ์ของผู้รับบริการ/กลุ่มนั้น
+type Session struct {
+	ID            uint      `json:"session_id" gorm:"primaryKey;autoIncrement"`
+	ClientID      *uint     `json:"client_id" gorm:"index"`
+	ClientGroupID *uint     `json:"client_group_id" gorm:"index"`
+	StartsAt      time.Time `json:"starts_at" gorm:"not null;index"`
+	EndsAt        time.Time `json:"ends_at" gorm:"not null"`
+	Location      string    `json:"location" gorm:"type:text"`
+	Notes         string    `json:"notes" gorm:"type:text"`
+	Status        string    `json:"status" gorm:"type:text;not null;default:planned;index"`
+	CreatedByID   uint      `json:"created_by_id" gorm:"not null"`
+	// DuplicatedFromID คือ session ต้นแบบเมื่อ session นี้ถูกสร้างด้วยการคัดลอก
+	DuplicatedFromID *uint     `json:"duplicated_from_id"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Client      *Client       `json:"client,omitempty" gorm:"foreignKey:ClientID;constraint:OnDelete:RESTRICT"`
+	ClientGroup *ClientGroup  `json:"client_group,omitempty" gorm:"foreignKey:ClientGroupID;constraint:OnDelete:RESTRICT"`
+	Items       []SessionItem `json:"items" gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE"`
+}
+
+// SessionItem คือกิจกรรมหนึ่งรายการใน session เรียงตาม Position
+// ActivityTitle เก็บชื่อกิจกรรม ณ ตอนวางแผนไว้ ถ้ากิจกรรมถูกลบภายหลัง ActivityID จะเป็น null แต่แผนยังอ่านได้
+type SessionItem struct {
+	ID             uint      `json:"item_id" gorm:"primaryKey;autoIncrement"`
+	SessionID      uint      `json:"session_id" gorm:"not null;index"`
+	Position       int       `json:"position" gorm:"not null"`
+	ActivityID     *uint     `json:"activity_id" gorm:"index"`
+	ActivityTitle  string    `json:"activity_title" gorm:"type:text;not null"`
+	PlannedMinutes *int      `json:"planned_minutes"`
+	Notes          string    `json:"notes" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Activity *ActivitySummary `json:"activity,omitempty" gorm:"foreignKey:ActivityID;constraint:OnDelete:SET NULL"`
+}
+
+// ClientGroupsAccessibleBy จำกัด query ของ client_groups แบบเดียวกับ ClientsAccessibleBy
+func ClientGroupsAccessibleBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("client_groups.owner_user_id = ? OR client_groups.organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)", userID, userID)
+	}
+}
+
+// SessionsAccessibleBy จำกัด query ของ sessions ให้เหลือเฉพาะ session ของผู้รับบริการ/กลุ่มที่ผู้ใช้เข้าถึงได้
+// (ไม่รวมผู้รับบริการ/กลุ่มที่ถูกลบแล้ว)
+func SessionsAccessibleBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		clients := db.Session(&gorm.Session{NewDB: true}).Model(&Client{}).Select("clients.id").Scopes(ClientsAccessibleBy(userID))
+		groups := db.Session(&gorm.Session{NewDB: true}).Model(&ClientGroup{}).Select("client_groups.id").Scopes(ClientGroupsAccessibleBy(userID))
+		return db.Where("sessions.client_id IN (?) OR sessions.client_group_id IN (?)", clients, groups)
+	}
+}
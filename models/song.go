@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Song คือเพลงในคลังเพลงกลาง ใช้ร่วมกันได้หลายกิจกรรม (many-to-many ผ่าน activity_songs)
+type Song struct {
+	ID              uint      `json:"song_id" gorm:"primaryKey;autoIncrement"`
+	Title           string    `json:"title" gorm:"type:text;not null"`
+	NormalizedTitle string    `json:"-" gorm:"type:text;index"`
+	Composer        string    `json:"composer" gorm:"type:text"`
+	Lyricist        string    `json:"lyricist" gorm:"type:text"`
+	Language        string    `json:"language" gorm:"type:text"`
+	TempoBPM        *int      `json:"tempo_bpm"`
+	Key             string    `json:"key" gorm:"column:musical_key;type:text"`
+	TimeSignature   string    `json:"time_signature" gorm:"type:text"`
+	Lyrics          string    `json:"lyrics" gorm:"type:text"`
+	CoverImage      string    `json:"cover_image" gorm:"type:text"`
+	AudioURL        string    `json:"audio_url" gorm:"type:text"`
+	CoverMediaID    *uint     `json:"cover_media_id"`
+	AudioMediaID    *uint     `json:"audio_media_id"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	CoverMedia *Media `json:"cover_media,omitempty" gorm:"foreignKey:CoverMediaID;constraint:OnDelete:SET NULL"`
+	AudioMedia *Media `json:"audio_media,omitempty" gorm:"foreignKey:AudioMediaID;constraint:OnDelete:SET NULL"`
+}
+
+func (s *Song) BeforeSave(tx *gorm.DB) error {
+	s.NormalizedTitle = NormalizeSongTitle(s.Title)
+	return nil
+}
+
+// NormalizeSongTitle ทำให้ชื่อเพลงที่สะกดต่างกันเล็กน้อย (ช่องว่าง ตัวพิมพ์ เครื่องหมาย) เทียบกันได้
+func NormalizeSongTitle(title string) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	title = strings.Trim(title, `"'“”‘’`)
+	return strings.Join(strings.Fields(title), " ")
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TargetPopulation คือกลุ่มผู้รับการบำบัดที่กิจกรรมเหมาะสม เช่น กลุ่มอาการออทิสติก ภาวะสมองเสื่อม
+type TargetPopulation struct {
+	ID          uint      `json:"target_population_id" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"type:text;not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
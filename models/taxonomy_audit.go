@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TaxonomyKindGoal        = "goal"
+	TaxonomyKindSubGoal     = "sub_goal"
+	TaxonomyKindCategory    = "category"
+	TaxonomyKindSubCategory = "sub_category"
+
+	TaxonomyActionCreate  = "create"
+	TaxonomyActionUpdate  = "update"
+	TaxonomyActionReorder = "reorder"
+	TaxonomyActionMerge   = "merge"
+	TaxonomyActionRetire  = "retire"
+	TaxonomyActionRestore = "restore"
+)
+
+// TaxonomyAuditLog บันทึกทุกการเปลี่ยนแปลง taxonomy จากหน้า admin
+// Before/After เก็บสถานะของรายการก่อนและหลังเปลี่ยน ส่วน merge เก็บจำนวนลิงก์ที่ถูกย้ายไว้ใน After
+type TaxonomyAuditLog struct {
+	ID        uint      `json:"audit_id" gorm:"primaryKey;autoIncrement"`
+	Kind      string    `json:"kind" gorm:"type:text;not null;index:idx_taxonomy_audit_entity"`
+	EntityID  uint      `json:"entity_id" gorm:"not null;index:idx_taxonomy_audit_entity"`
+	Action    string    `json:"action" gorm:"type:text;not null"`
+	Before    string    `json:"-" gorm:"type:jsonb;default:null"`
+	After     string    `json:"-" gorm:"type:jsonb;default:null"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+
+	BeforeJSON json.RawMessage `json:"before,omitempty" gorm:"-"`
+	AfterJSON  json.RawMessage `json:"after,omitempty" gorm:"-"`
+}
+
+func (l *TaxonomyAuditLog) AfterFind(tx *gorm.DB) error {
+	if l.Before != "" {
+		l.BeforeJSON = json.RawMessage(l.Before)
+	}
+	if l.After != "" {
+		l.AfterJSON = json.RawMessage(l.After)
+	}
+	return nil
+}
+
+// ActiveTaxonomy กรองรายการที่ยังไม่ถูกยกเลิก และเรียงตามลำดับที่ admin กำหนด
+func ActiveTaxonomy(db *gorm.DB) *gorm.DB {
+	return db.Where("retired_at IS NULL").Order("sort_order ASC, id ASC")
+}
+
+// TaxonomyOrder เรียงตามลำดับที่ admin กำหนด รวมรายการที่ถูกยกเลิกแล้ว
+func TaxonomyOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, id ASC")
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// สถานะของแผนการบำบัดและของเป้าหมายแต่ละข้อในแผน
+const (
+	PlanStatusActive       = "active"
+	PlanStatusMet          = "met"
+	PlanStatusDiscontinued = "discontinued"
+)
+
+// ValidPlanStatus ตรวจว่าสถานะของแผน/เป้าหมายในแผนถูกต้อง
+func ValidPlanStatus(status string) bool {
+	return status == PlanStatusActive || status == PlanStatusMet || status == PlanStatusDiscontinued
+}
+
+// TherapyPlan คือแผนการบำบัดรายบุคคล (แบบ IEP) ของผู้รับบริการ ประกอบด้วยเป้าหมายย่อยที่วัดผลได้
+// ทุกครั้งที่แก้ไข Version เพิ่มขึ้นและเก็บสำเนาของแผนไว้ใน TherapyPlanVersion
+// สิทธิ์เข้าถึงแผนตามสิทธิ์ของผู้รับบริการ
+type TherapyPlan struct {
+	ID          uint           `json:"plan_id" gorm:"primaryKey;autoIncrement"`
+	ClientID    uint           `json:"client_id" gorm:"not null;index"`
+	Title       string         `json:"title" gorm:"type:text;not null"`
+	StartDate   time.Time      `json:"start_date" gorm:"type:date;not null"`
+	EndDate     time.Time      `json:"end_date" gorm:"type:date;not null"`
+	Status      string         `json:"status" gorm:"type:text;not null;default:active;index"`
+	Notes       string         `json:"notes" gorm:"type:text"`
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	CreatedByID uint           `json:"created_by_id" gorm:"not null"`
+	UpdatedByID uint           `json:"updated_by_id" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Client *Client    `json:"-" gorm:"foreignKey:ClientID;constraint:OnDelete:RESTRICT"`
+	Goals  []PlanGoal `json:"goals" gorm:"foreignKey:PlanID;constraint:OnDelete:CASCADE"`
+}
+
+// PlanGoal คือเป้าหมายย่อยหนึ่งข้อในแผน พร้อมเกณฑ์ที่วัดได้
+// BaselineScore และ TargetScore ใช้หน่วยเดียวกับ progress (คะแนน 0-100 ที่ปรับตามมาตรวัดแล้ว)
+// TargetCriterion คือเกณฑ์ที่อ่านเข้าใจได้ เช่น "รอคอยได้ 2 นาทีโดยไม่ต้องช่วยเหลือ 4 ใน 5 ครั้ง"
+type PlanGoal struct {
+	ID              uint      `json:"plan_goal_id" gorm:"primaryKey;autoIncrement"`
+	PlanID          uint      `json:"plan_id" gorm:"not null;index"`
+	Position        int       `json:"position" gorm:"not null"`
+	SubGoalID       uint      `json:"sub_goal_id" gorm:"not null;index"`
+	BaselineScore   *float64  `json:"baseline_score"`
+	TargetScore     float64   `json:"target_score" gorm:"not null"`
+	TargetCriterion string    `json:"target_criterion" gorm:"type:text"`
+	ReviewDate      time.Time `json:"review_date" gorm:"type:date;not null"`
+	Status          string    `json:"status" gorm:"type:text;not null;default:active"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	SubGoal *ActivitySubGoal `json:"sub_goal,omitempty" gorm:"foreignKey:SubGoalID;constraint:OnDelete:RESTRICT"`
+}
+
+// TherapyPlanSnapshot คือข้อมูลของแผนหนึ่งฉบับที่เก็บไว้ในประวัติ
+type TherapyPlanSnapshot struct {
+	Title     string     `json:"title"`
+	StartDate time.Time  `json:"start_date"`
+	EndDate   time.Time  `json:"end_date"`
+	Status    string     `json:"status"`
+	Notes     string     `json:"notes"`
+	Goals     []PlanGoal `json:"goals"`
+}
+
+// TherapyPlanVersion คือสำเนาของแผนหลังการบันทึกแต่ละครั้ง (ฉบับที่ 1 คือตอนสร้าง)
+type TherapyPlanVersion struct {
+	ID          uint                `json:"plan_version_id" gorm:"primaryKey;autoIncrement"`
+	PlanID      uint                `json:"plan_id" gorm:"not null;uniqueIndex:idx_plan_version,priority:1"`
+	Version     int                 `json:"version" gorm:"not null;uniqueIndex:idx_plan_version,priority:2"`
+	Snapshot    TherapyPlanSnapshot `json:"snapshot" gorm:"type:jsonb;not null;serializer:json"`
+	ChangedByID uint                `json:"changed_by_id" gorm:"not null"`
+	CreatedAt   time.Time           `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Snapshot คืนสำเนาของแผน (ต้อง preload Goals มาแล้ว)
+func (p TherapyPlan) Snapshot() TherapyPlanSnapshot {
+	return TherapyPlanSnapshot{
+		Title:     p.Title,
+		StartDate: p.StartDate,
+		EndDate:   p.EndDate,
+		Status:    p.Status,
+		Notes:     p.Notes,
+		Goals:     p.Goals,
+	}
+}
+
+// TherapyPlansAccessibleBy จำกัด query ของ therapy_plans ให้เหลือเฉพาะแผนของผู้รับบริการที่ผู้ใช้เข้าถึงได้
+func TherapyPlansAccessibleBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		clients := db.Session(&gorm.Session{NewDB: true}).Model(&Client{}).Select("clients.id").Scopes(ClientsAccessibleBy(userID))
+		return db.Where("therapy_plans.client_id IN (?)", clients)
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Translation คือคำแปลของข้อความหนึ่งฟิลด์ของข้อมูลหนึ่งรายการในภาษาหนึ่ง
+// ข้อความต้นฉบับ (ภาษาไทย) ยังอยู่ในคอลัมน์ของตารางเดิม ตารางนี้เก็บเฉพาะภาษาอื่น
+// Field ตรงกับชื่อคอลัมน์ต้นฉบับ เช่น title, goal_name
+type Translation struct {
+	ID          uint      `json:"translation_id" gorm:"primaryKey;autoIncrement"`
+	EntityType  string    `json:"entity_type" gorm:"type:text;not null;uniqueIndex:idx_translation_key,priority:1"`
+	EntityID    uint      `json:"entity_id" gorm:"not null;uniqueIndex:idx_translation_key,priority:2"`
+	Field       string    `json:"field" gorm:"type:text;not null;uniqueIndex:idx_translation_key,priority:3"`
+	Locale      string    `json:"locale" gorm:"type:text;not null;uniqueIndex:idx_translation_key,priority:4;index"`
+	Value       string    `json:"value" gorm:"type:text;not null"`
+	UpdatedByID uint      `json:"updated_by_id"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -7,19 +7,21 @@ import (
 )
 
 type User struct {
-	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	FirstName   string         `json:"first_name" gorm:"column:firstname;not null"`
-	LastName    string         `json:"last_name" gorm:"column:lastname;not null"`
-	DateOfBirth time.Time      `json:"date_of_birth" gorm:"column:date_of_birth;type:date"`
-	Email       string         `json:"email" gorm:"column:email;not null;unique"`
-	Password    string         `json:"-" gorm:"column:password;not null"`
-	PhoneNumber string         `json:"phone_number" gorm:"column:phone;not null"`
-	Profile     string         `json:"profile" gorm:"column:profile"`
-	RoleID      uint           `json:"role_id"`
-	Role        *Role          `json:"-" gorm:"foreignKey:RoleID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	ID             uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	FirstName      string         `json:"first_name" gorm:"column:firstname;not null"`
+	LastName       string         `json:"last_name" gorm:"column:lastname;not null"`
+	DateOfBirth    time.Time      `json:"date_of_birth" gorm:"column:date_of_birth;type:date"`
+	Email          string         `json:"email" gorm:"column:email;not null;unique"`
+	Password       string         `json:"-" gorm:"column:password;not null"`
+	PhoneNumber    string         `json:"phone_number" gorm:"column:phone;not null"`
+	Profile        string         `json:"profile" gorm:"column:profile"`
+	ProfileMediaID *uint          `json:"profile_media_id"`
+	ProfileMedia   *Media         `json:"profile_media,omitempty" gorm:"foreignKey:ProfileMediaID;constraint:OnDelete:SET NULL"`
+	RoleID         uint           `json:"role_id"`
+	Role           *Role          `json:"-" gorm:"foreignKey:RoleID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 
 	Favorites   []UserFavorite    `json:"favorites" gorm:"foreignKey:UserID"`
 	ReadHistory []UserReadHistory `json:"read_history" gorm:"foreignKey:UserID"`
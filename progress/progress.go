@@ -0,0 +1,287 @@
+// Package progress สรุปคะแนนผลการสังเกตของผู้รับบริการตามเป้าหมายย่อยหรือเป้าหมายหลักเป็นช่วงเวลา
+// (รายวัน รายสัปดาห์ รายเดือน) พร้อมแนวโน้มและการเทียบกับ baseline ในรูปแบบที่นำไปวาดกราฟได้ทันที
+//
+// คะแนนของแต่ละผลการสังเกตถูกปรับเป็น 0-100 ตามมาตรวัดของตนเอง (ค่าต่ำสุด = 0, ค่าสูงสุด = 100)
+// จึงรวมผลที่ใช้มาตรวัดต่างกันเข้าด้วยกันได้
+package progress
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ขนาดช่วงเวลาที่รองรับ ใช้เป็นค่า field ของ date_trunc ด้วย
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// ระดับการสรุป: ตามเป้าหมายย่อย หรือรวมเป้าหมายย่อยทั้งหมดของเป้าหมายหลัก
+const (
+	LevelSubGoal = "sub_goal"
+	LevelGoal    = "goal"
+)
+
+// ทิศทางของแนวโน้ม
+const (
+	TrendImproving    = "improving"
+	TrendDeclining    = "declining"
+	TrendStable       = "stable"
+	TrendInsufficient = "insufficient_data"
+)
+
+// StableSlope คือความชันสูงสุด (คะแนนต่อช่วงเวลา) ที่ยังถือว่าคงที่
+const StableSlope = 1.0
+
+// MaxBuckets คือจำนวนช่วงเวลาสูงสุดต่อการขอหนึ่งครั้ง
+const MaxBuckets = 400
+
+// labelLayout คือรูปแบบวันที่เริ่มต้นของช่วงเวลา ใช้เป็นแกน x ของกราฟ
+const labelLayout = "2006-01-02"
+
+// ValidBucket ตรวจว่าขนาดช่วงเวลาถูกต้อง
+func ValidBucket(bucket string) bool {
+	return bucket == BucketDay || bucket == BucketWeek || bucket == BucketMonth
+}
+
+// ValidLevel ตรวจว่าระดับการสรุปถูกต้อง
+func ValidLevel(level string) bool {
+	return level == LevelSubGoal || level == LevelGoal
+}
+
+// BucketStart คือวันเริ่มต้นของช่วงเวลาที่ t อยู่ (สัปดาห์เริ่มวันจันทร์ ตรงกับ date_trunc ของ PostgreSQL)
+func BucketStart(t time.Time, bucket string) time.Time {
+	y, m, d := t.Date()
+	switch bucket {
+	case BucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	case BucketWeek:
+		day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// Labels คือวันเริ่มต้นของทุกช่วงเวลาตั้งแต่ from ถึงก่อน to (ตามเขตเวลาของ from)
+// ช่วงเวลาที่ไม่มีผลการสังเกตก็อยู่ในรายการ เพื่อให้กราฟเว้นช่องได้ถูกต้อง
+func Labels(from, to time.Time, bucket string) ([]string, error) {
+	var labels []string
+	for t := BucketStart(from, bucket); t.Before(to); t = nextBucket(t, bucket) {
+		if len(labels) == MaxBuckets {
+			return nil, fmt.Errorf("range contains more than %d %s buckets", MaxBuckets, bucket)
+		}
+		labels = append(labels, t.Format(labelLayout))
+	}
+	return labels, nil
+}
+
+// Query คือเงื่อนไขของการสรุปคะแนน
+type Query struct {
+	ClientID  uint
+	Level     string
+	Bucket    string
+	From      time.Time
+	To        time.Time
+	Location  *time.Location
+	SubGoalID *uint
+	GoalID    *uint
+}
+
+// Row คือคะแนนเฉลี่ยของหนึ่งเส้น (เป้าหมายย่อยหรือเป้าหมายหลัก) ในหนึ่งช่วงเวลา
+type Row struct {
+	SeriesID     uint
+	Bucket       string
+	AverageScore float64
+	Count        int
+	Independent  int
+}
+
+// normalizedScore ปรับคะแนนเป็น 0-100 ตามค่าต่ำสุด/สูงสุดของมาตรวัด (มาตรวัดที่มีค่าเดียวถือเป็น 100)
+const normalizedScore = `CASE WHEN s.max_value = s.min_value THEN 100
+	ELSE (o.rating - s.min_value)::float8 * 100 / (s.max_value - s.min_value) END`
+
+// Aggregate รวมคะแนนผลการสังเกตที่มีคะแนนและเป้าหมายย่อยตาม q ด้วย date_trunc ตามเขตเวลาของ q.Location
+func Aggregate(db *gorm.DB, q Query) ([]Row, error) {
+	seriesColumn := "o.sub_goal_id"
+	if q.Level == LevelGoal {
+		seriesColumn = "sg.goal_id"
+	}
+
+	query := db.Table("observations AS o").
+		Select(fmt.Sprintf(`%s AS series_id,
+			to_char(date_trunc(@bucket, o.observed_at AT TIME ZONE @tz), 'YYYY-MM-DD') AS bucket,
+			AVG(%s) AS average_score,
+			COUNT(*) AS count,
+			COUNT(*) FILTER (WHERE o.prompt_level = 'independent') AS independent`, seriesColumn, normalizedScore),
+			map[string]interface{}{"bucket": q.Bucket, "tz": q.Location.String()}).
+		Joins("JOIN activity_sub_goals sg ON sg.id = o.sub_goal_id").
+		Joins("JOIN (SELECT rating_scale_id, MIN(value) AS min_value, MAX(value) AS max_value FROM rating_scale_levels GROUP BY rating_scale_id) s ON s.rating_scale_id = o.rating_scale_id").
+		Where("o.client_id = ? AND o.rating IS NOT NULL", q.ClientID).
+		Where("o.observed_at >= ? AND o.observed_at < ?", q.From, q.To)
+	if q.SubGoalID != nil {
+		query = query.Where("o.sub_goal_id = ?", *q.SubGoalID)
+	}
+	if q.GoalID != nil {
+		query = query.Where("sg.goal_id = ?", *q.GoalID)
+	}
+
+	var rows []Row
+	err := query.Group("1, 2").Order("1, 2").Scan(&rows).Error
+	return rows, err
+}
+
+// Point คือค่าของหนึ่งช่วงเวลาในหนึ่งเส้น
+type Point struct {
+	Bucket       string  `json:"bucket"`
+	AverageScore float64 `json:"average_score"`
+	Count        int     `json:"count"`
+	// IndependentRate คือสัดส่วน (0-1) ของผลการสังเกตที่ทำได้เองโดยไม่ต้องช่วยเหลือ
+	IndependentRate float64 `json:"independent_rate"`
+}
+
+// Comparison เทียบคะแนนล่าสุดกับ baseline (ช่วงเวลาแรกที่มีข้อมูล จำนวน BaselineBuckets ช่วง)
+type Comparison struct {
+	Baseline        *float64 `json:"baseline"`
+	BaselineBuckets []string `json:"baseline_buckets"`
+	Latest          *float64 `json:"latest"`
+	LatestBucket    string   `json:"latest_bucket,omitempty"`
+	Change          *float64 `json:"change"`
+}
+
+// Trend คือความชันของเส้นตรงที่ fit กับคะแนน (คะแนนต่อหนึ่งช่วงเวลา) และทิศทาง
+type Trend struct {
+	Slope     *float64 `json:"slope_per_bucket"`
+	Direction string   `json:"direction"`
+}
+
+// Series คือหนึ่งเส้นของกราฟ Data เรียงตาม labels (null คือช่วงเวลาที่ไม่มีผลการสังเกต)
+type Series struct {
+	ID     uint       `json:"id"`
+	Name   string     `json:"name"`
+	GoalID uint       `json:"goal_id,omitempty"`
+	Data   []*float64 `json:"data"`
+	Counts []int      `json:"counts"`
+	Points []Point    `json:"points"`
+
+	Observations int        `json:"observations"`
+	Comparison   Comparison `json:"comparison"`
+	Trend        Trend      `json:"trend"`
+}
+
+// Round ปัดคะแนนเป็นทศนิยมสองตำแหน่ง
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Build จัด rows เป็นเส้นตาม labels พร้อม baseline และแนวโน้ม เรียงตามลำดับที่ series ปรากฏใน rows
+func Build(rows []Row, labels []string, baselineBuckets int) []*Series {
+	index := make(map[string]int, len(labels))
+	for i, label := range labels {
+		index[label] = i
+	}
+
+	var order []*Series
+	byID := map[uint]*Series{}
+	for _, row := range rows {
+		pos, ok := index[row.Bucket]
+		if !ok {
+			continue
+		}
+		s, ok := byID[row.SeriesID]
+		if !ok {
+			s = &Series{ID: row.SeriesID, Data: make([]*float64, len(labels)), Counts: make([]int, len(labels))}
+			byID[row.SeriesID] = s
+			order = append(order, s)
+		}
+		score := Round(row.AverageScore)
+		s.Data[pos] = &score
+		s.Counts[pos] = row.Count
+		s.Observations += row.Count
+		s.Points = append(s.Points, Point{
+			Bucket:          row.Bucket,
+			AverageScore:    score,
+			Count:           row.Count,
+			IndependentRate: Round(float64(row.Independent) / float64(row.Count)),
+		})
+	}
+
+	for _, s := range order {
+		s.Comparison = compare(s.Points, baselineBuckets)
+		s.Trend = trend(s.Data)
+	}
+	return order
+}
+
+// compare ใช้ค่าเฉลี่ยถ่วงด้วยจำนวนผลการสังเกตของช่วงเวลาแรก ๆ เป็น baseline และช่วงเวลาล่าสุดเป็นค่าปัจจุบัน
+func compare(points []Point, baselineBuckets int) Comparison {
+	cmp := Comparison{BaselineBuckets: []string{}}
+	if len(points) == 0 {
+		return cmp
+	}
+	if baselineBuckets > len(points) {
+		baselineBuckets = len(points)
+	}
+	var sum float64
+	var count int
+	for _, p := range points[:baselineBuckets] {
+		sum += p.AverageScore * float64(p.Count)
+		count += p.Count
+		cmp.BaselineBuckets = append(cmp.BaselineBuckets, p.Bucket)
+	}
+	baseline := Round(sum / float64(count))
+	cmp.Baseline = &baseline
+
+	// ถ้ามีข้อมูลเฉพาะในช่วง baseline ยังไม่มีค่าล่าสุดให้เทียบ
+	if len(points) > baselineBuckets {
+		last := points[len(points)-1]
+		latest := last.AverageScore
+		change := Round(latest - baseline)
+		cmp.Latest, cmp.LatestBucket, cmp.Change = &latest, last.Bucket, &change
+	}
+	return cmp
+}
+
+// trend คำนวณความชันด้วย least squares โดยใช้ตำแหน่งของช่วงเวลาเป็นแกน x (ช่วงที่ว่างไม่นับแต่ระยะห่างยังอยู่)
+func trend(data []*float64) Trend {
+	var n, sumX, sumY, sumXY, sumXX float64
+	for i, v := range data {
+		if v == nil {
+			continue
+		}
+		x := float64(i)
+		n++
+		sumX += x
+		sumY += *v
+		sumXY += x * *v
+		sumXX += x * x
+	}
+	if n < 2 {
+		return Trend{Direction: TrendInsufficient}
+	}
+	slope := Round((n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX))
+	t := Trend{Slope: &slope, Direction: TrendStable}
+	switch {
+	case slope > StableSlope:
+		t.Direction = TrendImproving
+	case slope < -StableSlope:
+		t.Direction = TrendDeclining
+	}
+	return t
+}
@@ -0,0 +1,97 @@
+// Package qrcodes จัดการ QR code ที่ระบบสร้างให้กิจกรรม (ช่อง 1 และ 2)
+// ใช้ร่วมกันระหว่าง API และการนำเข้าคลังกิจกรรม
+package qrcodes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"project-backend/config"
+	"project-backend/helpers"
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// SlotColumns คือคอลัมน์ข้อความเดิม (qr_1, qr_2) ที่เก็บ URL ของภาพ QR ไว้ให้ client รุ่นเก่า
+var SlotColumns = map[int]string{1: "qr1", 2: "qr2"}
+
+// ParseSlot แปลงเลขช่อง QR จาก path
+func ParseSlot(raw string) (int, error) {
+	slot, err := strconv.Atoi(raw)
+	if _, ok := SlotColumns[slot]; err != nil || !ok {
+		return 0, fmt.Errorf("QR slot must be 1 or 2")
+	}
+	return slot, nil
+}
+
+// GeneratedSlots คืนช่อง QR ของกิจกรรมที่ระบบสร้างภาพไว้แล้ว
+func GeneratedSlots(db *gorm.DB, activityID uint) (map[int]bool, error) {
+	var slots []int
+	if err := db.Model(&models.ActivityQRCode{}).Where("activity_id = ?", activityID).Pluck("slot", &slots).Error; err != nil {
+		return nil, err
+	}
+	generated := make(map[int]bool, len(slots))
+	for _, slot := range slots {
+		generated[slot] = true
+	}
+	return generated, nil
+}
+
+// Sync ตั้งค่าเป้าหมายของ QR ช่อง slot และสร้างภาพใหม่เมื่อเป้าหมายหรือระดับเปลี่ยน
+// target ว่างหมายถึงลบ QR ช่องนั้น level ว่างใช้ระดับเดิมของช่องนั้น (หรือ cfg.DefaultLevel ถ้ายังไม่มี QR)
+// ต้องเรียกภายใน Transaction และตรวจ URL มาแล้ว
+func Sync(tx *gorm.DB, cfg *config.QRConfig, activityID uint, slot int, target, level string) error {
+	target = strings.TrimSpace(target)
+
+	var code models.ActivityQRCode
+	err := tx.Where("activity_id = ? AND slot = ?", activityID, slot).First(&code).Error
+	exists := err == nil
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if level == "" {
+		level = cfg.DefaultLevel
+		if exists && code.Level != "" {
+			level = code.Level
+		}
+	}
+
+	if target == "" {
+		if exists {
+			if err := tx.Delete(&code).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Activity{}).Where("id = ?", activityID).Update(SlotColumns[slot], "").Error
+	}
+
+	if exists && code.TargetURL == target && code.Level == level && code.Size == cfg.DefaultSize {
+		return nil
+	}
+
+	png, err := helpers.RenderQRPNG(target, level, cfg.DefaultSize)
+	if err != nil {
+		return err
+	}
+	svg, err := helpers.RenderQRSVG(target, level, cfg.DefaultSize)
+	if err != nil {
+		return err
+	}
+
+	code.ActivityID = activityID
+	code.Slot = slot
+	code.TargetURL = target
+	code.Level = level
+	code.Size = cfg.DefaultSize
+	code.PNG = png
+	code.SVG = svg
+	if err := tx.Save(&code).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Activity{}).
+		Where("id = ?", activityID).
+		Update(SlotColumns[slot], models.QRCodeURL(activityID, slot, "png")).Error
+}
@@ -0,0 +1,143 @@
+// Package recommend แนะนำกิจกรรมให้สมาชิกจากกิจกรรมที่เคยอ่านและกด favorite
+// โดยรวมความสนใจด้านเนื้อหา (เป้าหมายย่อย/หมวดหมู่ย่อย) กับ co-occurrence ระหว่างผู้ใช้
+// ตาราง co-occurrence และความนิยมถูกคำนวณใหม่ทั้งหมดเป็นระยะด้วย Scheduler ไม่ได้คำนวณตอน request
+package recommend
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"project-backend/config"
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// FavoriteWeight คือน้ำหนักของการกด favorite เทียบกับการอ่าน 1 ครั้ง
+const FavoriteWeight = 2
+
+// Stats สรุปผลการคำนวณโมเดลหนึ่งรอบ
+type Stats struct {
+	Pairs      int64     `json:"pairs"`
+	Popular    int64     `json:"popular"`
+	ComputedAt time.Time `json:"computed_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// interactions คือคู่ (ผู้ใช้, กิจกรรม) ที่ผู้ใช้เคยอ่านหรือกด favorite เฉพาะกิจกรรมที่ยังมีอยู่
+const interactionsCTE = `interactions AS (
+	SELECT i.user_id, i.activity_id FROM (
+		SELECT user_id, activity_id FROM user_read_histories
+		UNION
+		SELECT user_id, activity_id FROM user_favorites
+	) i JOIN activities ON activities.id = i.activity_id
+), counts AS (
+	SELECT activity_id, COUNT(*) AS n FROM interactions GROUP BY activity_id
+)`
+
+// Compute คำนวณตาราง activity_cooccurrences และ activity_popularities ใหม่ทั้งหมดใน Transaction เดียว
+// แต่ละกิจกรรมเก็บคู่ที่คะแนนสูงสุดไม่เกิน cfg.Neighbors รายการ
+func Compute(ctx context.Context, db *gorm.DB, cfg *config.RecommendConfig) (*Stats, error) {
+	started := time.Now()
+	stats := &Stats{ComputedAt: started}
+	since := started.AddDate(0, 0, -cfg.PopularityDays)
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM activity_cooccurrences").Error; err != nil {
+			return err
+		}
+		res := tx.Exec(`WITH `+interactionsCTE+`
+INSERT INTO activity_cooccurrences (activity_id, related_activity_id, user_count, score, computed_at)
+SELECT activity_id, related_activity_id, user_count, score, ? FROM (
+	SELECT a.activity_id, b.activity_id AS related_activity_id, COUNT(*) AS user_count,
+		COUNT(*) / SQRT(ca.n::float8 * cb.n) AS score,
+		ROW_NUMBER() OVER (PARTITION BY a.activity_id ORDER BY COUNT(*) / SQRT(ca.n::float8 * cb.n) DESC, b.activity_id) AS rank
+	FROM interactions a
+	JOIN interactions b ON b.user_id = a.user_id AND b.activity_id <> a.activity_id
+	JOIN counts ca ON ca.activity_id = a.activity_id
+	JOIN counts cb ON cb.activity_id = b.activity_id
+	GROUP BY a.activity_id, b.activity_id, ca.n, cb.n
+) ranked WHERE rank <= ?`, started, cfg.Neighbors)
+		if res.Error != nil {
+			return res.Error
+		}
+		stats.Pairs = res.RowsAffected
+
+		if err := tx.Exec("DELETE FROM activity_popularities").Error; err != nil {
+			return err
+		}
+		res = tx.Exec(`WITH readers AS (
+	SELECT activity_id, COUNT(DISTINCT user_id) AS n FROM user_read_histories WHERE updated_at >= ? GROUP BY activity_id
+), favorites AS (
+	SELECT activity_id, COUNT(*) AS n FROM user_favorites WHERE created_at >= ? GROUP BY activity_id
+)
+INSERT INTO activity_popularities (activity_id, readers, favorites, score, computed_at)
+SELECT a.id, COALESCE(r.n, 0), COALESCE(f.n, 0), COALESCE(r.n, 0) + ? * COALESCE(f.n, 0), ?
+FROM activities a
+LEFT JOIN readers r ON r.activity_id = a.id
+LEFT JOIN favorites f ON f.activity_id = a.id
+WHERE r.n IS NOT NULL OR f.n IS NOT NULL`, since, since, FavoriteWeight, started)
+		if res.Error != nil {
+			return res.Error
+		}
+		stats.Popular = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats.DurationMS = time.Since(started).Milliseconds()
+	return stats, nil
+}
+
+// Scheduler คำนวณโมเดลใหม่ทุก cfg.Interval (ปิดได้ด้วย Interval <= 0) และกันไม่ให้คำนวณซ้อนกัน
+type Scheduler struct {
+	db  *gorm.DB
+	cfg *config.RecommendConfig
+
+	mu sync.Mutex
+}
+
+func NewScheduler(db *gorm.DB, cfg *config.RecommendConfig) *Scheduler {
+	return &Scheduler{db: db, cfg: cfg}
+}
+
+// Start คำนวณทันทีหนึ่งรอบแล้วคำนวณซ้ำตามรอบเวลาจนกว่า ctx จะถูกยกเลิก
+func (s *Scheduler) Start(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		log.Println("recommend: scheduled recompute disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if stats, err := s.Run(ctx); err != nil {
+				log.Printf("recommend: recompute failed: %v", err)
+			} else {
+				log.Printf("recommend: %d co-occurrence pairs, %d popular activities (%dms)", stats.Pairs, stats.Popular, stats.DurationMS)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run คำนวณโมเดลหนึ่งรอบ ถ้ามีรอบอื่นกำลังทำอยู่จะรอให้เสร็จก่อน
+func (s *Scheduler) Run(ctx context.Context) (*Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Compute(ctx, s.db, s.cfg)
+}
+
+// LastComputed คืนเวลาที่คำนวณโมเดลครั้งล่าสุด (zero ถ้ายังไม่เคยคำนวณ)
+func LastComputed(db *gorm.DB) (time.Time, error) {
+	var row models.ActivityPopularity
+	err := db.Select("computed_at").Order("computed_at DESC").Limit(1).Find(&row).Error
+	return row.ComputedAt, err
+}
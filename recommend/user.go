@@ -0,0 +1,238 @@
+package recommend
+
+import (
+	"sort"
+
+	"project-backend/config"
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SourcePersonalized มาจากความสนใจของผู้ใช้และ co-occurrence
+	SourcePersonalized = "personalized"
+	// SourcePopular มาจากความนิยม ใช้กับผู้ใช้ใหม่หรือเติมเมื่อรายการแนะนำเฉพาะบุคคลมีไม่พอ
+	SourcePopular = "popular"
+)
+
+// Recommendation คะแนนทุกค่าอยู่ในช่วง 0-1 (normalize ด้วยค่าสูงสุดของรายการที่ได้ในแต่ละแหล่ง)
+type Recommendation struct {
+	ActivityID         uint    `json:"activity_id"`
+	Score              float64 `json:"score"`
+	ContentScore       float64 `json:"content_score"`
+	CollaborativeScore float64 `json:"collaborative_score"`
+	Source             string  `json:"source"`
+}
+
+// ForUser แนะนำกิจกรรมไม่เกิน limit รายการให้ userID โดยไม่รวมกิจกรรมที่ผู้ใช้อ่านหรือกด favorite แล้ว
+func ForUser(db *gorm.DB, cfg *config.RecommendConfig, userID uint, limit int) ([]Recommendation, error) {
+	engaged, err := engagement(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []Recommendation{}
+	if len(engaged) > 0 {
+		content, err := contentScores(db, engaged)
+		if err != nil {
+			return nil, err
+		}
+		collaborative, err := collaborativeScores(db, engaged)
+		if err != nil {
+			return nil, err
+		}
+		normalize(content)
+		normalize(collaborative)
+
+		candidates := map[uint]*Recommendation{}
+		get := func(id uint) *Recommendation {
+			r := candidates[id]
+			if r == nil {
+				r = &Recommendation{ActivityID: id, Source: SourcePersonalized}
+				candidates[id] = r
+			}
+			return r
+		}
+		for id, score := range content {
+			get(id).ContentScore = score
+		}
+		for id, score := range collaborative {
+			get(id).CollaborativeScore = score
+		}
+		for _, r := range candidates {
+			r.Score = cfg.ContentWeight*r.ContentScore + (1-cfg.ContentWeight)*r.CollaborativeScore
+			if r.Score > 0 {
+				out = append(out, *r)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool {
+			if out[i].Score != out[j].Score {
+				return out[i].Score > out[j].Score
+			}
+			return out[i].ActivityID > out[j].ActivityID
+		})
+		if len(out) > limit {
+			out = out[:limit]
+		}
+	}
+
+	if len(out) < limit {
+		exclude := make(map[uint]bool, len(engaged)+len(out))
+		for id := range engaged {
+			exclude[id] = true
+		}
+		for _, r := range out {
+			exclude[r.ActivityID] = true
+		}
+		popular, err := popularFill(db, exclude, limit-len(out))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, popular...)
+	}
+	return out, nil
+}
+
+// engagement คืนน้ำหนักความสนใจต่อกิจกรรม: อ่านแล้วได้ 1 กด favorite ได้เพิ่ม FavoriteWeight
+func engagement(db *gorm.DB, userID uint) (map[uint]float64, error) {
+	var read, favorites []uint
+	if err := db.Model(&models.UserReadHistory{}).Where("user_id = ?", userID).Pluck("activity_id", &read).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.UserFavorite{}).Where("user_id = ?", userID).Pluck("activity_id", &favorites).Error; err != nil {
+		return nil, err
+	}
+	weights := make(map[uint]float64, len(read)+len(favorites))
+	for _, id := range read {
+		weights[id] += 1
+	}
+	for _, id := range favorites {
+		weights[id] += FavoriteWeight
+	}
+	return weights, nil
+}
+
+type tagRow struct {
+	ActivityID uint
+	TagID      uint
+}
+
+// contentScores สร้าง profile จากเป้าหมายย่อยและหมวดหมู่ย่อยของกิจกรรมที่ผู้ใช้สนใจ
+// แล้วให้คะแนนกิจกรรมอื่นตามผลรวมน้ำหนักของ tag ที่ตรงกับ profile (เฉพาะกิจกรรมที่เผยแพร่แล้ว)
+func contentScores(db *gorm.DB, engaged map[uint]float64) (map[uint]float64, error) {
+	ids := keys(engaged)
+	scores := map[uint]float64{}
+	for _, link := range []struct{ table, column string }{
+		{"activity_selected_sub_goals", "activity_sub_goal_id"},
+		{"activity_selected_sub_categories", "activity_sub_category_id"},
+	} {
+		var own []tagRow
+		if err := db.Table(link.table).
+			Select("activity_id, "+link.column+" AS tag_id").
+			Where("activity_id IN ?", ids).
+			Scan(&own).Error; err != nil {
+			return nil, err
+		}
+		profile := map[uint]float64{}
+		for _, row := range own {
+			profile[row.TagID] += engaged[row.ActivityID]
+		}
+		if len(profile) == 0 {
+			continue
+		}
+
+		var others []tagRow
+		if err := db.Table(link.table+" AS s").
+			Select("s.activity_id, s."+link.column+" AS tag_id").
+			Joins("JOIN activities a ON a.id = s.activity_id AND a.status = ?", models.ActivityStatusPublished).
+			Where("s."+link.column+" IN ?", keys(profile)).
+			Scan(&others).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range others {
+			if _, seen := engaged[row.ActivityID]; !seen {
+				scores[row.ActivityID] += profile[row.TagID]
+			}
+		}
+	}
+	return scores, nil
+}
+
+// collaborativeScores รวมคะแนน co-occurrence ของกิจกรรมที่ผู้ใช้สนใจ ถ่วงด้วยน้ำหนักความสนใจ
+// ตัดกิจกรรมที่ยังไม่เผยแพร่ออกตั้งแต่ตอน query เพราะตาราง co-occurrence คำนวณไว้ล่วงหน้าและอาจมีฉบับร่าง
+func collaborativeScores(db *gorm.DB, engaged map[uint]float64) (map[uint]float64, error) {
+	var rows []models.ActivityCooccurrence
+	if err := db.Table("activity_cooccurrences AS c").
+		Select("c.*").
+		Joins("JOIN activities a ON a.id = c.related_activity_id AND a.status = ?", models.ActivityStatusPublished).
+		Where("c.activity_id IN ?", keys(engaged)).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	scores := map[uint]float64{}
+	for _, row := range rows {
+		if _, seen := engaged[row.RelatedActivityID]; !seen {
+			scores[row.RelatedActivityID] += row.Score * engaged[row.ActivityID]
+		}
+	}
+	return scores, nil
+}
+
+// popularFill เติมกิจกรรมยอดนิยมที่เผยแพร่แล้ว ต้องกรองฉบับร่างก่อน Limit ไม่เช่นนั้นรายการจะได้ไม่ครบ n
+func popularFill(db *gorm.DB, exclude map[uint]bool, n int) ([]Recommendation, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	var rows []models.ActivityPopularity
+	if err := db.Table("activity_popularities AS p").
+		Select("p.*").
+		Joins("JOIN activities a ON a.id = p.activity_id AND a.status = ?", models.ActivityStatusPublished).
+		Order("p.score DESC, p.activity_id DESC").
+		Limit(n + len(exclude)).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	var top float64
+	if len(rows) > 0 {
+		top = rows[0].Score
+	}
+	out := make([]Recommendation, 0, n)
+	for _, row := range rows {
+		if len(out) == n {
+			break
+		}
+		if exclude[row.ActivityID] {
+			continue
+		}
+		r := Recommendation{ActivityID: row.ActivityID, Source: SourcePopular}
+		if top > 0 {
+			r.Score = row.Score / top
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func normalize(scores map[uint]float64) {
+	var top float64
+	for _, s := range scores {
+		if s > top {
+			top = s
+		}
+	}
+	if top == 0 {
+		return
+	}
+	for id, s := range scores {
+		scores[id] = s / top
+	}
+}
+
+func keys(m map[uint]float64) []uint {
+	ids := make([]uint, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,69 @@
+package related
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache เก็บผลของ Find ต่อกิจกรรมไว้ตามเวลา ttl
+// การเปลี่ยนเป้าหมายย่อย/หมวดหมู่ย่อยของกิจกรรมหนึ่งกระทบคะแนนของกิจกรรมอื่นด้วย จึงล้างทั้งหมดด้วย Reset
+// เมธอดทั้งหมดใช้กับ *Cache ที่เป็น nil ได้ (ไม่ cache)
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]entry
+}
+
+type entry struct {
+	matches []Match
+	expires time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: map[uint]entry{}}
+}
+
+func (c *Cache) Get(activityID uint) ([]Match, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[activityID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, activityID)
+		return nil, false
+	}
+	return e.matches, true
+}
+
+func (c *Cache) Set(activityID uint, matches []Match) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[activityID] = entry{matches: matches, expires: now.Add(c.ttl)}
+}
+
+// Reset ล้างผลลัพธ์ทั้งหมด เรียกเมื่อความสัมพันธ์ของกิจกรรมกับ taxonomy เปลี่ยน
+func (c *Cache) Reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[uint]entry{}
+}
@@ -0,0 +1,169 @@
+// Package related หากิจกรรมที่คล้ายกันจากเป้าหมายย่อยและหมวดหมู่ย่อยที่เลือกร่วมกัน
+// พร้อมเหตุผลของการจับคู่ เพื่อแสดงเป็น "กิจกรรมที่เกี่ยวข้อง" ในหน้ากิจกรรม
+package related
+
+import (
+	"sort"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// น้ำหนักของการจับคู่แต่ละแบบ เป้าหมายย่อยเดียวกันสำคัญที่สุด
+// ส่วนเป้าหมายหลัก/หมวดหมู่หลักเดียวกันนับครั้งเดียวต่อรายการหลัก แม้จะมีรายการย่อยร่วมกันหลายรายการ
+const (
+	WeightSubGoal     = 3
+	WeightSubCategory = 2
+	WeightGoal        = 1
+	WeightCategory    = 1
+)
+
+// Reason คือ taxonomy ที่กิจกรรมสองรายการมีร่วมกัน Kind ใช้ค่าเดียวกับ models.TaxonomyKind*
+type Reason struct {
+	Kind   string `json:"kind"`
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+type Match struct {
+	ActivityID uint     `json:"activity_id"`
+	Score      int      `json:"score"`
+	Reasons    []Reason `json:"reasons"`
+}
+
+type link struct {
+	ActivityID uint
+	ChildID    uint
+	ParentID   uint
+}
+
+type side struct {
+	childKind, parentKind     string
+	childWeight, parentWeight int
+	childNames, parentNames   map[uint]string
+	links                     []link
+}
+
+// Find คืนกิจกรรมที่เกี่ยวข้องกับ activityID สูงสุด limit รายการ เรียงตามคะแนนแล้วตามกิจกรรมล่าสุด
+// กิจกรรมที่ไม่มีอะไรร่วมกันเลยและกิจกรรมฉบับร่างจะไม่ถูกคืน
+func Find(db *gorm.DB, activityID uint, limit int) ([]Match, error) {
+	var activity models.Activity
+	if err := db.Preload("SubGoals").Preload("SubCategories").First(&activity, activityID).Error; err != nil {
+		return nil, err
+	}
+
+	goals := side{
+		childKind: models.TaxonomyKindSubGoal, parentKind: models.TaxonomyKindGoal,
+		childWeight: WeightSubGoal, parentWeight: WeightGoal,
+		childNames: map[uint]string{}, parentNames: map[uint]string{},
+	}
+	for _, sg := range activity.SubGoals {
+		goals.childNames[sg.ID] = sg.SubGoalName
+		goals.parentNames[sg.GoalID] = ""
+	}
+	categories := side{
+		childKind: models.TaxonomyKindSubCategory, parentKind: models.TaxonomyKindCategory,
+		childWeight: WeightSubCategory, parentWeight: WeightCategory,
+		childNames: map[uint]string{}, parentNames: map[uint]string{},
+	}
+	for _, sc := range activity.SubCategories {
+		categories.childNames[sc.ID] = sc.SubCategoryName
+		categories.parentNames[sc.CategoryID] = ""
+	}
+
+	if len(goals.parentNames) > 0 {
+		ids := keys(goals.parentNames)
+		var rows []models.ActivityGoal
+		if err := db.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, g := range rows {
+			goals.parentNames[g.ID] = g.GoalName
+		}
+		// กิจกรรมอื่นที่เลือกเป้าหมายย่อยใดก็ได้ภายใต้เป้าหมายหลักเดียวกัน (รวมเป้าหมายย่อยเดียวกันด้วย)
+		if err := db.Table("activity_selected_sub_goals AS s").
+			Select("s.activity_id, s.activity_sub_goal_id AS child_id, g.goal_id AS parent_id").
+			Joins("JOIN activity_sub_goals g ON g.id = s.activity_sub_goal_id").
+			Joins("JOIN activities a ON a.id = s.activity_id AND a.status = ?", models.ActivityStatusPublished).
+			Where("s.activity_id <> ? AND g.goal_id IN ?", activityID, ids).
+			Scan(&goals.links).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(categories.parentNames) > 0 {
+		ids := keys(categories.parentNames)
+		var rows []models.ActivityMainCategory
+		if err := db.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range rows {
+			categories.parentNames[c.ID] = c.CategoryName
+		}
+		if err := db.Table("activity_selected_sub_categories AS s").
+			Select("s.activity_id, s.activity_sub_category_id AS child_id, c.category_id AS parent_id").
+			Joins("JOIN activity_sub_categories c ON c.id = s.activity_sub_category_id").
+			Joins("JOIN activities a ON a.id = s.activity_id AND a.status = ?", models.ActivityStatusPublished).
+			Where("s.activity_id <> ? AND c.category_id IN ?", activityID, ids).
+			Scan(&categories.links).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	matches := map[uint]*Match{}
+	for _, s := range []side{goals, categories} {
+		seen := map[Reason]map[uint]bool{}
+		add := func(activityID uint, r Reason) {
+			if seen[r] == nil {
+				seen[r] = map[uint]bool{}
+			}
+			if seen[r][activityID] {
+				return
+			}
+			seen[r][activityID] = true
+			m := matches[activityID]
+			if m == nil {
+				m = &Match{ActivityID: activityID}
+				matches[activityID] = m
+			}
+			m.Score += r.Weight
+			m.Reasons = append(m.Reasons, r)
+		}
+		for _, l := range s.links {
+			if name, ok := s.childNames[l.ChildID]; ok {
+				add(l.ActivityID, Reason{Kind: s.childKind, ID: l.ChildID, Name: name, Weight: s.childWeight})
+			}
+			add(l.ActivityID, Reason{Kind: s.parentKind, ID: l.ParentID, Name: s.parentNames[l.ParentID], Weight: s.parentWeight})
+		}
+	}
+
+	out := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		sort.SliceStable(m.Reasons, func(i, j int) bool {
+			if m.Reasons[i].Weight != m.Reasons[j].Weight {
+				return m.Reasons[i].Weight > m.Reasons[j].Weight
+			}
+			return m.Reasons[i].ID < m.Reasons[j].ID
+		})
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].ActivityID > out[j].ActivityID
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func keys(m map[uint]string) []uint {
+	ids := make([]uint, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,224 @@
+package richtext
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var htmlTag = regexp.MustCompile(`(?i)</?(p|br|div|span|b|strong|i|em|u|s|del|strike|ul|ol|li|h[1-6]|a|blockquote|code|pre|hr|table|tr|td|th|font)\b[^>]*>`)
+
+// LooksLikeHTML คืน true เมื่อข้อความมีแท็ก HTML ที่พบบ่อยจากการคัดลอกมาวาง
+// ใช้แยกข้อความเดิมที่ต้องแปลงเป็น Markdown ออกจากข้อความธรรมดา/Markdown ที่ใช้ได้อยู่แล้ว
+func LooksLikeHTML(s string) bool {
+	return htmlTag.MatchString(s)
+}
+
+// FromHTML แปลง HTML เดิมเป็น Markdown โดยคงโครงสร้างที่ Render รองรับ
+// (ย่อหน้า ตัวหนา/เอียง รายการ หัวข้อ ลิงก์ อ้างอิง โค้ด) แท็กอื่นเหลือไว้เฉพาะข้อความ
+// ส่วน script/style และแท็กที่ไม่รู้จักจะไม่ถูกส่งต่อไปเป็น HTML อีก
+func FromHTML(source string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(source), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return source
+	}
+	w := &mdWriter{}
+	for _, n := range nodes {
+		w.node(n)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(w.String(), "\n\n"))
+}
+
+type mdWriter struct {
+	strings.Builder
+	lists []listState
+	pre   bool
+}
+
+type listState struct {
+	ordered bool
+	next    int
+}
+
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+
+func (w *mdWriter) block() {
+	s := w.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	if strings.HasSuffix(s, "\n") {
+		w.WriteString("\n")
+		return
+	}
+	w.WriteString("\n\n")
+}
+
+func (w *mdWriter) line() {
+	if s := w.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		w.WriteString("\n")
+	}
+}
+
+// space เว้นวรรคหนึ่งช่องระหว่างข้อความ ยกเว้นต้นบรรทัดหรือมีช่องว่างอยู่แล้ว
+func (w *mdWriter) space() {
+	if s := w.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+		w.WriteString(" ")
+	}
+}
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// wrap ครอบข้อความ inline ด้วยเครื่องหมาย Markdown โดยย้ายช่องว่างหัวท้ายออกไปนอกเครื่องหมาย
+// (Markdown ไม่นับ "** คำ **" เป็นตัวหนา) และข้ามไปเลยเมื่อไม่มีข้อความข้างใน
+func (w *mdWriter) wrap(n *html.Node, mark string) {
+	inner := &mdWriter{}
+	inner.children(n)
+	text := inner.String()
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		if text != "" {
+			w.space()
+		}
+		return
+	}
+	if text[0] == ' ' || text[0] == '\n' {
+		w.space()
+	}
+	w.WriteString(mark + trimmed + mark)
+	if last := text[len(text)-1]; last == ' ' || last == '\n' {
+		w.space()
+	}
+}
+
+func (w *mdWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre {
+			w.WriteString(n.Data)
+			return
+		}
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" || unicode.IsSpace(rune(n.Data[0])) {
+			w.space()
+		}
+		if text == "" {
+			return
+		}
+		w.WriteString(mdEscaper.Replace(text))
+		if unicode.IsSpace(rune(n.Data[len(n.Data)-1])) {
+			w.space()
+		}
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Title:
+		return
+	case atom.Br:
+		w.WriteString("\n")
+	case atom.Hr:
+		w.block()
+		w.WriteString("---")
+		w.block()
+	case atom.P, atom.Div, atom.Table:
+		w.block()
+		w.children(n)
+		w.block()
+	case atom.Tr:
+		w.line()
+		w.children(n)
+		w.line()
+	case atom.Td, atom.Th:
+		w.children(n)
+		w.WriteString(" ")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block()
+		level := int(n.Data[1] - '0')
+		w.WriteString(strings.Repeat("#", level) + " ")
+		w.children(n)
+		w.block()
+	case atom.B, atom.Strong:
+		w.wrap(n, "**")
+	case atom.I, atom.Em:
+		w.wrap(n, "*")
+	case atom.S, atom.Del, atom.Strike:
+		w.wrap(n, "~~")
+	case atom.Code:
+		if w.pre {
+			w.children(n)
+			return
+		}
+		w.wrap(n, "`")
+	case atom.Pre:
+		w.block()
+		w.WriteString("```\n")
+		w.pre = true
+		w.children(n)
+		w.pre = false
+		w.line()
+		w.WriteString("```")
+		w.block()
+	case atom.Blockquote:
+		w.block()
+		inner := &mdWriter{}
+		inner.children(n)
+		for _, l := range strings.Split(strings.TrimSpace(inner.String()), "\n") {
+			w.WriteString(strings.TrimRight("> "+l, " ") + "\n")
+		}
+		w.block()
+	case atom.A:
+		href := ""
+		for _, attr := range n.Attr {
+			if attr.Key == "href" {
+				href = strings.TrimSpace(attr.Val)
+			}
+		}
+		if href == "" {
+			w.children(n)
+			return
+		}
+		w.WriteString("[")
+		w.children(n)
+		w.WriteString("](" + strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(href) + ")")
+	case atom.Ul, atom.Ol:
+		if len(w.lists) == 0 {
+			w.block()
+		} else {
+			w.line()
+		}
+		w.lists = append(w.lists, listState{ordered: n.DataAtom == atom.Ol, next: 1})
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.block()
+		}
+	case atom.Li:
+		w.line()
+		marker := "- "
+		if depth := len(w.lists); depth > 0 {
+			w.WriteString(strings.Repeat("   ", depth-1))
+			if list := &w.lists[depth-1]; list.ordered {
+				marker = strconv.Itoa(list.next) + ". "
+				list.next++
+			}
+		}
+		w.WriteString(marker)
+		w.children(n)
+		w.line()
+	default:
+		w.children(n)
+	}
+}
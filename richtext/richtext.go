@@ -0,0 +1,77 @@
+// Package richtext แปลงข้อความ Markdown ของกิจกรรม (ขั้นตอน ข้อเสนอแนะ พฤติกรรมที่สังเกตได้)
+// เป็น HTML ที่ผ่านการกรองด้วย allow-list แล้ว ให้หน้าเว็บแสดงผลได้โดยไม่เปิดช่อง XSS
+package richtext
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// markdown ไม่เปิด WithUnsafe จึงไม่ส่ง HTML ดิบใน Markdown ออกไป (goldmark แทนด้วย comment)
+// WithHardWraps ให้การขึ้นบรรทัดใหม่เดียวแสดงเป็น <br> เหมือนข้อความเดิมที่ผู้ดูแลพิมพ์ไว้
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+	goldmark.WithRendererOptions(goldmarkhtml.WithHardWraps()),
+)
+
+// policy คือแท็กและ attribute ที่ยอมให้ออกไปถึงหน้าเว็บ นอกเหนือจากนี้ถูกตัดทิ้งทั้งหมด
+// ลิงก์รับเฉพาะ http/https/mailto และติด rel="nofollow"
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(
+		"p", "br", "hr", "strong", "em", "del",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"ul", "ol", "li", "blockquote", "pre", "code",
+		"table", "thead", "tbody", "tr", "th", "td",
+	)
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	return p
+}
+
+// Render แปลง Markdown เป็น HTML ที่กรองแล้ว ข้อความว่างได้สตริงว่าง
+func Render(source string) string {
+	if strings.TrimSpace(source) == "" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		// goldmark คืน error เฉพาะตอนเขียน buffer ไม่สำเร็จ ให้แสดงเป็นข้อความธรรมดาแทน
+		return "<p>" + html.EscapeString(source) + "</p>"
+	}
+	return strings.TrimSpace(policy.Sanitize(buf.String()))
+}
+
+var (
+	cellEnds   = regexp.MustCompile(`(</th>|</td>)\n?`)
+	lineEnds   = regexp.MustCompile(`(<br/?>|</li>|</tr>)\n?`)
+	blockEnds  = regexp.MustCompile(`(</p>|</h[1-6]>|</ul>|</ol>|</table>|</blockquote>|</pre>)\n?`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// PlainText แปลง Markdown เป็นข้อความธรรมดา (ไม่มีเครื่องหมาย Markdown) สำหรับ PDF และที่อื่นที่แสดง HTML ไม่ได้
+func PlainText(source string) string {
+	if strings.TrimSpace(source) == "" {
+		return ""
+	}
+	rendered := Render(source)
+	rendered = strings.ReplaceAll(rendered, "<li>", "• ")
+	rendered = cellEnds.ReplaceAllString(rendered, "  ")
+	rendered = lineEnds.ReplaceAllString(rendered, "\n")
+	rendered = blockEnds.ReplaceAllString(rendered, "\n\n")
+	text := html.UnescapeString(bluemonday.StrictPolicy().Sanitize(rendered))
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
@@ -0,0 +1,64 @@
+package richtext
+
+import "testing"
+
+// HTML ที่คัดลอกมาวางต้องแปลงเป็น Markdown แล้วแสดงผลได้โครงสร้างเดิม
+func TestFromHTMLRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"paragraphs", "<p>ปรบมือ</p><p>ร้องเพลง</p>", "<p>ปรบมือ</p>\n<p>ร้องเพลง</p>"},
+		{"inline", "<p><b>Tip:</b> clap <i>slowly</i></p>", "<p><strong>Tip:</strong> clap <em>slowly</em></p>"},
+		{"line break", "first<br>second", "<p>first<br>\nsecond</p>"},
+		{"list", "<ol><li>one</li><li>two</li></ol>", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>"},
+		{"heading", "<h2>Goal</h2><div>text</div>", "<h2>Goal</h2>\n<p>text</p>"},
+		{"link", `<a href="https://example.com/a b">site</a>`, `<p><a href="https://example.com/a%20b" rel="nofollow">site</a></p>`},
+		{"markdown characters stay literal", "<p>2*3 = 6 and a_b</p>", "<p>2*3 = 6 and a_b</p>"},
+		{"script dropped", "<p>ok</p><script>alert(1)</script>", "<p>ok</p>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			md := FromHTML(tc.html)
+			if got := Render(md); got != tc.want {
+				t.Errorf("Render(FromHTML(%q))\nmarkdown: %q\n     got: %q\n    want: %q", tc.html, md, got, tc.want)
+			}
+		})
+	}
+}
+
+// Markdown ที่ Render แล้วแปลงกลับด้วย FromHTML ต้องแสดงผลเหมือนเดิม
+func TestRenderFromHTMLRoundTrip(t *testing.T) {
+	sources := []string{
+		"**Tip:** clap *slowly*",
+		"# Warm up\n\nSing together",
+		"1. one\n2. two",
+		"- drum\n- bell",
+		"> quote",
+		"line one\nline two",
+		"use `code` here",
+		"~~old~~ new",
+		"[site](https://example.com)",
+	}
+	for _, md := range sources {
+		want := Render(md)
+		back := FromHTML(want)
+		if got := Render(back); got != want {
+			t.Errorf("round trip of %q\nmarkdown: %q\n     got: %q\n    want: %q", md, back, got, want)
+		}
+	}
+}
+
+func TestLooksLikeHTML(t *testing.T) {
+	for s, want := range map[string]bool{
+		"<p>text</p>":      true,
+		"a<br>b":           true,
+		"**bold** and 2<3": false,
+		"plain text":       false,
+	} {
+		if got := LooksLikeHTML(s); got != want {
+			t.Errorf("LooksLikeHTML(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
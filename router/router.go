@@ -82,8 +82,15 @@ package router
 
 import (
 	"os"
+	"project-backend/config"
 	"project-backend/controllers"
+	"project-backend/handout"
+	"project-backend/jobs"
 	"project-backend/middleware"
+	"project-backend/recommend"
+	"project-backend/related"
+	"project-backend/storage"
+	"project-backend/thumbnail"
 	"strings"
 	"time"
 
@@ -92,7 +99,26 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRouter(db *gorm.DB) *gin.Engine {
+// Services รวม dependency ที่ handler ต้องใช้นอกเหนือจากฐานข้อมูล
+type Services struct {
+	Store      storage.Storage
+	StorageCfg *config.StorageConfig
+	Thumbs     *thumbnail.Worker
+	QR         *config.QRConfig
+	PDF        *config.PDFConfig
+	Fonts      *handout.Fonts // nil ถ้าไม่มีไฟล์ฟอนต์ (ปิดการส่งออก PDF)
+	Jobs       *jobs.Runner
+	Import     *config.ImportConfig
+	Locale     *config.LocaleConfig
+	Related    *related.Cache
+	RelatedCfg *config.RelatedConfig
+
+	Recommender  *recommend.Scheduler
+	RecommendCfg *config.RecommendConfig
+	Observation  *config.ObservationConfig
+}
+
+func SetupRouter(db *gorm.DB, svc Services) *gin.Engine {
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -102,10 +128,14 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     getAllowedOrigins(),
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(middleware.Locale(svc.Locale))
+
+	r.GET("/media/:key", controllers.ServeMedia(db, svc.Store))
 
 	auth := r.Group("/auth")
 	{
@@ -123,25 +153,102 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 		apiPublic.GET("/activities/:id", controllers.GetActivityByID(db))
 
 		apiPublic.GET("/activities/search", controllers.SearchAndFilterActivities(db)) //แก้แล้ว
+		apiPublic.GET("/variants/search", controllers.SearchActivityVariants(db))
 		apiPublic.GET("/activities/:id/stats", controllers.GetActivityStats(db))
+		apiPublic.GET("/activities/:id/reviews", controllers.ListActivityReviews(db))
+		apiPublic.GET("/activities/:id/related", controllers.GetRelatedActivities(db, svc.Related, svc.RelatedCfg))
+		apiPublic.GET("/activities/:id/qr/:slot", controllers.GetActivityQRCode(db, svc.QR))
+		apiPublic.GET("/activities/:id/export.pdf", controllers.ExportActivityPDF(db, svc.Store, svc.Fonts))
+		apiPublic.GET("/activities/booklet.pdf", controllers.ExportActivityBooklet(db, svc.Store, svc.Fonts, svc.PDF))
 
 		apiPublic.GET("/master-goals", controllers.GetActivityMasterGoals(db))
 		apiPublic.GET("/master-categories", controllers.GetActivityMasterCategories(db))
 
+		apiPublic.GET("/equipment", controllers.ListEquipment(db))
+		apiPublic.GET("/target-populations", controllers.ListTargetPopulations(db))
+
+		apiPublic.GET("/songs", controllers.ListSongs(db))
+		apiPublic.GET("/songs/:id", controllers.GetSongByID(db))
+		apiPublic.GET("/songs/:id/activities", controllers.ListSongActivities(db))
+
 	}
 
 	apiPrivate := r.Group("/api", middleware.AuthMiddleware("member", "admin"))
 	{
 
 		apiPrivate.GET("/profile", controllers.GetProfile(db))
-		apiPrivate.PUT("/profile", controllers.UpdateProfile(db))
-		apiPrivate.DELETE("/profile/image", controllers.DeleteProfileImage(db))
+		apiPrivate.PUT("/profile", controllers.UpdateProfile(db, svc.Store))
+		apiPrivate.DELETE("/profile/image", controllers.DeleteProfileImage(db, svc.Store))
+
+		apiPrivate.POST("/media", controllers.UploadMedia(db, svc.Store, svc.StorageCfg, svc.Thumbs))
+		apiPrivate.GET("/media/:id", controllers.GetMedia(db))
+		apiPrivate.DELETE("/media/:id", controllers.DeleteMedia(db, svc.Store))
 
 		apiPrivate.POST("/activities/:id/favorite", controllers.ToggleFavorite(db))
 		apiPrivate.GET("/favorites", controllers.ListFavorites(db))
 
 		apiPrivate.POST("/activities/:id/read", controllers.RecordReadHistory(db))
 		apiPrivate.GET("/read-history", controllers.ListReadHistory(db))
+		apiPrivate.GET("/activities/:id/review", controllers.GetMyReview(db))
+		apiPrivate.PUT("/activities/:id/review", controllers.UpsertMyReview(db))
+		apiPrivate.DELETE("/activities/:id/review", controllers.DeleteMyReview(db))
+		apiPrivate.POST("/reviews/:id/report", controllers.ReportReview(db))
+		apiPrivate.GET("/recommendations", controllers.GetRecommendations(db, svc.RecommendCfg))
+
+		apiPrivate.GET("/organizations", controllers.ListMyOrganizations(db))
+		apiPrivate.GET("/clients", controllers.ListClients(db))
+		apiPrivate.POST("/clients", controllers.CreateClient(db))
+
+		// ผู้รับบริการรายคน: middleware ตรวจว่าเป็นเจ้าของหรือสมาชิกหน่วยงานเจ้าของก่อนถึง controller
+		client := apiPrivate.Group("/clients/:id", middleware.ClientAccess(db))
+		{
+			client.GET("", controllers.GetClient(db))
+			client.PUT("", controllers.UpdateClient(db))
+			client.DELETE("", controllers.DeleteClient(db))
+			client.GET("/progress", controllers.GetClientProgress(db))
+			client.GET("/plans", controllers.ListClientPlans(db))
+			client.POST("/plans", controllers.CreateClientPlan(db))
+		}
+		plan := apiPrivate.Group("/plans/:id", middleware.TherapyPlanAccess(db))
+		{
+			plan.GET("", controllers.GetPlan(db))
+			plan.PUT("", controllers.UpdatePlan(db))
+			plan.DELETE("", controllers.DeletePlan(db))
+			plan.PUT("/status", controllers.UpdatePlanStatus(db))
+			plan.GET("/versions", controllers.ListPlanVersions(db))
+			plan.GET("/versions/:version", controllers.GetPlanVersion(db))
+			plan.GET("/suggestions", controllers.GetPlanSuggestions(db))
+			plan.GET("/review", controllers.GetPlanReview(db))
+		}
+
+		apiPrivate.GET("/client-groups", controllers.ListClientGroups(db))
+		apiPrivate.POST("/client-groups", controllers.CreateClientGroup(db))
+		clientGroup := apiPrivate.Group("/client-groups/:id", middleware.ClientGroupAccess(db))
+		{
+			clientGroup.GET("", controllers.GetClientGroup(db))
+			clientGroup.PUT("", controllers.UpdateClientGroup(db))
+			clientGroup.DELETE("", controllers.DeleteClientGroup(db))
+		}
+
+		apiPrivate.GET("/sessions", controllers.ListSessionCalendar(db))
+		apiPrivate.POST("/sessions", controllers.CreateSession(db))
+		session := apiPrivate.Group("/sessions/:id", middleware.SessionAccess(db))
+		{
+			session.GET("", controllers.GetSession(db))
+			session.PUT("", controllers.UpdateSession(db))
+			session.DELETE("", controllers.DeleteSession(db))
+			session.POST("/duplicate", controllers.DuplicateSession(db))
+			session.POST("/reschedule", controllers.RescheduleSession(db))
+			session.PUT("/items/order", controllers.ReorderSessionItems(db))
+			session.GET("/observations", controllers.ListSessionObservations(db, svc.Observation))
+			session.POST("/observations", controllers.CreateSessionObservations(db, svc.Observation))
+		}
+		observation := apiPrivate.Group("/observations/:id", middleware.ObservationAccess(db))
+		{
+			observation.PUT("", controllers.UpdateObservation(db, svc.Observation))
+			observation.DELETE("", controllers.DeleteObservation(db, svc.Observation))
+		}
+		apiPrivate.GET("/rating-scales", controllers.ListRatingScales(db))
 
 	}
 
@@ -149,9 +256,55 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	{
 		admin.GET("/users", controllers.ListAllUsers(db))
 
-		admin.POST("/activities", controllers.CreateActivity(db))
-		admin.PUT("/activities/:id", controllers.UpdateActivity(db))
-		admin.DELETE("/activities/:id", controllers.DeleteActivity(db))
+		admin.GET("/activities", controllers.ListAdminActivities(db))
+		admin.GET("/activities/:id", controllers.GetAdminActivity(db))
+		admin.POST("/activities", controllers.CreateActivity(db, svc.QR, svc.Related))
+		admin.POST("/activities/:id/clone", controllers.CloneActivity(db, svc.QR))
+		admin.PUT("/activities/:id", controllers.UpdateActivity(db, svc.QR, svc.Related))
+		admin.PATCH("/activities/:id", controllers.PatchActivity(db, svc.QR, svc.Related))
+		admin.PUT("/activities/:id/qr/:slot", controllers.SetActivityQRCode(db, svc.QR))
+		admin.DELETE("/activities/:id", controllers.DeleteActivity(db, svc.Related))
+
+		admin.POST("/equipment", controllers.CreateEquipment(db))
+		admin.PUT("/equipment/:id", controllers.UpdateEquipment(db))
+		admin.DELETE("/equipment/:id", controllers.DeleteEquipment(db))
+		admin.POST("/target-populations", controllers.CreateTargetPopulation(db))
+		admin.PUT("/target-populations/:id", controllers.UpdateTargetPopulation(db))
+		admin.DELETE("/target-populations/:id", controllers.DeleteTargetPopulation(db))
+
+		admin.POST("/songs", controllers.CreateSong(db))
+		admin.PUT("/songs/:id", controllers.UpdateSong(db))
+		admin.DELETE("/songs/:id", controllers.DeleteSong(db))
+
+		// :kind = goals, sub-goals, categories, sub-categories
+		admin.POST("/taxonomy/:kind", controllers.CreateTaxonomyEntry(db))
+		admin.PUT("/taxonomy/:kind/order", controllers.ReorderTaxonomy(db))
+		admin.PUT("/taxonomy/:kind/:id", controllers.UpdateTaxonomyEntry(db))
+		admin.POST("/taxonomy/:kind/:id/merge", controllers.MergeTaxonomyEntry(db, svc.Related))
+		admin.POST("/taxonomy/:kind/:id/retire", controllers.RetireTaxonomyEntry(db))
+		admin.POST("/taxonomy/:kind/:id/restore", controllers.RestoreTaxonomyEntry(db))
+		admin.GET("/taxonomy/audit", controllers.ListTaxonomyAudit(db))
+
+		admin.GET("/translations", controllers.ListTranslations(db))
+		admin.GET("/translations/report", controllers.TranslationReport(db, svc.Locale))
+		admin.PUT("/translations/:entity_type/:entity_id/:locale", controllers.UpsertTranslations(db, svc.Locale))
+
+		admin.POST("/imports/activities", controllers.ImportActivities(db, svc.Jobs, svc.QR, svc.Import))
+		admin.GET("/library/export", controllers.ExportLibrary(db, svc.Store))
+		admin.GET("/reviews", controllers.ListReviewModeration(db))
+		admin.PUT("/reviews/:id/hide", controllers.HideReview(db))
+		admin.PUT("/reviews/:id/restore", controllers.RestoreReview(db))
+		admin.PUT("/reviews/:id/dismiss-reports", controllers.DismissReviewReports(db))
+		admin.POST("/recommendations/recompute", controllers.RecomputeRecommendations(svc.Jobs, svc.Recommender))
+		admin.POST("/library/import", controllers.ImportLibrary(db, svc.Store, svc.Jobs, svc.Thumbs, svc.QR, svc.StorageCfg, svc.Import))
+		admin.GET("/jobs", controllers.ListJobs(db))
+		admin.GET("/jobs/:id", controllers.GetJob(db))
+
+		admin.POST("/rating-scales", controllers.CreateRatingScale(db))
+		admin.GET("/organizations", controllers.ListOrganizations(db))
+		admin.POST("/organizations", controllers.CreateOrganization(db))
+		admin.POST("/organizations/:id/members", controllers.AddOrganizationMember(db))
+		admin.DELETE("/organizations/:id/members/:user_id", controllers.RemoveOrganizationMember(db))
 
 		admin.POST("/roles", controllers.AdminCreateUser(db)) //แก้แล้ว
 		admin.DELETE("/roles/:id", controllers.AdminDeleteUser(db))
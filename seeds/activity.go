@@ -1,6 +1,7 @@
 package seeds
 
 import (
+	"fmt"
 	"log"
 	"project-backend/models"
 
@@ -89,8 +90,17 @@ func SeedActivityGoals(db *gorm.DB) error {
 		},
 	}
 
+	// เป้าหมายย่อยถูกเพิ่มแยกด้วย ON CONFLICT DO NOTHING เหมือนหมวดหมู่ย่อยใน SeedMainCategories
 	for _, goal := range goals {
-		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&goal).Error; err != nil {
+		subGoals := goal.SubGoals
+		for i := range subGoals {
+			subGoals[i].GoalID = goal.ID
+		}
+		err := db.Clauses(clause.OnConflict{DoNothing: true}).Omit("SubGoals").Create(&goal).Error
+		if err == nil {
+			err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&subGoals).Error
+		}
+		if err != nil {
 			log.Printf("❌ Error seeding Goal %d: %v", goal.ID, err)
 		}
 	}
@@ -143,11 +153,20 @@ func SeedMainCategories(db *gorm.DB) error {
 		},
 	}
 
+	// หมวดหมู่ย่อยถูกเพิ่มแยกด้วย ON CONFLICT DO NOTHING เช่นเดียวกับหมวดหมู่หลัก
+	// (ถ้าให้ gorm บันทึกผ่าน association จะ update category_id ของแถวเดิม)
+	// เพื่อไม่ให้การ seed ทุกครั้งที่เริ่มระบบเขียนทับชื่อ ลำดับ การย้าย การยกเลิก และการรวมที่ admin แก้ไว้
 	for _, cat := range categories {
 
-		err := db.Session(&gorm.Session{FullSaveAssociations: true}).
-			Clauses(clause.OnConflict{DoNothing: true}).
-			Create(&cat).Error
+		subCategories := cat.SubCategories
+		for i := range subCategories {
+			subCategories[i].CategoryID = cat.ID
+		}
+
+		err := db.Clauses(clause.OnConflict{DoNothing: true}).Omit("SubCategories").Create(&cat).Error
+		if err == nil {
+			err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&subCategories).Error
+		}
 
 		if err != nil {
 			log.Printf("❌ Error seeding Category %d: %v", cat.ID, err)
@@ -159,3 +178,19 @@ func SeedMainCategories(db *gorm.DB) error {
 	return nil
 
 }
+
+// SyncTaxonomySequences เลื่อน sequence ของตาราง taxonomy ให้เลย ID ที่ seed ใส่ไว้ตรง ๆ
+// ไม่อย่างนั้นรายการที่ admin สร้างใหม่จะได้ ID ชนกับข้อมูล seed
+func SyncTaxonomySequences(db *gorm.DB) error {
+	tables := []string{"activity_goals", "activity_sub_goals", "activity_main_categories", "activity_sub_categories"}
+	for _, table := range tables {
+		err := db.Exec(fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE((SELECT MAX(id) FROM %[1]s), 0) + 1, false)",
+			table,
+		)).Error
+		if err != nil {
+			return fmt.Errorf("sync sequence for %s: %w", table, err)
+		}
+	}
+	return nil
+}
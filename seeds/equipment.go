@@ -0,0 +1,39 @@
+package seeds
+
+import (
+	"log"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedEquipment สร้างรายการอุปกรณ์พื้นฐานที่ใช้บ่อยในกิจกรรมดนตรีบำบัด
+func SeedEquipment(db *gorm.DB) error {
+	items := []models.Equipment{
+		{Name: "กลองมือ", Type: "percussion"},
+		{Name: "กลอง", Type: "percussion"},
+		{Name: "แทมบูรีน", Type: "percussion"},
+		{Name: "ลูกแซก", Type: "percussion"},
+		{Name: "ฉิ่ง", Type: "percussion"},
+		{Name: "ระฆังมือ", Type: "percussion"},
+		{Name: "ไม้เคาะจังหวะ", Type: "percussion"},
+		{Name: "ไซโลโฟน", Type: "melodic"},
+		{Name: "กีตาร์", Type: "melodic"},
+		{Name: "คีย์บอร์ด", Type: "melodic"},
+		{Name: "ผ้าพันคอ", Type: "prop"},
+		{Name: "ลูกบอล", Type: "prop"},
+		{Name: "ลำโพง", Type: "audio"},
+	}
+
+	for _, item := range items {
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoNothing: true,
+		}).Create(&item).Error; err != nil {
+			log.Printf("❌ Error seeding Equipment %s: %v", item.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package seeds
+
+import (
+	"log"
+
+	"project-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// SeedRatingScales สร้างมาตรวัด 1-5 ระดับเป็นมาตรวัดเริ่มต้นของผลการสังเกต
+func SeedRatingScales(db *gorm.DB) error {
+	scale := models.RatingScale{
+		Name:        "ระดับความสำเร็จ 1-5",
+		Description: "ระดับที่ผู้รับบริการแสดงพฤติกรรมเป้าหมายได้ในกิจกรรม",
+	}
+	if err := db.Where(models.RatingScale{Name: scale.Name}).FirstOrCreate(&scale).Error; err != nil {
+		return err
+	}
+
+	var defaults int64
+	if err := db.Model(&models.RatingScale{}).Where("is_default = ?", true).Count(&defaults).Error; err != nil {
+		return err
+	}
+	if defaults == 0 {
+		if err := db.Model(&scale).Update("is_default", true).Error; err != nil {
+			return err
+		}
+	}
+
+	var levels int64
+	if err := db.Model(&models.RatingScaleLevel{}).Where("rating_scale_id = ?", scale.ID).Count(&levels).Error; err != nil {
+		return err
+	}
+	if levels > 0 {
+		return nil
+	}
+	labels := []string{"ยังทำไม่ได้", "ทำได้เล็กน้อย", "ทำได้บางส่วน", "ทำได้เกือบทั้งหมด", "ทำได้ทั้งหมด"}
+	rows := make([]models.RatingScaleLevel, 0, len(labels))
+	for i, label := range labels {
+		rows = append(rows, models.RatingScaleLevel{RatingScaleID: scale.ID, Value: i + 1, Label: label})
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		return err
+	}
+	log.Println("Seeded RatingScale:", scale.Name)
+	return nil
+}
@@ -0,0 +1,51 @@
+package seeds
+
+import (
+	"log"
+
+	"project-backend/i18n"
+	"project-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedTargetPopulations สร้างกลุ่มเป้าหมายที่พบบ่อยในงานดนตรีบำบัด พร้อมชื่อภาษาอังกฤษ
+func SeedTargetPopulations(db *gorm.DB) error {
+	items := []struct {
+		Name    string
+		English string
+	}{
+		{"กลุ่มอาการออทิสติก", "Autism spectrum"},
+		{"ภาวะสมองเสื่อม", "Dementia"},
+		{"สมองพิการ", "Cerebral palsy"},
+		{"ดาวน์ซินโดรม", "Down syndrome"},
+		{"สมาธิสั้น", "ADHD"},
+		{"บกพร่องทางสติปัญญา", "Intellectual disability"},
+		{"บกพร่องทางการได้ยิน", "Hearing impairment"},
+		{"บกพร่องทางการมองเห็น", "Visual impairment"},
+		{"ผู้ป่วยโรคหลอดเลือดสมอง", "Stroke"},
+		{"ผู้สูงอายุ", "Older adults"},
+		{"ผู้ป่วยจิตเวช", "Mental health conditions"},
+	}
+
+	for _, item := range items {
+		population := models.TargetPopulation{Name: item.Name}
+		if err := db.Where(models.TargetPopulation{Name: item.Name}).FirstOrCreate(&population).Error; err != nil {
+			log.Printf("❌ Error seeding TargetPopulation %s: %v", item.Name, err)
+			continue
+		}
+
+		translation := models.Translation{
+			EntityType: i18n.EntityTargetPopulation,
+			EntityID:   population.ID,
+			Field:      "name",
+			Locale:     "en",
+			Value:      item.English,
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&translation).Error; err != nil {
+			log.Printf("❌ Error seeding TargetPopulation translation %s: %v", item.Name, err)
+		}
+	}
+	return nil
+}
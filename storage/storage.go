@@ -0,0 +1,62 @@
+// Package storage เก็บไฟล์ที่ผู้ใช้อัปโหลดผ่าน interface เดียว
+// เพื่อให้สลับระหว่าง local disk กับ S3-compatible (เช่น MinIO) ได้ด้วยการตั้งค่า
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"project-backend/config"
+)
+
+// ErrNotFound ถูกคืนเมื่อไม่พบไฟล์ตาม key ที่ระบุ
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey ถูกคืนเมื่อ key ไม่ปลอดภัย (เช่น มี ".." หรือเป็น absolute path)
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+type Storage interface {
+	// Put บันทึกไฟล์ไว้ที่ key (เขียนทับถ้ามีอยู่แล้ว)
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open เปิดไฟล์เพื่ออ่าน ผู้เรียกต้อง Close เอง
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete ลบไฟล์ ถ้าไม่มีไฟล์อยู่แล้วถือว่าสำเร็จ
+	Delete(ctx context.Context, key string) error
+	// Name คืนชื่อ backend ที่บันทึกไว้คู่กับ media record
+	Name() string
+}
+
+// New สร้าง Storage ตาม cfg.Backend ("local" หรือ "s3")
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocal(cfg.LocalDir)
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
+
+// cleanKey ตรวจสอบว่า key เป็น path แบบ relative ที่ไม่หลุดออกนอก root
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
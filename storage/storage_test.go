@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// testStorage ทดสอบพฤติกรรมที่ทุก backend ต้องมีเหมือนกันตาม interface Storage
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+	key := "activity_cover/2026/01/cover.png"
+
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open before Put: got %v, want ErrNotFound", err)
+	}
+
+	put := func(body string) {
+		t.Helper()
+		if err := s.Put(ctx, key, strings.NewReader(body), int64(len(body)), "image/png"); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	read := func() string {
+		t.Helper()
+		r, err := s.Open(ctx, key)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(data)
+	}
+
+	put("first")
+	if got := read(); got != "first" {
+		t.Fatalf("Open after Put: got %q, want %q", got, "first")
+	}
+	// Put ซ้ำ key เดิมต้องเขียนทับ
+	put("second")
+	if got := read(); got != "second" {
+		t.Fatalf("Open after overwrite: got %q, want %q", got, "second")
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open after Delete: got %v, want ErrNotFound", err)
+	}
+	// ลบไฟล์ที่ไม่มีอยู่แล้วถือว่าสำเร็จ
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete missing key: %v", err)
+	}
+
+	for _, bad := range []string{"", "/etc/passwd", "../secret", "a/../../b", `a\b`} {
+		if err := s.Put(ctx, bad, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): got %v, want ErrInvalidKey", bad, err)
+		}
+		if _, err := s.Open(ctx, bad); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Open(%q): got %v, want ErrInvalidKey", bad, err)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	testStorage(t, s)
+}
+
+func TestS3(t *testing.T) {
+	faker := gofakes3.New(s3mem.New(), gofakes3.WithLogger(gofakes3.DiscardLog()))
+	server := httptest.NewServer(faker.Server())
+	defer server.Close()
+
+	// NewS3 สร้าง bucket ให้เองถ้ายังไม่มี
+	s, err := NewS3(S3Options{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "test",
+		SecretKey: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	testStorage(t, s)
+}
@@ -0,0 +1,229 @@
+// Package thumbnail สร้างภาพย่อย (thumb, card, full) ของภาพที่อัปโหลดใน background
+// เพื่อไม่ให้การย่อภาพทำให้ request อัปโหลดช้า
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"project-backend/models"
+	"project-backend/storage"
+
+	"github.com/disintegration/imaging"
+	"github.com/gen2brain/webp"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// Spec กำหนดขนาดของ variant แต่ละแบบ Crop=true จะตัดภาพให้เต็มกรอบ (ใช้กับ thumb)
+type Spec struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+	Crop      bool
+}
+
+var DefaultSpecs = []Spec{
+	{Name: "thumb", MaxWidth: 200, MaxHeight: 200, Crop: true},
+	{Name: "card", MaxWidth: 640, MaxHeight: 640},
+	{Name: "full", MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// Purposes คือประเภทไฟล์ที่ต้องสร้าง variants
+var Purposes = []string{
+	models.MediaPurposeActivityCover,
+	models.MediaPurposeSongImage,
+	models.MediaPurposeProfile,
+}
+
+// NeedsVariants บอกว่าไฟล์ purpose นี้ต้องสร้าง variants หรือไม่
+func NeedsVariants(purpose string) bool {
+	for _, p := range Purposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
+// variants ทุกขนาดเก็บเป็น WebP แบบ lossy ซึ่งเล็กกว่า JPEG ที่คุณภาพใกล้กันและเก็บความโปร่งใสได้ จึงไม่ต้องแยกใช้ PNG
+const (
+	webpQuality     = 80
+	variantExt      = ".webp"
+	variantMimeType = "image/webp"
+)
+
+type Worker struct {
+	db      *gorm.DB
+	store   storage.Storage
+	specs   []Spec
+	queue   chan uint
+	workers int
+
+	mu       sync.Mutex
+	inflight map[uint]bool
+}
+
+func NewWorker(db *gorm.DB, store storage.Storage, workers int) *Worker {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Worker{
+		db:       db,
+		store:    store,
+		specs:    DefaultSpecs,
+		queue:    make(chan uint, 256),
+		workers:  workers,
+		inflight: map[uint]bool{},
+	}
+}
+
+// Start เริ่ม worker และตรวจหางานที่ค้าง (pending) เป็นระยะ
+// เช่นงานที่ค้างจากการ restart หรือคิวเต็มตอนอัปโหลด
+func (w *Worker) Start(ctx context.Context) {
+	for i := 0; i < w.workers; i++ {
+		go w.run(ctx)
+	}
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			w.enqueuePending()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Enqueue ส่ง media เข้าคิว ถ้าคิวเต็มจะถูกหยิบขึ้นมาทำในรอบตรวจงานค้างถัดไป
+func (w *Worker) Enqueue(mediaID uint) {
+	w.mu.Lock()
+	if w.inflight[mediaID] {
+		w.mu.Unlock()
+		return
+	}
+	w.inflight[mediaID] = true
+	w.mu.Unlock()
+
+	select {
+	case w.queue <- mediaID:
+	default:
+		w.done(mediaID)
+	}
+}
+
+func (w *Worker) done(mediaID uint) {
+	w.mu.Lock()
+	delete(w.inflight, mediaID)
+	w.mu.Unlock()
+}
+
+func (w *Worker) enqueuePending() {
+	var ids []uint
+	if err := w.db.Model(&models.Media{}).
+		Where("variant_status = ?", models.VariantStatusPending).
+		Order("id ASC").
+		Limit(cap(w.queue)).
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("thumbnail: could not load pending media: %v", err)
+		return
+	}
+	for _, id := range ids {
+		w.Enqueue(id)
+	}
+}
+
+func (w *Worker) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-w.queue:
+			status := models.VariantStatusReady
+			if err := w.Process(ctx, id); err != nil {
+				log.Printf("thumbnail: media %d: %v", id, err)
+				status = models.VariantStatusFailed
+			}
+			w.db.Model(&models.Media{}).Where("id = ?", id).Update("variant_status", status)
+			w.done(id)
+		}
+	}
+}
+
+// Process สร้าง variants ทั้งหมดของ media หนึ่งไฟล์ (เขียนทับ variants เดิมถ้ามี)
+// ภาพถูก decode แล้ว encode ใหม่ จึงไม่มี EXIF หรือ metadata อื่นติดไปด้วย
+// แต่จะหมุนภาพตาม EXIF orientation ก่อน เพื่อให้ภาพจากกล้องมือถือไม่ตะแคง
+func (w *Worker) Process(ctx context.Context, mediaID uint) error {
+	var media models.Media
+	if err := w.db.First(&media, mediaID).Error; err != nil {
+		return err
+	}
+
+	reader, err := w.store.Open(ctx, media.StorageKey)
+	if err != nil {
+		return err
+	}
+	src, err := imaging.Decode(reader, imaging.AutoOrientation(true))
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	base := strings.TrimSuffix(media.Key, path.Ext(media.Key))
+	dir := path.Dir(media.StorageKey)
+
+	for _, spec := range w.specs {
+		var img image.Image
+		if spec.Crop {
+			img = imaging.Fill(src, spec.MaxWidth, spec.MaxHeight, imaging.Center, imaging.Lanczos)
+		} else {
+			img = imaging.Fit(src, spec.MaxWidth, spec.MaxHeight, imaging.Lanczos)
+		}
+
+		var buf bytes.Buffer
+		if err := webp.Encode(&buf, img, webp.Options{Quality: webpQuality}); err != nil {
+			return fmt.Errorf("encode %s: %w", spec.Name, err)
+		}
+
+		variant := models.MediaVariant{
+			MediaID:     media.ID,
+			Name:        spec.Name,
+			Key:         base + "-" + spec.Name + variantExt,
+			ContentType: variantMimeType,
+			Width:       img.Bounds().Dx(),
+			Height:      img.Bounds().Dy(),
+			Size:        int64(buf.Len()),
+		}
+		variant.StorageKey = path.Join(dir, variant.Key)
+
+		if err := w.store.Put(ctx, variant.StorageKey, &buf, variant.Size, variantMimeType); err != nil {
+			return fmt.Errorf("store %s: %w", spec.Name, err)
+		}
+
+		var existing models.MediaVariant
+		err := w.db.Where("media_id = ? AND name = ?", media.ID, spec.Name).First(&existing).Error
+		if err == nil {
+			variant.ID = existing.ID
+			if err := w.db.Save(&variant).Error; err != nil {
+				return err
+			}
+			// variants ที่สร้างไว้ก่อนเปลี่ยนเป็น WebP ใช้ key (.jpg/.png) คนละไฟล์ จึงลบไฟล์เดิมทิ้ง
+			if existing.StorageKey != variant.StorageKey {
+				w.store.Delete(ctx, existing.StorageKey)
+			}
+		} else if err := w.db.Create(&variant).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}